@@ -0,0 +1,9 @@
+// Package api содержит машиночитаемый контракт JSON API (OpenAPI 3)
+package api
+
+import _ "embed"
+
+// OpenAPI — спецификация OpenAPI 3 в формате JSON
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -1,25 +1,33 @@
 // Package main - основной сервис API для обработки заказов
-// Запускает HTTP-сервер, Kafka consumer и инициализирует зависимости
+// Запускает HTTP- и gRPC-серверы, Kafka consumer и инициализирует зависимости
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"WBtech_l0/internal/auth"
 	"WBtech_l0/internal/config"
+	grpcdelivery "WBtech_l0/internal/delivery/grpc"
 	httpdelivery "WBtech_l0/internal/delivery/http"
+	"WBtech_l0/internal/events"
+	"WBtech_l0/internal/health"
+	"WBtech_l0/internal/i18n"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/ratelimit"
 	"WBtech_l0/internal/repository/cache"
 	"WBtech_l0/internal/repository/postgres"
 	"WBtech_l0/internal/telemetry"
@@ -28,94 +36,273 @@ import (
 )
 
 func main() {
+	var (
+		configFlags config.Flags
+		checkConfig bool
+	)
+	configFlags.Register(flag.CommandLine)
+	flag.BoolVar(&checkConfig, "check-config", false, "validate config, print effective values (secrets redacted) and exit")
+	flag.Parse()
+
 	// Загружаем конфигурацию
-	configPath := filepath.Join("configs", "config.yaml")
-	cfg := config.LoadConfig(configPath)
-	log.Printf("Config loaded from: %s", configPath)
+	cfg, err := configFlags.Load()
+	if checkConfig {
+		os.Exit(runCheckConfig(cfg, err))
+	}
+	if err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	slog.Info("config loaded", slog.String("path", configFlags.Path))
 
-	// Запускаем миграции
-	if err := runMigrations(*cfg); err != nil {
-		log.Fatalf("Migration error: %v", err)
+	// Аутентификация API
+	authn, err := auth.NewAuthenticator(cfg.Auth)
+	if err != nil {
+		logging.Fatal("auth init error", logging.Err(err))
+	}
+	if !authn.Enabled() {
+		slog.Warn("API authentication is disabled (auth.enabled=false)")
+	}
+
+	// Шаблоны, статика и локализация веб-интерфейса
+	assets, err := httpdelivery.NewAssets(cfg.Web)
+	if err != nil {
+		logging.Fatal("web assets error", logging.Err(err))
+	}
+	bundle, err := i18n.New(cfg.I18n)
+	if err != nil {
+		logging.Fatal("i18n error", logging.Err(err))
+	}
+	spec, err := httpdelivery.NewAPISpec(cfg.OpenAPI)
+	if err != nil {
+		logging.Fatal("OpenAPI spec error", logging.Err(err))
 	}
 
 	// Создаем контекст для graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Инициализация трейсера (Jaeger). URL можно вынести в конфиг
-	shutdownTracer := telemetry.InitTracer("order-service", cfg.Telemetry.OTLPEndpoint)
+	// Трассировка: экспортер, коллектор, сэмплер и атрибуты ресурса — из telemetry.*
+	telemetry.SetSampler(cfg.Telemetry.Sampler, cfg.Telemetry.SampleRatio)
+	shutdownTracer := telemetry.InitTracer(cfg.Telemetry)
 	defer shutdownTracer()
 
 	// Репозиторий
 	repo := postgres.InitDB(*cfg)
 	defer func() {
 		if err := repo.Close(); err != nil {
-			log.Printf("failed to close repository: %v", err)
+			slog.Warn("failed to close repository", logging.Err(err))
 		}
 	}()
+	prometheus.MustRegister(repo.StatsCollector())
 
 	// Инициализируем кеш
 	orderCache := cache.NewOrderCache(cfg.Cache.DefaultTTL, cfg.Cache.MaxSize)
+	prometheus.MustRegister(orderCache.Collector())
 
-	// Восстанавливаем кеш из БД
-	log.Println("Loading cache from database...")
-	if err := postgres.LoadCacheFromDB(ctx, repo, orderCache); err != nil {
-		log.Printf("failed to load cache from DB: %v", err)
-		// Закрываем ресурсы вручную
-		if closeErr := repo.Close(); closeErr != nil {
-			log.Printf("error closing repository: %v", closeErr)
-		}
-		shutdownTracer()
-		cancel()
-		log.Fatal("exiting due to cache load error") //nolint:gocritic
-	}
+	// Шина событий для потоковых API
+	broker := events.NewBroker(cfg.Events.HistorySize)
+
+	// Бизнес-метрики заказов — в отдельном реестре, /metrics отдаёт его вместе с глобальным
+	businessRegistry := prometheus.NewRegistry()
+	businessMetrics := telemetry.NewBusinessMetrics(businessRegistry, cfg.Telemetry.Business)
 
-	log.Printf("Cache loaded with %d orders", len(orderCache.GetAll()))
 	// Usecase
-	orderUsecase := usecase.NewOrderUsecase(repo, orderCache)
+	orderUsecase := usecase.NewOrderUsecase(repo, orderCache, usecase.Publishers{broker, businessMetrics})
+
+	// Kafka consumer
+	consumer, err := kafka.NewConsumer(*cfg, orderUsecase, businessMetrics)
+	if err != nil {
+		logging.Fatal("Kafka consumer error", logging.Err(err))
+	}
+
+	// Проверки готовности. Этапы запуска отмечаются флагами, зависимости проверяются
+	// с таймаутом, а результаты кешируются, чтобы частые пробы не нагружали Postgres и Kafka
+	migrationsReady := health.NewFlag("migrations", "migrations are not applied yet")
+	cacheReady := health.NewFlag("cache", "cache warm-up in progress")
+	checks := health.NewRegistry()
+	checks.Register(migrationsReady, 0, 0)
+	checks.Register(cacheReady, 0, 0)
+	checks.Register(health.CheckerFunc("postgres", repo.PingContext), cfg.Health.CheckTimeout, cfg.Health.CacheTTL)
+	checks.Register(consumer, cfg.Health.CheckTimeout, cfg.Health.CacheTTL)
 
 	// Канал для сигналов ОС
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Запускаем Kafka consumer
+	// Ограничение частоты запросов
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryBackend(10*time.Minute), cfg.RateLimit)
+	}
+	// Публичный поиск посылки ограничивается всегда, даже при rate_limit.enabled=false
+	lookupLimiter := ratelimit.NewLimiter(ratelimit.NewMemoryBackend(10*time.Minute), cfg.RateLimit.PublicLookup.Config())
+
+	// Перечитывание конфигурации: настройки с тегом reload применяются без перезапуска
+	reloader := config.NewReloader(cfg, configFlags.Load)
+	reloader.OnReload(func(prev, c *config.Config) {
+		orderCache.SetLimits(c.Cache.DefaultTTL, c.Cache.MaxSize)
+		if err := logging.ReloadLevel(prev.Log, c.Log); err != nil {
+			slog.Error("failed to set log level", logging.Err(err))
+		}
+		telemetry.SetSampler(c.Telemetry.Sampler, c.Telemetry.SampleRatio)
+		consumer.SetRetryBackoff(c.Kafka.RetryBackoff)
+		if limiter != nil {
+			limiter.SetLimits(c.RateLimit)
+		}
+		lookupLimiter.SetLimits(c.RateLimit.PublicLookup.Config())
+	})
 	go func() {
-		kafka.ConsumeKafka(ctx, *cfg, orderUsecase)
+		if err := reloader.Watch(ctx, configFlags.Path); err != nil {
+			slog.Error("config watch failed", logging.Err(err))
+		}
 	}()
-	log.Println("Kafka consumer started")
 
 	// Создаем и запускаем сервер
-	server := httpdelivery.NewServer(cfg, orderUsecase, repo, orderCache)
+	server := httpdelivery.NewServer(cfg, httpdelivery.Deps{
+		Usecase:  orderUsecase,
+		Exporter: repo,
+		Checks:   checks,
+		Cache:    orderCache,
+		Authn:    authn,
+		Limiter:  limiter,
+		Lookup:   lookupLimiter,
+		Broker:   broker,
+		Assets:   assets,
+		I18n:     bundle,
+		Spec:     spec,
+		Reloads:  reloader.Status,
+		Consumer: consumer,
+		Rewarm: func(ctx context.Context) error {
+			return postgres.LoadCacheFromDB(ctx, repo, orderCache)
+		},
+	})
+
+	// gRPC API для внутренних сервисов: те же usecase, шина событий, проверки и аутентификация
+	var grpcServer *grpcdelivery.Server
+	if cfg.GRPCServer.Enabled {
+		grpcServer = grpcdelivery.NewServer(cfg, grpcdelivery.Deps{
+			Usecase: orderUsecase,
+			Broker:  broker,
+			Checks:  checks,
+			Authn:   authn,
+		})
+	}
+
+	// pprof и состояние рантайма — на отдельном порту и только для администраторов
+	var diagServer *httpdelivery.DiagnosticsServer
+	if cfg.Diagnostics.Enabled {
+		diagServer = httpdelivery.NewDiagnosticsServer(cfg.Diagnostics, authn)
+	}
+
+	// shutdownServers останавливает HTTP-, gRPC-серверы и сервер диагностики в пределах общего таймаута
+	shutdownServers := func(ctx context.Context) {
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("HTTP server shutdown failed", logging.Err(err))
+		}
+		// лимитеры нужны только HTTP-серверу: после его остановки очистка корзин не нужна
+		for _, l := range []*ratelimit.Limiter{limiter, lookupLimiter} {
+			if l == nil {
+				continue
+			}
+			if err := l.Close(); err != nil {
+				slog.Warn("failed to stop rate limiter cleanup", logging.Err(err))
+			}
+		}
+		if grpcServer != nil {
+			if err := grpcServer.Shutdown(ctx); err != nil {
+				slog.Error("gRPC server shutdown failed", logging.Err(err))
+			}
+		}
+		if diagServer != nil {
+			if err := diagServer.Shutdown(ctx); err != nil {
+				slog.Error("diagnostics server shutdown failed", logging.Err(err))
+			}
+		}
+	}
 
 	// Добавляем отдельный HTTP-маршрут для метрик (можно на другом порту или на основном)
 	go func() {
-		http.Handle("/metrics", promhttp.Handler())
+		gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, businessRegistry}
+		// Свой ServeMux, а не http.DefaultServeMux: в него регистрируется net/http/pprof,
+		// а порт метрик не требует аутентификации
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+			promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})))
 		addr := ":" + cfg.Telemetry.MetricsPort
-		if err := http.ListenAndServe(addr, nil); err != nil { // стандартный порт для метрик
-			log.Printf("Metrics server error: %v", err)
+		if err := http.ListenAndServe(addr, mux); err != nil { // стандартный порт для метрик
+			slog.Error("metrics server failed", logging.Err(err))
 		}
 	}()
 
-	// Запускаем сервер в горутине
+	// Сервер запускается до миграций и прогрева кеша: /livez отвечает сразу,
+	// а /readyz возвращает 503, пока сервис не готов принимать трафик
 	go func() {
 		if err := server.Run(); err != nil {
-			log.Printf("Server error: %v", err)
+			slog.Error("HTTP server failed", logging.Err(err))
 			cancel()
 		}
 	}()
+	if grpcServer != nil {
+		go func() {
+			if err := grpcServer.Run(); err != nil {
+				slog.Error("gRPC server failed", logging.Err(err))
+				cancel()
+			}
+		}()
+	}
+	if diagServer != nil {
+		// сбой диагностики не останавливает сервис
+		go func() {
+			if err := diagServer.Run(); err != nil {
+				slog.Error("diagnostics server failed", logging.Err(err))
+			}
+		}()
+	}
+
+	// exitOnStartupError останавливает сервер, освобождает ресурсы и завершает процесс
+	exitOnStartupError := func(msg string, err error) {
+		slog.Error(msg, logging.Err(err))
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		shutdownServers(shutdownCtx)
+		if closeErr := repo.Close(); closeErr != nil {
+			slog.Warn("failed to close repository", logging.Err(closeErr))
+		}
+		shutdownTracer()
+		cancel()
+		logging.Fatal("exiting due to startup error", slog.String("reason", msg))
+	}
+
+	// Запускаем миграции
+	if err := runMigrations(*cfg); err != nil {
+		exitOnStartupError("Migration error", err)
+	}
+	migrationsReady.Set(true)
+
+	// Восстанавливаем кеш из БД
+	slog.Info("loading cache from database")
+	if err := postgres.LoadCacheFromDB(ctx, repo, orderCache); err != nil {
+		exitOnStartupError("failed to load cache from DB", err)
+	}
+	cacheReady.Set(true)
+	slog.Info("cache loaded", slog.Int("orders", len(orderCache.GetAll())))
+
+	// Запускаем Kafka consumer
+	go consumer.Run(ctx)
 
 	// Ждем сигнал завершения
 	<-sigChan
-	log.Println("Shutting down gracefully...")
+	slog.Info("shutting down gracefully")
 
 	// Даем время на завершение операций
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
-	// Останавливаем HTTP сервер
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Error during shutdown: %v", err)
-	}
+	// Останавливаем HTTP и gRPC серверы
+	shutdownServers(shutdownCtx)
 
 	// Останавливаем Kafka consumer
 	cancel()
@@ -123,7 +310,7 @@ func main() {
 	// Даем время на завершение Kafka consumer
 	time.Sleep(2 * time.Second)
 
-	log.Println("Shutdown complete")
+	slog.Info("shutdown complete")
 }
 
 func runMigrations(cfg config.Config) error {
@@ -136,13 +323,26 @@ func runMigrations(cfg config.Config) error {
 
 	defer func() {
 		if sourceErr, dbErr := m.Close(); sourceErr != nil || dbErr != nil {
-			log.Printf("failed to close migrator: sourceErr=%v, dbErr=%v", sourceErr, dbErr)
+			slog.Warn("failed to close migrator", slog.Any("source_error", sourceErr), slog.Any("db_error", dbErr))
 		}
 	}()
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
-	log.Println("Migrations applied successfully")
+	slog.Info("migrations applied")
 	return nil
 }
+
+// runCheckConfig печатает действующую конфигурацию или ошибки схемы и возвращает код выхода
+func runCheckConfig(cfg *config.Config, err error) int {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := cfg.WriteYAML(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+// Package main - утилита выгрузки заказов в NDJSON, CSV или Parquet
+// Читает заказы из БД серверным курсором и пишет в файл или stdout
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/export"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/repository/postgres"
+)
+
+func main() {
+	var (
+		configFlags config.Flags
+		formatName  string
+		from, to    string
+		outPath     string
+		pageSize    int
+	)
+
+	configFlags.Register(flag.CommandLine)
+	flag.StringVar(&formatName, "format", "ndjson", "output format: ndjson, csv or parquet")
+	flag.StringVar(&from, "from", "", "export orders created at or after this time (RFC3339 or YYYY-MM-DD)")
+	flag.StringVar(&to, "to", "", "export orders created before this time (RFC3339 or YYYY-MM-DD)")
+	flag.StringVar(&outPath, "out", "", "output file (default stdout)")
+	flag.IntVar(&pageSize, "page-size", 0, "orders per cursor page (default export.page_size from config)")
+	flag.Parse()
+
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		logging.Fatal("invalid -format", logging.Err(err))
+	}
+	filter, err := export.ParseFilter(from, to)
+	if err != nil {
+		logging.Fatal("invalid date range", logging.Err(err))
+	}
+
+	// Логи идут в stderr, поэтому выгрузку в stdout можно перенаправлять в файл
+	cfg, err := configFlags.Load()
+	if err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	slog.Info("config loaded", slog.String("path", configFlags.Path))
+	if pageSize <= 0 {
+		pageSize = cfg.Export.PageSize
+	}
+
+	repo := postgres.InitDB(*cfg)
+	defer func() {
+		if err := repo.Close(); err != nil {
+			slog.Warn("failed to close repository", logging.Err(err))
+		}
+	}()
+
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			logging.Fatal("failed to create output file", logging.Err(err))
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				slog.Warn("failed to close output file", logging.Err(err))
+			}
+		}()
+		out = f
+	}
+	buf := bufio.NewWriterSize(out, 64<<10)
+
+	// Ctrl+C прерывает выгрузку и закрывает курсор
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w, err := export.NewWriter(format, buf)
+	if err != nil {
+		logging.Fatal("failed to create writer", logging.Err(err))
+	}
+	start := time.Now()
+	n, err := export.Run(ctx, repo, filter, pageSize, w, nil)
+	if err != nil {
+		logging.Fatal("export failed", slog.Int("orders", n), logging.Err(err))
+	}
+	if err := buf.Flush(); err != nil {
+		logging.Fatal("failed to write output", logging.Err(err))
+	}
+	slog.Info("export completed", slog.Int("orders", n), slog.String("format", string(format)),
+		slog.Duration("duration", time.Since(start).Round(time.Millisecond)))
+}
@@ -7,7 +7,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,6 +15,8 @@ import (
 
 	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/logging"
+	orderkafka "WBtech_l0/internal/usecase/kafka"
 
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
@@ -22,14 +24,25 @@ import (
 
 func main() {
 	var (
-		msgType    = flag.String("type", "valid", "Type of message: valid or invalid")
-		count      = flag.Int("count", 1, "Number of messages to send")
-		interval   = flag.Duration("interval", 1*time.Second, "Interval between messages")
-		configPath = flag.String("config", "configs/config.yaml", "Path to config file")
+		msgType     = flag.String("type", "valid", "Type of message: valid or invalid")
+		count       = flag.Int("count", 1, "Number of messages to send")
+		interval    = flag.Duration("interval", 1*time.Second, "Interval between messages")
+		configFlags config.Flags
 	)
+	configFlags.Register(flag.CommandLine)
 	flag.Parse()
 
-	cfg := config.LoadConfig(*configPath)
+	cfg, err := configFlags.Load()
+	if err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	dialer, err := orderkafka.NewDialer(cfg.Kafka)
+	if err != nil {
+		logging.Fatal("Kafka config error", logging.Err(err))
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -40,21 +53,22 @@ func main() {
 		Balancer:     &kafka.LeastBytes{},
 		RequiredAcks: int(kafka.RequireOne),
 		Async:        false,
+		Dialer:       dialer,
 	})
 	defer func() {
 		if err := writer.Close(); err != nil {
-			log.Printf("failed to close Kafka writer: %v", err)
+			slog.Warn("failed to close Kafka writer", logging.Err(err))
 		}
 	}()
 
-	log.Printf("Producer started, sending %d %s message(s) to topic %s every %v",
-		*count, *msgType, cfg.Kafka.Topic, *interval)
+	slog.Info("producer started", slog.Int("count", *count), slog.String("type", *msgType),
+		slog.String(logging.KeyTopic, cfg.Kafka.Topic), slog.Duration("interval", *interval))
 
 	for i := 0; i < *count; i++ {
 		// Генерируем сообщение и отдельно получаем orderUID для ключа
 		msgData, orderUID, err := generateMessage(*msgType)
 		if err != nil {
-			log.Fatalf("Failed to generate message: %v", err) //nolint:gocritic
+			logging.Fatal("failed to generate message", logging.Err(err))
 		}
 
 		// Определяем ключ (для невалидных сообщений может быть пустым)
@@ -69,14 +83,14 @@ func main() {
 			Value: msgData,
 		})
 		if err != nil {
-			log.Printf("Failed to send message %d: %v", i+1, err)
+			slog.Error("failed to send message", slog.Int("n", i+1), logging.OrderUID(orderUID), logging.Err(err))
 		} else {
-			log.Printf("Message %d sent: order_uid=%s", i+1, orderUID)
+			slog.Info("message sent", slog.Int("n", i+1), logging.OrderUID(orderUID))
 		}
 		// Если контекст завершён (например, получен сигнал), выходим из цикла
 		select {
 		case <-ctx.Done():
-			log.Println("Shutdown signal received, stopping producer")
+			slog.Info("shutdown signal received, stopping producer")
 			return
 		default:
 		}
@@ -140,16 +154,16 @@ func loadValidOrderTemplate() domain.Order {
 	if err == nil {
 		defer func() {
 			if err := file.Close(); err != nil {
-				log.Printf("failed to close file: %v", err)
+				slog.Warn("failed to close file", slog.String("path", path), logging.Err(err))
 			}
 		}()
 		var order domain.Order
-		if err := json.NewDecoder(file).Decode(&order); err == nil {
+		if err = json.NewDecoder(file).Decode(&order); err == nil {
 			return order
 		}
-		log.Printf("Warning: cannot decode model.json: %v", err)
+		slog.Warn("cannot decode order template", slog.String("path", path), logging.Err(err))
 	}
-	log.Printf("Using hardcoded order template")
+	slog.Info("using hardcoded order template")
 	return hardcodedValidOrder()
 }
 
@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/logging"
 	"WBtech_l0/internal/repository/postgres"
+	orderkafka "WBtech_l0/internal/usecase/kafka"
 
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
@@ -21,40 +23,46 @@ import (
 func main() {
 	// Парсим аргументы командной строки
 	var (
-		configPath    string
+		configFlags   config.Flags
 		numOrders     int
 		sendToKafka   bool
 		clearExisting bool
 	)
 
-	flag.StringVar(&configPath, "config", "configs/config.yaml", "path to config file")
+	configFlags.Register(flag.CommandLine)
 	flag.IntVar(&numOrders, "count", 10, "number of test orders to create")
 	flag.BoolVar(&sendToKafka, "kafka", true, "send orders to Kafka")
 	flag.BoolVar(&clearExisting, "clear", false, "clear existing data before seeding")
 	flag.Parse()
 
 	// Загружаем конфигурацию
-	cfg := config.LoadConfig(configPath)
-	log.Printf("Config loaded from: %s", configPath)
+	cfg, err := configFlags.Load()
+	if err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		logging.Fatal("config error", logging.Err(err))
+	}
+	slog.Info("config loaded", slog.String("path", configFlags.Path))
 
 	// Подключаемся к базе данных
 	repo := postgres.InitDB(*cfg)
 	defer func() {
 		if err := repo.Close(); err != nil {
-			log.Printf("failed to close repository: %v", err)
+			slog.Warn("failed to close repository", logging.Err(err))
 		}
 	}()
 
 	// Опционально очищаем существующие данные
 	if clearExisting {
-		log.Println("Clearing existing data...")
+		slog.Info("clearing existing data")
 		if err := repo.ClearAll(context.Background()); err != nil {
-			log.Fatalf("Failed to clear database: %v", err) //nolint:gocritic
+			logging.Fatal("failed to clear database", logging.Err(err))
 		}
-		log.Println("Database cleared")
+		slog.Info("database cleared")
 	}
 	// Создаем тестовые заказы
-	log.Printf("Creating %d test orders...", numOrders)
+	slog.Info("creating test orders", slog.Int("count", numOrders))
 	orders := make([]domain.Order, 0, numOrders)
 
 	for i := 0; i < numOrders; i++ {
@@ -64,25 +72,25 @@ func main() {
 		// Сохраняем в БД
 		err := repo.SaveOrder(context.Background(), order)
 		if err != nil {
-			log.Printf("Failed to save order %s: %v", order.OrderUID, err)
+			slog.Error("failed to save order", logging.OrderUID(order.OrderUID), logging.Err(err))
 			continue
 		}
 
-		log.Printf("Order %d saved to DB: %s", i+1, order.OrderUID)
+		slog.Debug("order saved to DB", slog.Int("n", i+1), logging.OrderUID(order.OrderUID))
 	}
 
-	log.Printf("Successfully saved %d orders to database", len(orders))
+	slog.Info("orders saved to database", slog.Int("count", len(orders)))
 
 	// Опционально отправляем в Kafka
 	if sendToKafka && len(orders) > 0 {
-		log.Println("Sending orders to Kafka...")
+		slog.Info("sending orders to Kafka")
 		if err := sendOrdersToKafka(cfg, orders); err != nil {
-			log.Fatalf("Failed to send orders to Kafka: %v", err)
+			logging.Fatal("failed to send orders to Kafka", logging.Err(err))
 		}
-		log.Printf("Successfully sent %d orders to Kafka topic: %s", len(orders), cfg.Kafka.Topic)
+		slog.Info("orders sent to Kafka", slog.Int("count", len(orders)), slog.String(logging.KeyTopic, cfg.Kafka.Topic))
 	}
 
-	log.Println("Seed completed successfully!")
+	slog.Info("seed completed")
 }
 
 // createTestOrder создает тестовый заказ с уникальными данными
@@ -171,7 +179,12 @@ func generateDigits(n int) string {
 // sendOrdersToKafka отправляет заказы в Kafka
 func sendOrdersToKafka(cfg *config.Config, orders []domain.Order) error {
 	// Настройка Kafka writer
+	transport, err := orderkafka.NewTransport(cfg.Kafka)
+	if err != nil {
+		return fmt.Errorf("kafka transport: %w", err)
+	}
 	w := &kafka.Writer{
+		Transport:    transport,
 		Addr:         kafka.TCP(cfg.Kafka.Brokers),
 		Topic:        cfg.Kafka.Topic,
 		Balancer:     &kafka.LeastBytes{},
@@ -181,7 +194,7 @@ func sendOrdersToKafka(cfg *config.Config, orders []domain.Order) error {
 	}
 	defer func() {
 		if err := w.Close(); err != nil {
-			log.Printf("failed to close Kafka writer: %v", err)
+			slog.Warn("failed to close Kafka writer", logging.Err(err))
 		}
 	}()
 
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"WBtech_l0/internal/config"
+)
+
+const sha256Prefix = "sha256:"
+
+type apiKey struct {
+	name   string
+	hash   []byte
+	scopes []Scope
+}
+
+// APIKeyStore хранит хеши статических API-ключей
+type APIKeyStore struct {
+	keys []apiKey
+}
+
+// NewAPIKeyStore разбирает ключи из конфига. Хеш задаётся как "sha256:<hex>"
+func NewAPIKeyStore(cfgKeys []config.APIKeyConfig) (*APIKeyStore, error) {
+	store := &APIKeyStore{keys: make([]apiKey, 0, len(cfgKeys))}
+	for _, k := range cfgKeys {
+		if k.Name == "" {
+			return nil, fmt.Errorf("api key without name")
+		}
+		if !strings.HasPrefix(k.KeyHash, sha256Prefix) {
+			return nil, fmt.Errorf("api key %q: unsupported hash format, expected %s<hex>", k.Name, sha256Prefix)
+		}
+		hash, err := hex.DecodeString(strings.TrimPrefix(k.KeyHash, sha256Prefix))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %q: invalid sha256 hash", k.Name)
+		}
+		scopes, err := parseScopes(k.Scopes)
+		if err != nil {
+			return nil, fmt.Errorf("api key %q: %w", k.Name, err)
+		}
+		store.keys = append(store.keys, apiKey{name: k.Name, hash: hash, scopes: scopes})
+	}
+	return store, nil
+}
+
+// HashAPIKey возвращает хеш ключа в формате, который ожидает конфиг
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return sha256Prefix + hex.EncodeToString(sum[:])
+}
+
+// Authenticate ищет ключ по хешу. Сравнение выполняется за постоянное время
+func (s *APIKeyStore) Authenticate(key string) (Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.hash) == 1 {
+			return Principal{Subject: k.name, Method: MethodAPIKey, Scopes: k.scopes}, nil
+		}
+	}
+	return Principal{}, ErrInvalidCredentials
+}
+
+// parseScopes проверяет, что все права из списка известны
+func parseScopes(raw []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(raw))
+	for _, s := range raw {
+		switch Scope(s) {
+		case ScopeOrdersRead, ScopeOrdersReadPII, ScopeOrdersWrite, ScopeAdmin:
+			scopes = append(scopes, Scope(s))
+		default:
+			return nil, fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,151 @@
+// Package auth реализует аутентификацию клиентов API по статическим ключам и JWT
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/pii"
+)
+
+// Scope — право доступа к API
+type Scope string
+
+// Поддерживаемые права доступа
+const (
+	ScopeOrdersRead    Scope = "orders:read"
+	ScopeOrdersReadPII Scope = "orders:read_pii"
+	ScopeOrdersWrite   Scope = "orders:write"
+	ScopeAdmin         Scope = "admin"
+)
+
+// Способы аутентификации
+const (
+	MethodAPIKey   = "api_key"
+	MethodJWT      = "jwt"
+	MethodDisabled = "disabled"
+)
+
+// APIKeyHeader — заголовок, в котором передаётся статический API-ключ
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrNoCredentials — запрос не содержит ни API-ключа, ни токена
+	ErrNoCredentials = errors.New("no credentials provided")
+	// ErrInvalidCredentials — ключ или токен не прошли проверку
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal — аутентифицированный клиент API
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []Scope
+}
+
+// HasScope проверяет наличие права. Scope admin включает в себя все остальные
+func (p Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет клиента в контексте запроса
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext достаёт клиента из контекста запроса
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// VisibleOrder маскирует персональные данные заказа, если у клиента нет права
+// orders:read_pii. Без аутентифицированного клиента в контексте данные тоже маскируются.
+// Общее правило для всех транспортов (HTTP, gRPC)
+func VisibleOrder(ctx context.Context, order domain.Order) domain.Order {
+	if p, ok := PrincipalFromContext(ctx); ok && p.HasScope(ScopeOrdersReadPII) {
+		return order
+	}
+	return pii.RedactOrder(order)
+}
+
+// Authenticator проверяет учётные данные запроса
+type Authenticator struct {
+	enabled bool
+	keys    *APIKeyStore
+	jwt     *JWTVerifier
+}
+
+// NewAuthenticator создаёт Authenticator по настройкам из конфига.
+// Если аутентификация выключена, любой запрос получает все права
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{enabled: cfg.Enabled}
+	if !cfg.Enabled {
+		return a, nil
+	}
+
+	keys, err := NewAPIKeyStore(cfg.APIKeys)
+	if err != nil {
+		return nil, fmt.Errorf("api keys: %w", err)
+	}
+	a.keys = keys
+
+	if cfg.JWT.JWKSFile != "" {
+		verifier, err := NewJWTVerifier(cfg.JWT)
+		if err != nil {
+			return nil, fmt.Errorf("jwt verifier: %w", err)
+		}
+		a.jwt = verifier
+	}
+	return a, nil
+}
+
+// Enabled сообщает, включена ли аутентификация
+func (a *Authenticator) Enabled() bool {
+	return a.enabled
+}
+
+// Authenticate извлекает и проверяет учётные данные из заголовков запроса
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	return a.AuthenticateCredentials(r.Header.Get(APIKeyHeader), r.Header.Get("Authorization"))
+}
+
+// AuthenticateCredentials проверяет API-ключ или значение Authorization ("Bearer <jwt>").
+// Используется транспортами без http.Request, например gRPC
+func (a *Authenticator) AuthenticateCredentials(apiKey, authorization string) (Principal, error) {
+	if !a.enabled {
+		return Principal{
+			Subject: "anonymous",
+			Method:  MethodDisabled,
+			Scopes:  []Scope{ScopeAdmin},
+		}, nil
+	}
+
+	if apiKey != "" {
+		return a.keys.Authenticate(apiKey)
+	}
+
+	if authorization == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if a.jwt == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return a.jwt.Verify(token)
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+)
+
+// writeJWKS генерирует RSA-ключ и сохраняет его публичную часть во временный JWKS-файл
+func writeJWKS(t *testing.T, kid string) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	set := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return key, path
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	authn, err := NewAuthenticator(config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "dashboard", KeyHash: HashAPIKey("secret-key"), Scopes: []string{"orders:read"}},
+		},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/order/1", nil)
+	req.Header.Set(APIKeyHeader, "secret-key")
+	p, err := authn.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, "dashboard", p.Subject)
+	require.True(t, p.HasScope(ScopeOrdersRead))
+	require.False(t, p.HasScope(ScopeOrdersReadPII))
+
+	req.Header.Set(APIKeyHeader, "wrong-key")
+	_, err = authn.Authenticate(req)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthenticator_NoCredentials(t *testing.T) {
+	authn, err := NewAuthenticator(config.AuthConfig{Enabled: true})
+	require.NoError(t, err)
+
+	_, err = authn.Authenticate(httptest.NewRequest("GET", "/", nil))
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestAuthenticator_Disabled(t *testing.T) {
+	authn, err := NewAuthenticator(config.AuthConfig{Enabled: false})
+	require.NoError(t, err)
+
+	p, err := authn.Authenticate(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	require.True(t, p.HasScope(ScopeOrdersReadPII))
+}
+
+func TestNewAPIKeyStore_InvalidConfig(t *testing.T) {
+	_, err := NewAPIKeyStore([]config.APIKeyConfig{{Name: "k", KeyHash: "plain-text"}})
+	require.Error(t, err)
+
+	_, err = NewAPIKeyStore([]config.APIKeyConfig{{Name: "k", KeyHash: HashAPIKey("x"), Scopes: []string{"orders:delete"}}})
+	require.Error(t, err)
+}
+
+func TestAuthenticator_JWT(t *testing.T) {
+	key, jwksPath := writeJWKS(t, "key-1")
+	authn, err := NewAuthenticator(config.AuthConfig{
+		Enabled: true,
+		JWT:     config.JWTConfig{JWKSFile: jwksPath, Issuer: "https://idp.local", Audience: "order-service"},
+	})
+	require.NoError(t, err)
+
+	valid := jwt.MapClaims{
+		"sub":   "reconciliation-job",
+		"iss":   "https://idp.local",
+		"aud":   "order-service",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "orders:read orders:read_pii billing:read",
+	}
+	req := httptest.NewRequest("GET", "/api/order/1", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, key, "key-1", valid))
+	p, err := authn.Authenticate(req)
+	require.NoError(t, err)
+	require.Equal(t, "reconciliation-job", p.Subject)
+	require.Equal(t, MethodJWT, p.Method)
+	require.True(t, p.HasScope(ScopeOrdersReadPII))
+	require.False(t, p.HasScope(ScopeOrdersWrite))
+
+	cases := map[string]jwt.MapClaims{
+		"expired":      {"sub": "x", "iss": "https://idp.local", "aud": "order-service", "exp": time.Now().Add(-time.Minute).Unix()},
+		"wrong issuer": {"sub": "x", "iss": "https://evil", "aud": "order-service", "exp": time.Now().Add(time.Hour).Unix()},
+		"no exp":       {"sub": "x", "iss": "https://idp.local", "aud": "order-service"},
+	}
+	for name, claims := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+signToken(t, key, "key-1", claims))
+			_, err := authn.Authenticate(req)
+			require.True(t, errors.Is(err, ErrInvalidCredentials), "got %v", err)
+		})
+	}
+
+	otherKey, _ := writeJWKS(t, "key-1")
+	req.Header.Set("Authorization", "Bearer "+signToken(t, otherKey, "key-1", valid))
+	_, err = authn.Authenticate(req)
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestVisibleOrder(t *testing.T) {
+	order := domain.Order{OrderUID: "b563feb7b2b84b6test", Delivery: domain.Delivery{Phone: "+9720000000", City: "Kiryat Mozkin"}}
+
+	require.NotEqual(t, order.Delivery.Phone, VisibleOrder(context.Background(), order).Delivery.Phone)
+
+	reader := WithPrincipal(context.Background(), Principal{Subject: "dashboard", Scopes: []Scope{ScopeOrdersRead}})
+	masked := VisibleOrder(reader, order)
+	require.NotEqual(t, order.Delivery.Phone, masked.Delivery.Phone)
+	require.Equal(t, "Kiryat Mozkin", masked.Delivery.City)
+
+	support := WithPrincipal(context.Background(), Principal{Subject: "support", Scopes: []Scope{ScopeOrdersRead, ScopeOrdersReadPII}})
+	require.Equal(t, order, VisibleOrder(support, order))
+}
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"WBtech_l0/internal/config"
+)
+
+// jwk — один ключ из JWKS (RFC 7517). Поддерживаются RSA и EC ключи
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWTVerifier проверяет подпись и стандартные claims токенов по локальному JWKS
+type JWTVerifier struct {
+	keys   map[string]crypto.PublicKey
+	parser *jwt.Parser
+}
+
+// tokenClaims — claims, которые мы читаем из токена
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope"` // RFC 8693: права через пробел
+	Scp   []string `json:"scp"`   // альтернативный формат: массив прав
+}
+
+// NewJWTVerifier загружает JWKS-файл и настраивает проверку iss/aud/exp
+func NewJWTVerifier(cfg config.JWTConfig) (*JWTVerifier, error) {
+	data, err := os.ReadFile(cfg.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("read jwks file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse jwks file %s: %w", cfg.JWKSFile, err)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return &JWTVerifier{keys: keys, parser: jwt.NewParser(opts...)}, nil
+}
+
+// Verify проверяет токен и возвращает клиента с правами из claims scope/scp
+func (v *JWTVerifier) Verify(raw string) (Principal, error) {
+	var claims tokenClaims
+	_, err := v.parser.ParseWithClaims(raw, &claims, v.keyFunc)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	rawScopes := claims.Scp
+	if claims.Scope != "" {
+		rawScopes = append(rawScopes, strings.Fields(claims.Scope)...)
+	}
+	// Неизвестные права в токене пропускаем: IdP может выдавать права других сервисов
+	scopes := make([]Scope, 0, len(rawScopes))
+	for _, s := range rawScopes {
+		if parsed, err := parseScopes([]string{s}); err == nil {
+			scopes = append(scopes, parsed...)
+		}
+	}
+	return Principal{Subject: claims.Subject, Method: MethodJWT, Scopes: scopes}, nil
+}
+
+// keyFunc выбирает публичный ключ по kid из заголовка токена
+func (v *JWTVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, nil
+		}
+	}
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// parseJWKS разбирает набор ключей. Ключи с use отличным от "sig" пропускаются
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			key, err = k.ecKey()
+		default:
+			err = fmt.Errorf("unsupported kty %q", k.Kty)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64url: %w", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
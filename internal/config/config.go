@@ -2,93 +2,273 @@
 package config
 
 import (
-	"log"
+	"os"
+	"reflect"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// Схема конфигурации задаётся тегами полей:
+//   - mapstructure — ключ в YAML (полный ключ — путь через точку, например cache.max_size);
+//   - default — значение, если ключ не задан;
+//   - validate — правила через запятую: required, min=N, max=N, port, oneof=a b;
+//   - secret:"true" — значение скрывается при печати конфигурации;
+//   - reload:"true" — значение применяется на лету при перечитывании конфига (Reloader),
+//     остальные ключи требуют перезапуска.
+// Проверки, связывающие несколько полей, — в (*Config).validateRelations.
+
 // PostgresConfig содержит настройки подключения к PostgreSQL
 type PostgresConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Database string
+	Host     string `mapstructure:"host" validate:"required"`
+	Port     string `mapstructure:"port" default:"5432" validate:"required,port"`
+	User     string `mapstructure:"user" validate:"required"`
+	Password string `mapstructure:"password" secret:"true"`
+	Database string `mapstructure:"database" validate:"required"`
 }
 
 // HTTPServerConfig содержит настройки HTTP-сервера
 type HTTPServerConfig struct {
-	Host string
-	Port string
+	Host        string            `mapstructure:"host"`
+	Port        string            `mapstructure:"port" default:"8080" validate:"required,port"`
+	Compression CompressionConfig `mapstructure:"compression"`
+	TLS         TLSConfig         `mapstructure:"tls"`
+	H2C         bool              `mapstructure:"h2c"` // HTTP/2 без TLS (prior knowledge) для внутренних клиентов за mesh
+}
+
+// CompressionConfig содержит настройки сжатия ответов (zstd, gzip)
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	MinSize int  `mapstructure:"min_size" default:"1024" validate:"min=0"` // ответы короче порога (в байтах) не сжимаются
+}
+
+// TLSConfig содержит настройки TLS HTTP-сервера. Сертификат перечитывается
+// при изменении файлов, перезапуск не нужен
+type TLSConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// GRPCServerConfig содержит настройки gRPC-сервера для внутренних сервисов
+type GRPCServerConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Host       string `mapstructure:"host"`
+	Port       string `mapstructure:"port" default:"9090" validate:"required,port"`
+	Reflection bool   `mapstructure:"reflection"` // регистрировать gRPC reflection (grpcurl, evans)
 }
 
 // KafkaConfig содержит настройки подключения к Kafka
 type KafkaConfig struct {
-	Brokers  string
-	Topic    string
-	GroupID  string
-	DLQTopic string
+	Brokers      string          `mapstructure:"brokers" validate:"required"`
+	Topic        string          `mapstructure:"topic" default:"orders" validate:"required"`
+	GroupID      string          `mapstructure:"group_id" validate:"required"`
+	DLQTopic     string          `mapstructure:"dlq_topic" default:"orders-dlq" validate:"required"`
+	SASL         KafkaSASLConfig `mapstructure:"sasl"`
+	RetryBackoff time.Duration   `mapstructure:"retry_backoff" default:"1s" validate:"min=10ms" reload:"true"` // пауза перед повторным чтением после ошибки брокера
+}
+
+// KafkaSASLConfig содержит учётные данные SASL для брокеров Kafka.
+// Mechanism none — без аутентификации. Пароль лучше передавать через ORDERS_KAFKA_SASL_PASSWORD_FILE
+type KafkaSASLConfig struct {
+	Mechanism string `mapstructure:"mechanism" default:"none" validate:"oneof=none plain scram-sha-256 scram-sha-512"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password" secret:"true"`
 }
 
 // CacheConfig содержит настройки in-memory кеша
 type CacheConfig struct {
-	DefaultTTL time.Duration
-	MaxSize    int
+	DefaultTTL time.Duration `mapstructure:"default_ttl" default:"1h" validate:"min=1s" reload:"true"`
+	MaxSize    int           `mapstructure:"max_size" default:"1000" validate:"min=1" reload:"true"`
 }
 
 // TelemetryConfig настройки телеметрии (метрики и трассировка)
 type TelemetryConfig struct {
-	OTLPEndpoint string // URL для OTLP экспортера
-	MetricsPort  string // порт для экспорта метрик Prometheus
+	MetricsPort        string                `mapstructure:"metrics_port" default:"2112" validate:"required,port"`                          // порт для экспорта метрик Prometheus
+	Exporter           string                `mapstructure:"exporter" default:"otlp-http" validate:"oneof=none stdout otlp-http otlp-grpc"` // куда отправлять трейсы
+	OTLPEndpoint       string                `mapstructure:"otlp_endpoint"`                                                                 // host:port коллектора; пусто — localhost:4318 (HTTP) или localhost:4317 (gRPC)
+	OTLPHeaders        string                `mapstructure:"otlp_headers" secret:"true"`                                                    // заголовки запросов к коллектору: key=value через запятую
+	OTLPTLS            OTLPTLSConfig         `mapstructure:"otlp_tls"`
+	Sampler            string                `mapstructure:"sampler" default:"parent_ratio" validate:"oneof=always never ratio parent_ratio" reload:"true"` // parent_ratio — как ratio, но решение родителя сохраняется
+	SampleRatio        float64               `mapstructure:"sample_ratio" default:"1" validate:"min=0,max=1" reload:"true"`                                 // доля трассируемых запросов для ratio и parent_ratio (0..1)
+	ServiceName        string                `mapstructure:"service_name" default:"order-service" validate:"required"`                                      // service.name в ресурсе трейсов
+	Environment        string                `mapstructure:"environment"`                                                                                   // deployment.environment; пусто — не указывается
+	ResourceAttributes string                `mapstructure:"resource_attributes"`                                                                           // дополнительные атрибуты ресурса: key=value через запятую
+	Business           BusinessMetricsConfig `mapstructure:"business"`
+}
+
+// BusinessMetricsConfig ограничивает значения меток бизнес-метрик. Значения не из списка
+// попадают в метку "other", поэтому число временных рядов не растёт от данных заказов
+type BusinessMetricsConfig struct {
+	Currencies       []string `mapstructure:"currencies" default:"RUB,USD,EUR"`
+	DeliveryServices []string `mapstructure:"delivery_services" default:"meest"`
+	Providers        []string `mapstructure:"providers" default:"wbpay"`
+	Banks            []string `mapstructure:"banks" default:"alpha,sber,tinkoff"`
+	Entries          []string `mapstructure:"entries" default:"WBIL"`
+}
+
+// OTLPTLSConfig содержит настройки TLS для подключения к OTLP-коллектору. Без enabled
+// трейсы отправляются открытым текстом (коллектор рядом с сервисом или в том же поде)
+type OTLPTLSConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CAFile   string `mapstructure:"ca_file"`   // корневой сертификат коллектора; пусто — системные
+	CertFile string `mapstructure:"cert_file"` // клиентский сертификат для mTLS
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// LogConfig содержит настройки логирования
+type LogConfig struct {
+	Level  string `mapstructure:"level" default:"info" validate:"oneof=debug info warn error" reload:"true"`
+	Format string `mapstructure:"format" default:"text" validate:"oneof=text json"` // json — для сборщиков логов
+}
+
+// APIKeyConfig описывает статический API-ключ. Сам ключ в конфиге не хранится,
+// только его хеш в формате "sha256:<hex>"
+type APIKeyConfig struct {
+	Name    string   `mapstructure:"name" validate:"required"`
+	KeyHash string   `mapstructure:"key_hash" validate:"required"`
+	Scopes  []string `mapstructure:"scopes"`
+}
+
+// JWTConfig содержит настройки проверки JWT
+type JWTConfig struct {
+	JWKSFile string `mapstructure:"jwks_file"` // путь к локальному JWKS-файлу с публичными ключами
+	Issuer   string `mapstructure:"issuer"`    // ожидаемый iss (пусто — не проверяется)
+	Audience string `mapstructure:"audience"`  // ожидаемый aud (пусто — не проверяется)
+}
+
+// AuthConfig содержит настройки аутентификации API
+type AuthConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	APIKeys []APIKeyConfig `mapstructure:"api_keys"`
+	JWT     JWTConfig      `mapstructure:"jwt"`
+}
+
+// RateLimitRule задаёт token bucket для маршрута (или лимит по умолчанию)
+type RateLimitRule struct {
+	Route string  `mapstructure:"route"`
+	RPS   float64 `mapstructure:"rps" validate:"min=0"`   // скорость пополнения, запросов в секунду
+	Burst int     `mapstructure:"burst" validate:"min=0"` // допустимый всплеск запросов
+}
+
+// RateLimitConfig содержит настройки ограничения частоты запросов
+type RateLimitConfig struct {
+	Enabled           bool              `mapstructure:"enabled"`
+	TrustForwardedFor bool              `mapstructure:"trust_forwarded_for"` // брать IP клиента из X-Forwarded-For (только за доверенным прокси)
+	Default           RateLimitRule     `mapstructure:"default" reload:"true"`
+	Routes            []RateLimitRule   `mapstructure:"routes" reload:"true"`
+	PublicLookup      PublicLookupLimit `mapstructure:"public_lookup"`
+}
+
+// PublicLookupLimit — лимит публичного поиска посылки по трек-номеру. Маршруты не требуют
+// аутентификации, поэтому лимит по IP действует всегда, даже при rate_limit.enabled=false
+type PublicLookupLimit struct {
+	RPS   float64 `mapstructure:"rps" default:"1" validate:"min=0.001" reload:"true"`
+	Burst int     `mapstructure:"burst" default:"10" validate:"min=1" reload:"true"`
+}
+
+// Config возвращает настройки ограничителя, у которого этот лимит — лимит по умолчанию
+func (l PublicLookupLimit) Config() RateLimitConfig {
+	return RateLimitConfig{Default: RateLimitRule{RPS: l.RPS, Burst: l.Burst}}
+}
+
+// EventsConfig содержит настройки потоковых API (SSE, WebSocket)
+type EventsConfig struct {
+	HistorySize       int           `mapstructure:"history_size" default:"1000" validate:"min=0"`       // сколько последних событий хранить для продолжения по Last-Event-ID
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval" default:"15s" validate:"min=1s"` // интервал heartbeat-сообщений
+}
+
+// HealthConfig содержит настройки проверок готовности
+type HealthConfig struct {
+	CheckTimeout time.Duration `mapstructure:"check_timeout" default:"2s" validate:"min=1ms"` // таймаут одной проверки зависимости
+	CacheTTL     time.Duration `mapstructure:"cache_ttl" validate:"min=0s"`                   // время кеширования результата проверки
+}
+
+// WebConfig содержит настройки веб-интерфейса
+type WebConfig struct {
+	DevMode bool   `mapstructure:"dev_mode"`                              // читать шаблоны и статику с диска и перезагружать при изменениях
+	Dir     string `mapstructure:"dir" default:"web" validate:"required"` // каталог с шаблонами для dev-режима
+}
+
+// I18nConfig содержит настройки локализации веб-интерфейса
+type I18nConfig struct {
+	DefaultLocale string `mapstructure:"default_locale" default:"ru" validate:"oneof=ru en"`    // язык, если его не удалось определить по заказу и Accept-Language
+	TimeZone      string `mapstructure:"time_zone" default:"Europe/Moscow" validate:"required"` // часовой пояс для отображения дат (IANA, например Europe/Moscow)
+}
+
+// OpenAPIConfig содержит настройки проверки запросов и ответов по спецификации API
+type OpenAPIConfig struct {
+	ValidateRequests  bool `mapstructure:"validate_requests" default:"true"` // отклонять запросы, не соответствующие спецификации (400)
+	ValidateResponses bool `mapstructure:"validate_responses"`               // проверять JSON-ответы; для тестов и стендов, ответ буферизуется
+}
+
+// ExportConfig содержит настройки выгрузки заказов
+type ExportConfig struct {
+	PageSize int `mapstructure:"page_size" default:"500" validate:"min=1,max=10000"` // сколько заказов читается из курсора за раз; определяет расход памяти
+}
+
+// DiagnosticsConfig содержит настройки отдельного порта диагностики: pprof, состояние
+// рантайма и архивы профилей. Доступен только клиентам со scope admin
+type DiagnosticsConfig struct {
+	Enabled            bool          `mapstructure:"enabled"`
+	Host               string        `mapstructure:"host" default:"127.0.0.1"` // по умолчанию порт доступен только локально
+	Port               string        `mapstructure:"port" default:"6060" validate:"required,port"`
+	ProfileDir         string        `mapstructure:"profile_dir" default:"profiles" validate:"required"`   // каталог для архивов профилей
+	MaxProfileDuration time.Duration `mapstructure:"max_profile_duration" default:"60s" validate:"min=1s"` // предел длительности CPU-профиля и трассировки
 }
 
 // Config объединяет все настройки приложения
 type Config struct {
-	Postgres       PostgresConfig
-	HTTPServer     HTTPServerConfig
-	Kafka          KafkaConfig
-	Cache          CacheConfig
-	MigrationsPath string // Путь к папке с миграциями
-	Telemetry      TelemetryConfig
-}
-
-// LoadConfig загружает конфигурацию из YAML-файла с помощью Viper
-func LoadConfig(path string) *Config {
-	viper.SetConfigFile(path)
-	viper.AutomaticEnv() // поддержка переменных окружения
-	err := viper.ReadInConfig()
-	if err != nil {
-		log.Fatalf("Error reading config file: %v", err)
-	}
+	Postgres       PostgresConfig    `mapstructure:"postgresql"`
+	HTTPServer     HTTPServerConfig  `mapstructure:"http_server"`
+	GRPCServer     GRPCServerConfig  `mapstructure:"grpc_server"`
+	Kafka          KafkaConfig       `mapstructure:"kafka"`
+	Cache          CacheConfig       `mapstructure:"cache"`
+	MigrationsPath string            `mapstructure:"migrations_path" default:"migrations" validate:"required"` // Путь к папке с миграциями
+	Telemetry      TelemetryConfig   `mapstructure:"telemetry"`
+	Log            LogConfig         `mapstructure:"log"`
+	Auth           AuthConfig        `mapstructure:"auth"`
+	RateLimit      RateLimitConfig   `mapstructure:"rate_limit"`
+	Events         EventsConfig      `mapstructure:"events"`
+	Health         HealthConfig      `mapstructure:"health"`
+	Web            WebConfig         `mapstructure:"web"`
+	I18n           I18nConfig        `mapstructure:"i18n"`
+	OpenAPI        OpenAPIConfig     `mapstructure:"openapi"`
+	Export         ExportConfig      `mapstructure:"export"`
+	Diagnostics    DiagnosticsConfig `mapstructure:"diagnostics"`
+}
 
-	var cfg Config
-	cfg.Postgres = PostgresConfig{
-		Host:     viper.GetString("postgresql.host"),
-		Port:     viper.GetString("postgresql.port"),
-		User:     viper.GetString("postgresql.user"),
-		Password: viper.GetString("postgresql.password"),
-		Database: viper.GetString("postgresql.database"),
-	}
-	cfg.HTTPServer = HTTPServerConfig{
-		Host: viper.GetString("http_server.host"),
-		Port: viper.GetString("http_server.port"),
-	}
-	cfg.Kafka = KafkaConfig{
-		Brokers: viper.GetString("kafka.brokers"),
-		Topic:   viper.GetString("kafka.topic"),
-		GroupID: viper.GetString("kafka.group_id"),
+// LoadConfig собирает конфигурацию из источников по возрастанию приоритета: значения
+// по умолчанию, YAML-файл path (пустой path — без файла), переменные окружения
+// ORDERS_* (и ORDERS_*_FILE) и overrides из флагов -set. Результат проверяется по схеме.
+// Ошибка чтения файла — *ReadError, нарушения схемы — *ValidationError со всеми
+// найденными проблемами сразу
+func LoadConfig(path string, overrides Overrides) (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml") // формат не зависит от расширения (config.yaml.example)
+	setDefaults(v)
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, &ReadError{Path: path, Err: err}
+		}
 	}
 
-	cfg.Cache = CacheConfig{
-		DefaultTTL: viper.GetDuration("cache.default_ttl"),
-		MaxSize:    viper.GetInt("cache.max_size"),
-	}
-	cfg.MigrationsPath = viper.GetString("migrations_path")
+	var errs []*FieldError
+	unknownKeys(v.AllSettings(), reflect.TypeOf(Config{}), "", &errs)
+	// Set — старший слой viper: сначала окружение, затем флаги поверх него
+	errs = append(errs, applyEnv(v, os.Environ())...)
+	errs = append(errs, applyOverrides(v, overrides)...)
 
-	cfg.Telemetry = TelemetryConfig{
-		OTLPEndpoint: viper.GetString("telemetry.otlp_endpoint"),
-		MetricsPort:  viper.GetString("telemetry.metrics_port"),
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		// значения неверного типа: проверять правила по частично разобранному конфигу бессмысленно
+		errs = append(errs, decodeErrors(err)...)
+	} else {
+		errs = append(errs, cfg.validate()...)
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
 	}
-	return &cfg
+	return &cfg, nil
 }
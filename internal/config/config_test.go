@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+const minimalConfig = `
+postgresql:
+  host: localhost
+  user: tmp
+  password: secret
+  database: orders_db
+kafka:
+  brokers: localhost:9092
+  group_id: order-service-group
+`
+
+func TestLoadConfig_Example(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join("..", "..", "configs", "config.yaml.example"), nil)
+	require.NoError(t, err)
+	require.Equal(t, "orders-dlq", cfg.Kafka.DLQTopic)
+	require.Equal(t, 1000, cfg.Cache.MaxSize)
+	require.Len(t, cfg.Auth.APIKeys, 1)
+	require.NotEmpty(t, cfg.RateLimit.Routes)
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, minimalConfig), nil)
+	require.NoError(t, err)
+
+	require.Equal(t, "5432", cfg.Postgres.Port)
+	require.Equal(t, "8080", cfg.HTTPServer.Port)
+	require.Equal(t, 1024, cfg.HTTPServer.Compression.MinSize)
+	require.Equal(t, "orders", cfg.Kafka.Topic)
+	require.Equal(t, "orders-dlq", cfg.Kafka.DLQTopic)
+	require.Equal(t, time.Hour, cfg.Cache.DefaultTTL)
+	require.Equal(t, 1000, cfg.Cache.MaxSize)
+	require.Equal(t, 15*time.Second, cfg.Events.HeartbeatInterval)
+	require.Equal(t, 2*time.Second, cfg.Health.CheckTimeout)
+	require.True(t, cfg.OpenAPI.ValidateRequests)
+	require.Equal(t, "ru", cfg.I18n.DefaultLocale)
+	require.Equal(t, 500, cfg.Export.PageSize)
+	require.Equal(t, []string{"RUB", "USD", "EUR"}, cfg.Telemetry.Business.Currencies)
+	require.False(t, cfg.Diagnostics.Enabled)
+	require.Equal(t, "127.0.0.1", cfg.Diagnostics.Host)
+	require.Equal(t, time.Minute, cfg.Diagnostics.MaxProfileDuration)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	path := writeConfig(t, minimalConfig+`
+  topik: orders
+cache:
+  max_size: 0
+http_server:
+  port: "80800"
+  tls:
+    enabled: true
+i18n:
+  default_locale: de
+auth:
+  api_keys:
+    - name: ops
+      key_hsh: "sha256:00"
+rate_limit:
+  routes:
+    - rps: -1
+telemetry:
+  sampler: sometimes
+  otlp_headers: "authorization"
+  otlp_tls:
+    enabled: true
+    cert_file: client.pem
+diagnostics:
+  enabled: true
+  port: "2112"
+`)
+	_, err := LoadConfig(path, nil)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	var keys []string
+	for _, fe := range verr.Errors {
+		keys = append(keys, fe.Key)
+	}
+	require.ElementsMatch(t, []string{
+		"kafka.topik",
+		"auth.api_keys[0].key_hsh",
+		"http_server.port",
+		"cache.max_size",
+		"i18n.default_locale",
+		"auth.api_keys[0].key_hash",
+		"rate_limit.routes[0].rps",
+		"http_server.tls.cert_file",
+		"http_server.tls.key_file",
+		"rate_limit.routes[0].route",
+		"telemetry.sampler",
+		"telemetry.otlp_headers",
+		"telemetry.otlp_tls.key_file",
+		"diagnostics.enabled",
+		"diagnostics.port",
+	}, keys)
+
+	var ferr *FieldError
+	require.ErrorAs(t, err, &ferr)
+}
+
+func TestLoadConfig_WrongType(t *testing.T) {
+	_, err := LoadConfig(writeConfig(t, minimalConfig+"cache:\n  default_ttl: soon\n"), nil)
+	var ferr *FieldError
+	require.ErrorAs(t, err, &ferr)
+	require.Equal(t, "cache.default_ttl", ferr.Key)
+}
+
+func TestLoadConfig_ReadError(t *testing.T) {
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	var rerr *ReadError
+	require.ErrorAs(t, err, &rerr)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = LoadConfig(writeConfig(t, "postgresql: [unclosed"), nil)
+	require.ErrorAs(t, err, &rerr)
+}
+
+func TestConfig_WriteYAML(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, minimalConfig), nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.WriteYAML(&buf))
+	require.NotContains(t, buf.String(), "secret")
+	require.Contains(t, buf.String(), "password: '***'")
+
+	// напечатанный конфиг снова проходит проверку и даёт те же значения
+	again, err := LoadConfig(writeConfig(t, buf.String()), nil)
+	require.NoError(t, err)
+	again.Postgres.Password = cfg.Postgres.Password
+	// пустые списки печатаются как [] и читаются пустыми, а не nil
+	require.Empty(t, again.Auth.APIKeys)
+	require.Empty(t, again.RateLimit.Routes)
+	again.Auth.APIKeys, again.RateLimit.Routes = nil, nil
+	require.Equal(t, cfg, again)
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Errors: []*FieldError{{Key: "cache.max_size", Reason: "must be >= 1, got 0"}}}
+	require.Equal(t, "invalid config:\n  cache.max_size: must be >= 1, got 0", err.Error())
+	require.True(t, errors.Is(err, err.Errors[0]))
+}
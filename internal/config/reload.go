@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ReloadStatus — состояние перечитывания конфигурации для health
+type ReloadStatus struct {
+	Generation uint64    `json:"generation"` // 1 — конфиг при запуске, +1 за каждое применённое изменение
+	LoadedAt   time.Time `json:"loaded_at"`
+	LastError  string    `json:"last_error,omitempty"` // причина последнего отклонённого перечитывания
+}
+
+// Reloader перечитывает конфигурацию по изменению файла или SIGHUP и применяет
+// ключи с тегом reload:"true". Новый конфиг проверяется целиком: при любой ошибке
+// не применяется ничего, и сервис продолжает работать с прежними значениями
+type Reloader struct {
+	load func() (*Config, error)
+
+	mu       sync.Mutex
+	current  *Config
+	status   ReloadStatus
+	appliers []func(prev, next *Config)
+}
+
+// NewReloader создаёт Reloader для уже загруженного cfg. load повторяет исходную загрузку
+// (тот же файл, окружение и флаги), например (*Flags).Load
+func NewReloader(cfg *Config, load func() (*Config, error)) *Reloader {
+	return &Reloader{
+		load:    load,
+		current: cfg,
+		status:  ReloadStatus{Generation: 1, LoadedAt: time.Now().UTC()},
+	}
+}
+
+// OnReload регистрирует функцию, которая применяет изменённые настройки. Она получает
+// прежний и новый действующий конфиг и должна быть идемпотентной; вызывается под блокировкой Reloader
+func (r *Reloader) OnReload(fn func(prev, next *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, fn)
+}
+
+// Current возвращает действующий конфиг. Его нельзя изменять
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Status возвращает номер поколения конфигурации и результат последней попытки
+func (r *Reloader) Status() ReloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Reload перечитывает конфигурацию. Возвращает ошибку, если новый конфиг не прошёл
+// проверку; ключи, требующие перезапуска, не применяются и только попадают в лог
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := r.load()
+	if err != nil {
+		r.status.LastError = err.Error()
+		return err
+	}
+	r.status.LastError = ""
+
+	next := *r.current
+	applied, restart := mergeReloadable(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem(), "")
+	if len(restart) > 0 {
+		slog.Warn("config changes require restart and are not applied", slog.String("keys", strings.Join(restart, ", ")))
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+
+	prev := r.current
+	r.current = &next
+	for _, fn := range r.appliers {
+		fn(prev, r.current)
+	}
+	r.status.Generation++
+	r.status.LoadedAt = time.Now().UTC()
+	slog.Info("config reloaded", slog.Uint64("generation", r.status.Generation), slog.String("keys", strings.Join(applied, ", ")))
+	return nil
+}
+
+// mergeReloadable копирует в dst изменённые ключи с тегом reload и возвращает их список,
+// а также список изменённых ключей, которые требуют перезапуска
+func mergeReloadable(dst, src reflect.Value, prefix string) (applied, restart []string) {
+	t := dst.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		key := joinKey(prefix, keyOf(sf))
+		df, sv := dst.Field(i), src.Field(i)
+		if reflect.DeepEqual(df.Interface(), sv.Interface()) {
+			continue
+		}
+		switch {
+		case sf.Tag.Get("reload") == "true":
+			df.Set(sv)
+			applied = append(applied, key)
+		case isSection(sf.Type):
+			a, r := mergeReloadable(df, sv, key)
+			applied, restart = append(applied, a...), append(restart, r...)
+		default:
+			restart = append(restart, key)
+		}
+	}
+	return applied, restart
+}
+
+// Watch перечитывает конфигурацию при изменении файла path и по SIGHUP, пока не отменён ctx.
+// Следит за каталогом, а не за файлом: ConfigMap в Kubernetes обновляется сменой симлинка.
+// Пустой path — только SIGHUP
+func (r *Reloader) Watch(ctx context.Context, path string) error {
+	var events chan fsnotify.Event
+	var watchErrs chan error
+	if path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("create config watcher: %w", err)
+		}
+		defer watcher.Close()
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			return fmt.Errorf("watch %s: %w", filepath.Dir(path), err)
+		}
+		events, watchErrs = watcher.Events, watcher.Errors
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	reload := func(reason string) {
+		if err := r.Reload(); err != nil {
+			slog.Error("config reload rejected, keeping current generation",
+				slog.String("reason", reason), slog.Uint64("generation", r.Status().Generation), slog.Any("error", err))
+		}
+	}
+
+	// Редактор и kubelet меняют файл несколькими событиями — перечитываем один раз после паузы
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			reload("SIGHUP")
+		case <-events:
+			debounce = time.After(200 * time.Millisecond)
+		case <-debounce:
+			reload("file change")
+		case err := <-watchErrs:
+			slog.Error("config watcher failed", slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReloader(t *testing.T, content string) (*Reloader, string) {
+	t.Helper()
+	path := writeConfig(t, content)
+	load := func() (*Config, error) { return LoadConfig(path, nil) }
+	cfg, err := load()
+	require.NoError(t, err)
+	return NewReloader(cfg, load), path
+}
+
+func TestReloader_Reload(t *testing.T) {
+	r, path := newTestReloader(t, minimalConfig)
+	var applied []*Config
+	var previous []*Config
+	r.OnReload(func(prev, next *Config) {
+		previous, applied = append(previous, prev), append(applied, next)
+	})
+
+	// Без изменений поколение не растёт
+	require.NoError(t, r.Reload())
+	require.Equal(t, uint64(1), r.Status().Generation)
+	require.Empty(t, applied)
+
+	// Перенастраиваемые ключи применяются, ключи перезапуска — нет
+	require.NoError(t, os.WriteFile(path, []byte(minimalConfig+`
+  retry_backoff: 5s
+cache:
+  max_size: 10
+log:
+  level: debug
+http_server:
+  port: "9999"
+`), 0o600))
+	require.NoError(t, r.Reload())
+	require.Equal(t, uint64(2), r.Status().Generation)
+	require.Len(t, applied, 1)
+	cfg := r.Current()
+	require.Same(t, cfg, applied[0])
+	require.Equal(t, "info", previous[0].Log.Level)
+	require.Equal(t, 5*time.Second, cfg.Kafka.RetryBackoff)
+	require.Equal(t, 10, cfg.Cache.MaxSize)
+	require.Equal(t, "debug", cfg.Log.Level)
+	require.Equal(t, "8080", cfg.HTTPServer.Port)
+
+	// Некорректный конфиг отклоняется целиком
+	require.NoError(t, os.WriteFile(path, []byte(minimalConfig+`
+cache:
+  max_size: 20
+log:
+  level: verbose
+`), 0o600))
+	require.Error(t, r.Reload())
+	st := r.Status()
+	require.Equal(t, uint64(2), st.Generation)
+	require.Contains(t, st.LastError, "log.level")
+	require.Same(t, cfg, r.Current())
+	require.Len(t, applied, 1)
+}
+
+func TestReloader_Watch(t *testing.T) {
+	r, path := newTestReloader(t, minimalConfig)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Watch(ctx, path) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	// Даём наблюдателю подписаться на каталог и меняем файл, пока изменение не будет замечено
+	require.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(path, []byte(minimalConfig+`
+cache:
+  default_ttl: 5m
+`), 0o600))
+		return r.Current().Cache.DefaultTTL == 5*time.Minute
+	}, 5*time.Second, 300*time.Millisecond)
+	require.Equal(t, uint64(2), r.Status().Generation)
+}
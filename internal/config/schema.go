@@ -0,0 +1,396 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// ReadError — файл конфигурации не найден или не разбирается как YAML
+type ReadError struct {
+	Path string
+	Err  error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("read config %s: %v", e.Path, e.Err)
+}
+
+func (e *ReadError) Unwrap() error { return e.Err }
+
+// FieldError — нарушение схемы в одном ключе конфигурации
+type FieldError struct {
+	Key    string // полный ключ, например cache.max_size или auth.api_keys[0].name
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Reason
+}
+
+// ValidationError собирает все нарушения схемы, чтобы конфиг исправлялся за один проход
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid config:")
+	for _, fe := range e.Errors {
+		b.WriteString("\n  ")
+		b.WriteString(fe.Error())
+	}
+	return b.String()
+}
+
+// Unwrap позволяет находить отдельные *FieldError через errors.As
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// keyOf возвращает ключ поля в YAML
+func keyOf(sf reflect.StructField) string {
+	if k := sf.Tag.Get("mapstructure"); k != "" {
+		return k
+	}
+	return strings.ToLower(sf.Name)
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// isSection сообщает, является ли поле вложенной секцией конфига (а не значением)
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != durationType
+}
+
+// setDefaults регистрирует значения по умолчанию из тегов default
+func setDefaults(v *viper.Viper) {
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			key := joinKey(prefix, keyOf(sf))
+			if isSection(sf.Type) {
+				walk(sf.Type, key)
+				continue
+			}
+			if def, ok := sf.Tag.Lookup("default"); ok {
+				v.SetDefault(key, def)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+}
+
+// unknownKeys находит ключи, которых нет в схеме: опечатка в имени ключа иначе
+// молча оставила бы значение по умолчанию
+func unknownKeys(settings map[string]any, t reflect.Type, prefix string, errs *[]*FieldError) {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := range t.NumField() {
+		fields[keyOf(t.Field(i))] = t.Field(i)
+	}
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		key := joinKey(prefix, k)
+		sf, ok := fields[k]
+		if !ok {
+			*errs = append(*errs, &FieldError{Key: key, Reason: "unknown key"})
+			continue
+		}
+		switch val := settings[k].(type) {
+		case map[string]any:
+			if isSection(sf.Type) {
+				unknownKeys(val, sf.Type, key, errs)
+			}
+		case []any:
+			if sf.Type.Kind() == reflect.Slice && isSection(sf.Type.Elem()) {
+				for j, el := range val {
+					if m, ok := el.(map[string]any); ok {
+						unknownKeys(m, sf.Type.Elem(), fmt.Sprintf("%s[%d]", key, j), errs)
+					}
+				}
+			}
+		}
+	}
+}
+
+// decodeErrors превращает ошибки mapstructure (неверный тип значения) в *FieldError
+func decodeErrors(err error) []*FieldError {
+	var errs []*FieldError
+	var walk func(err error)
+	walk = func(err error) {
+		var de *mapstructure.DecodeError
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		default:
+			if errors.As(err, &de) {
+				errs = append(errs, &FieldError{Key: de.Name(), Reason: de.Unwrap().Error()})
+			} else {
+				errs = append(errs, &FieldError{Key: "", Reason: err.Error()})
+			}
+		}
+	}
+	walk(err)
+	return errs
+}
+
+// validate проверяет правила из тегов validate и связи между полями
+func (c *Config) validate() []*FieldError {
+	var errs []*FieldError
+	validateStruct(reflect.ValueOf(c).Elem(), "", &errs)
+	return append(errs, c.validateRelations()...)
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *[]*FieldError) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf, fv := t.Field(i), v.Field(i)
+		key := joinKey(prefix, keyOf(sf))
+		switch {
+		case isSection(sf.Type):
+			validateStruct(fv, key, errs)
+			continue
+		case sf.Type.Kind() == reflect.Slice && isSection(sf.Type.Elem()):
+			for j := range fv.Len() {
+				validateStruct(fv.Index(j), fmt.Sprintf("%s[%d]", key, j), errs)
+			}
+			continue
+		}
+		rules := sf.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		for _, rule := range strings.Split(rules, ",") {
+			if reason := checkRule(fv, rule); reason != "" {
+				*errs = append(*errs, &FieldError{Key: key, Reason: reason})
+				break
+			}
+		}
+	}
+}
+
+// checkRule возвращает причину нарушения правила или пустую строку
+func checkRule(v reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+	case "port":
+		if s := v.String(); s != "" {
+			if n, err := strconv.Atoi(s); err != nil || n < 1 || n > 65535 {
+				return fmt.Sprintf("must be a port number 1-65535, got %q", s)
+			}
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		if !slices.Contains(allowed, v.String()) {
+			return fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), v.String())
+		}
+	case "min", "max":
+		cmp, shown := compareNumber(v, arg)
+		if (name == "min" && cmp < 0) || (name == "max" && cmp > 0) {
+			op := ">="
+			if name == "max" {
+				op = "<="
+			}
+			return fmt.Sprintf("must be %s %s, got %s", op, arg, shown)
+		}
+	default:
+		panic("config: unknown validate rule " + rule)
+	}
+	return ""
+}
+
+// compareNumber сравнивает значение поля с границей из тега (-1, 0, 1)
+// и возвращает значение в том виде, в каком его пишут в YAML
+func compareNumber(v reflect.Value, bound string) (int, string) {
+	switch {
+	case v.Type() == durationType:
+		b, err := time.ParseDuration(bound)
+		if err != nil {
+			panic("config: bad duration bound " + bound)
+		}
+		d := time.Duration(v.Int())
+		return cmpOf(d, b), d.String()
+	case v.CanInt():
+		b, err := strconv.ParseInt(bound, 10, 64)
+		if err != nil {
+			panic("config: bad int bound " + bound)
+		}
+		return cmpOf(v.Int(), b), strconv.FormatInt(v.Int(), 10)
+	case v.CanFloat():
+		b, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			panic("config: bad float bound " + bound)
+		}
+		return cmpOf(v.Float(), b), strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	}
+	panic("config: min/max on non-numeric field of type " + v.Type().String())
+}
+
+func cmpOf[T int64 | float64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// validateRelations проверяет правила, которые связывают несколько полей
+func (c *Config) validateRelations() []*FieldError {
+	var errs []*FieldError
+	if c.HTTPServer.TLS.Enabled {
+		if c.HTTPServer.TLS.CertFile == "" {
+			errs = append(errs, &FieldError{Key: "http_server.tls.cert_file", Reason: "is required when tls.enabled is true"})
+		}
+		if c.HTTPServer.TLS.KeyFile == "" {
+			errs = append(errs, &FieldError{Key: "http_server.tls.key_file", Reason: "is required when tls.enabled is true"})
+		}
+	}
+	if c.Kafka.DLQTopic == c.Kafka.Topic {
+		errs = append(errs, &FieldError{Key: "kafka.dlq_topic", Reason: "must differ from kafka.topic"})
+	}
+	if c.I18n.TimeZone != "" {
+		if _, err := time.LoadLocation(c.I18n.TimeZone); err != nil {
+			errs = append(errs, &FieldError{Key: "i18n.time_zone", Reason: fmt.Sprintf("unknown time zone %q", c.I18n.TimeZone)})
+		}
+	}
+	if c.Kafka.SASL.Mechanism != "none" {
+		if c.Kafka.SASL.Username == "" {
+			errs = append(errs, &FieldError{Key: "kafka.sasl.username", Reason: "is required when kafka.sasl.mechanism is set"})
+		}
+		if c.Kafka.SASL.Password == "" {
+			errs = append(errs, &FieldError{Key: "kafka.sasl.password", Reason: "is required when kafka.sasl.mechanism is set"})
+		}
+	}
+	if t := c.Telemetry.OTLPTLS; t.Enabled {
+		if t.CertFile != "" && t.KeyFile == "" {
+			errs = append(errs, &FieldError{Key: "telemetry.otlp_tls.key_file", Reason: "is required when otlp_tls.cert_file is set"})
+		}
+		if t.KeyFile != "" && t.CertFile == "" {
+			errs = append(errs, &FieldError{Key: "telemetry.otlp_tls.cert_file", Reason: "is required when otlp_tls.key_file is set"})
+		}
+	}
+	if _, err := ParseKeyValues(c.Telemetry.OTLPHeaders); err != nil {
+		errs = append(errs, &FieldError{Key: "telemetry.otlp_headers", Reason: err.Error()})
+	}
+	if _, err := ParseKeyValues(c.Telemetry.ResourceAttributes); err != nil {
+		errs = append(errs, &FieldError{Key: "telemetry.resource_attributes", Reason: err.Error()})
+	}
+	if c.Diagnostics.Enabled {
+		// без аутентификации порт диагностики был бы открыт любому клиенту
+		if !c.Auth.Enabled {
+			errs = append(errs, &FieldError{Key: "diagnostics.enabled", Reason: "requires auth.enabled"})
+		}
+		if p := c.Diagnostics.Port; p == c.HTTPServer.Port || p == c.Telemetry.MetricsPort {
+			errs = append(errs, &FieldError{Key: "diagnostics.port", Reason: "must differ from http_server.port and telemetry.metrics_port"})
+		}
+	}
+	for i, r := range c.RateLimit.Routes {
+		if r.Route == "" {
+			errs = append(errs, &FieldError{Key: fmt.Sprintf("rate_limit.routes[%d].route", i), Reason: "is required"})
+		}
+	}
+	return errs
+}
+
+// ParseKeyValues разбирает список вида "key1=value1,key2=value2" (формат OTEL_RESOURCE_ATTRIBUTES
+// и OTEL_EXPORTER_OTLP_HEADERS). Пробелы вокруг ключей и значений отбрасываются.
+// Ошибка не содержит самих значений: в заголовках бывают токены
+func ParseKeyValues(s string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for i, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("item %d: expected key=value", i+1)
+		}
+		pairs[k] = strings.TrimSpace(v)
+	}
+	return pairs, nil
+}
+
+// WriteYAML печатает действующую конфигурацию (со значениями по умолчанию) в YAML
+// в порядке схемы. Поля с тегом secret заменяются на "***"
+func (c *Config) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(reflect.ValueOf(c).Elem())); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlNode(v reflect.Value) *yaml.Node {
+	switch {
+	case v.Type() == durationType:
+		return scalarNode(time.Duration(v.Int()).String())
+	case isSection(v.Type()):
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			val := yamlNode(v.Field(i))
+			if sf.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+				val = scalarNode("***")
+			}
+			n.Content = append(n.Content, scalarNode(keyOf(sf)), val)
+		}
+		return n
+	case v.Kind() == reflect.Slice:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		if v.Type().Elem().Kind() != reflect.Struct {
+			n.Style = yaml.FlowStyle
+		}
+		for j := range v.Len() {
+			n.Content = append(n.Content, yamlNode(v.Index(j)))
+		}
+		return n
+	}
+	n := &yaml.Node{}
+	if err := n.Encode(v.Interface()); err != nil {
+		return scalarNode(fmt.Sprint(v.Interface()))
+	}
+	return n
+}
+
+func scalarNode(s string) *yaml.Node {
+	n := &yaml.Node{}
+	_ = n.Encode(s) // строка кодируется всегда
+	return n
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// EnvPrefix — префикс переменных окружения: ORDERS_POSTGRESQL_PASSWORD задаёт postgresql.password
+	EnvPrefix = "ORDERS_"
+	// fileSuffix — переменная с этим суффиксом содержит путь к файлу со значением
+	// (секреты, смонтированные оркестратором): ORDERS_POSTGRESQL_PASSWORD_FILE=/run/secrets/pg
+	fileSuffix = "_FILE"
+	// configPathEnv задаёт путь к файлу конфигурации, если не передан флаг -config
+	configPathEnv = EnvPrefix + "CONFIG"
+	// DefaultPath — файл конфигурации по умолчанию
+	DefaultPath = "configs/config.yaml"
+)
+
+// EnvName возвращает имя переменной окружения для ключа: kafka.group_id -> ORDERS_KAFKA_GROUP_ID
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// scalarKeys возвращает ключи схемы, которые задаются одной строкой (списки — только в файле)
+func scalarKeys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			key := joinKey(prefix, keyOf(sf))
+			switch {
+			case isSection(sf.Type):
+				walk(sf.Type, key)
+			case sf.Type.Kind() != reflect.Slice:
+				keys = append(keys, key)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return keys
+}
+
+// serviceLinkEnv — переменные, которые Kubernetes добавляет в под для каждого Service
+// (service links): для Service orders — ORDERS_SERVICE_HOST, ORDERS_SERVICE_PORT, ORDERS_PORT
+// и ORDERS_PORT_8080_TCP_*, для orders-db — ORDERS_DB_SERVICE_HOST и т. д.
+var serviceLinkEnv = regexp.MustCompile(`^` + EnvPrefix + `(?:[A-Z0-9_]+_)?(?:SERVICE_HOST|SERVICE_PORT(?:_[A-Z0-9_]+)?|PORT_[0-9]+_(?:TCP|UDP|SCTP)(?:_PROTO|_PORT|_ADDR)?)$`)
+
+// isServiceLinkEnv сообщает, что переменная похожа на service link Kubernetes. Голое
+// <SERVICE>_PORT узнаётся по значению (tcp://10.0.0.1:8080), чтобы не пропустить
+// опечатки вроде ORDERS_HTTP_SERVR_PORT
+func isServiceLinkEnv(name, val string) bool {
+	if serviceLinkEnv.MatchString(name) {
+		return true
+	}
+	if strings.HasSuffix(name, "_PORT") {
+		for _, scheme := range []string{"tcp://", "udp://", "sctp://"} {
+			if strings.HasPrefix(val, scheme) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyEnv переносит в v значения переменных ORDERS_<KEY> и ORDERS_<KEY>_FILE.
+// Незнакомая переменная с префиксом ORDERS_ — ошибка, как и незнакомый ключ в файле.
+// Исключение — service links Kubernetes: иначе сервис не запустился бы в поде рядом
+// с Service, имя которого начинается с orders
+func applyEnv(v *viper.Viper, environ []string) []*FieldError {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if name, val, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			env[name] = val
+		}
+	}
+	delete(env, configPathEnv)
+
+	var errs []*FieldError
+	for _, key := range scalarKeys() {
+		name := EnvName(key)
+		val, direct := env[name]
+		path, fromFile := env[name+fileSuffix]
+		delete(env, name)
+		delete(env, name+fileSuffix)
+		switch {
+		case direct && fromFile:
+			errs = append(errs, &FieldError{Key: key, Reason: fmt.Sprintf("both %s and %s%s are set", name, name, fileSuffix)})
+		case fromFile:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, &FieldError{Key: key, Reason: fmt.Sprintf("read %s%s: %v", name, fileSuffix, err)})
+				continue
+			}
+			// Файлы секретов обычно заканчиваются переводом строки
+			v.Set(key, strings.TrimRight(string(data), "\r\n"))
+		case direct:
+			v.Set(key, val)
+		}
+	}
+
+	unknown := make([]string, 0, len(env))
+	for name, val := range env {
+		if !isServiceLinkEnv(name, val) {
+			unknown = append(unknown, name)
+		}
+	}
+	slices.Sort(unknown)
+	for _, name := range unknown {
+		errs = append(errs, &FieldError{Key: name, Reason: "unknown environment variable"})
+	}
+	return errs
+}
+
+// Overrides — значения из флагов -set key=value, старший источник конфигурации.
+// Реализует flag.Value, флаг можно повторять
+type Overrides map[string]string
+
+func (o Overrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for k, v := range o {
+		pairs = append(pairs, k+"="+v)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set разбирает аргумент флага вида key=value
+func (o Overrides) Set(s string) error {
+	key, val, ok := strings.Cut(s, "=")
+	key = strings.ToLower(strings.TrimSpace(key))
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	o[key] = val
+	return nil
+}
+
+func applyOverrides(v *viper.Viper, overrides Overrides) []*FieldError {
+	var errs []*FieldError
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	known := scalarKeys()
+	for _, key := range keys {
+		if !slices.Contains(known, key) {
+			errs = append(errs, &FieldError{Key: key, Reason: "unknown key in -set"})
+			continue
+		}
+		v.Set(key, overrides[key])
+	}
+	return errs
+}
+
+// Flags — общие для всех бинарников флаги конфигурации: -config и -set
+type Flags struct {
+	Path      string
+	Overrides Overrides
+}
+
+// Register добавляет флаги в fs. Путь по умолчанию берётся из ORDERS_CONFIG
+func (f *Flags) Register(fs *flag.FlagSet) {
+	f.Overrides = Overrides{}
+	path := DefaultPath
+	if p, ok := os.LookupEnv(configPathEnv); ok {
+		path = p
+	}
+	fs.StringVar(&f.Path, "config", path, "path to config file, empty for env and flags only (env "+configPathEnv+")")
+	fs.Var(f.Overrides, "set", "override config key, repeatable: -set http_server.port=9000")
+}
+
+// Load загружает конфигурацию с учётом разобранных флагов
+func (f *Flags) Load() (*Config, error) {
+	return LoadConfig(f.Path, f.Overrides)
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Layering(t *testing.T) {
+	path := writeConfig(t, minimalConfig+`
+http_server:
+  port: "8081"
+cache:
+  max_size: 10
+`)
+	secret := filepath.Join(t.TempDir(), "pg-password")
+	require.NoError(t, os.WriteFile(secret, []byte("from-file\n"), 0o600))
+
+	t.Setenv("ORDERS_HTTP_SERVER_PORT", "8082")
+	t.Setenv("ORDERS_KAFKA_GROUP_ID", "env-group")
+	t.Setenv("ORDERS_POSTGRESQL_PASSWORD_FILE", secret)
+	t.Setenv("ORDERS_CONFIG", "ignored.yaml")
+
+	cfg, err := LoadConfig(path, Overrides{"http_server.port": "8083"})
+	require.NoError(t, err)
+	require.Equal(t, "8083", cfg.HTTPServer.Port)        // флаг старше окружения
+	require.Equal(t, "env-group", cfg.Kafka.GroupID)     // окружение старше файла
+	require.Equal(t, 10, cfg.Cache.MaxSize)              // файл старше значения по умолчанию
+	require.Equal(t, 500, cfg.Export.PageSize)           // значение по умолчанию
+	require.Equal(t, "from-file", cfg.Postgres.Password) // *_FILE без перевода строки
+}
+
+func TestLoadConfig_EnvOnly(t *testing.T) {
+	t.Setenv("ORDERS_POSTGRESQL_HOST", "db")
+	t.Setenv("ORDERS_POSTGRESQL_USER", "orders")
+	t.Setenv("ORDERS_POSTGRESQL_DATABASE", "orders_db")
+	t.Setenv("ORDERS_KAFKA_BROKERS", "kafka:9092")
+	t.Setenv("ORDERS_KAFKA_GROUP_ID", "orders")
+	t.Setenv("ORDERS_CACHE_DEFAULT_TTL", "30m")
+
+	cfg, err := LoadConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, "db", cfg.Postgres.Host)
+	require.Equal(t, "30m0s", cfg.Cache.DefaultTTL.String())
+}
+
+func TestLoadConfig_SourceErrors(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("ORDERS_POSTGRESQL_USER", "u")
+	t.Setenv("ORDERS_POSTGRESQL_USER_FILE", secret)
+	t.Setenv("ORDERS_KAFKA_SASL_PASSWORD_FILE", secret)
+	t.Setenv("ORDERS_KAFKA_BROKER", "typo")
+
+	_, err := LoadConfig(writeConfig(t, minimalConfig), Overrides{"cache.maxsize": "1", "auth.api_keys": "x"})
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	var keys []string
+	for _, fe := range verr.Errors {
+		keys = append(keys, fe.Key)
+	}
+	require.ElementsMatch(t, []string{
+		"postgresql.user",
+		"kafka.sasl.password",
+		"ORDERS_KAFKA_BROKER",
+		"cache.maxsize",
+		"auth.api_keys",
+	}, keys)
+}
+
+func TestLoadConfig_KubernetesServiceLinks(t *testing.T) {
+	// Kubernetes добавляет их в под, если в namespace есть Service orders или orders-db
+	t.Setenv("ORDERS_SERVICE_HOST", "10.96.0.12")
+	t.Setenv("ORDERS_SERVICE_PORT", "8080")
+	t.Setenv("ORDERS_SERVICE_PORT_HTTP", "8080")
+	t.Setenv("ORDERS_PORT", "tcp://10.96.0.12:8080")
+	t.Setenv("ORDERS_PORT_8080_TCP", "tcp://10.96.0.12:8080")
+	t.Setenv("ORDERS_PORT_8080_TCP_PROTO", "tcp")
+	t.Setenv("ORDERS_PORT_8080_TCP_PORT", "8080")
+	t.Setenv("ORDERS_PORT_8080_TCP_ADDR", "10.96.0.12")
+	t.Setenv("ORDERS_DB_SERVICE_HOST", "10.96.0.13")
+	t.Setenv("ORDERS_DB_PORT", "tcp://10.96.0.13:5432")
+
+	cfg, err := LoadConfig(writeConfig(t, minimalConfig), nil)
+	require.NoError(t, err)
+	require.Equal(t, "8080", cfg.HTTPServer.Port)
+
+	// опечатка в ключе с _PORT по-прежнему ошибка
+	t.Setenv("ORDERS_HTTP_SERVR_PORT", "9000")
+	_, err = LoadConfig(writeConfig(t, minimalConfig), nil)
+	var ferr *FieldError
+	require.ErrorAs(t, err, &ferr)
+	require.Equal(t, "ORDERS_HTTP_SERVR_PORT", ferr.Key)
+}
+
+func TestLoadConfig_KafkaSASL(t *testing.T) {
+	_, err := LoadConfig(writeConfig(t, minimalConfig+"  sasl:\n    mechanism: scram-sha-512\n    username: orders\n"), nil)
+	var ferr *FieldError
+	require.ErrorAs(t, err, &ferr)
+	require.Equal(t, "kafka.sasl.password", ferr.Key)
+
+	t.Setenv("ORDERS_KAFKA_SASL_PASSWORD", "s3cret")
+	cfg, err := LoadConfig(writeConfig(t, minimalConfig+"  sasl:\n    mechanism: scram-sha-512\n    username: orders\n"), nil)
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", cfg.Kafka.SASL.Password)
+}
+
+func TestFlags(t *testing.T) {
+	t.Setenv("ORDERS_CONFIG", "/etc/orders/config.yaml")
+	var f Flags
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f.Register(fs)
+	require.NoError(t, fs.Parse([]string{"-set", "http_server.port=9000", "-set", "Cache.Max_Size=5"}))
+	require.Equal(t, "/etc/orders/config.yaml", f.Path)
+	require.Equal(t, Overrides{"http_server.port": "9000", "cache.max_size": "5"}, f.Overrides)
+
+	require.Error(t, fs.Parse([]string{"-set", "no-value"}))
+}
+
+func TestEnvName(t *testing.T) {
+	require.Equal(t, "ORDERS_KAFKA_GROUP_ID", EnvName("kafka.group_id"))
+}
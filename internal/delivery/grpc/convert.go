@@ -0,0 +1,74 @@
+package grpcdelivery
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	ordersv1 "WBtech_l0/api/orders/v1"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/events"
+)
+
+// toProtoOrder переводит доменный заказ в сообщение gRPC API
+func toProtoOrder(o domain.Order) *ordersv1.Order {
+	items := make([]*ordersv1.Item, 0, len(o.Items))
+	for _, it := range o.Items {
+		items = append(items, &ordersv1.Item{
+			ChrtId:      int64(it.ChrtID),
+			TrackNumber: it.TrackNumber,
+			Price:       int64(it.Price),
+			Rid:         it.Rid,
+			Name:        it.Name,
+			Sale:        int64(it.Sale),
+			Size:        it.Size,
+			TotalPrice:  int64(it.TotalPrice),
+			NmId:        int64(it.NmID),
+			Brand:       it.Brand,
+			Status:      int64(it.Status),
+		})
+	}
+	return &ordersv1.Order{
+		OrderUid:    o.OrderUID,
+		TrackNumber: o.TrackNumber,
+		Entry:       o.Entry,
+		Delivery: &ordersv1.Delivery{
+			Name:    o.Delivery.Name,
+			Phone:   o.Delivery.Phone,
+			Zip:     o.Delivery.Zip,
+			City:    o.Delivery.City,
+			Address: o.Delivery.Address,
+			Region:  o.Delivery.Region,
+			Email:   o.Delivery.Email,
+		},
+		Payment: &ordersv1.Payment{
+			Transaction:  o.Payment.Transaction,
+			RequestId:    o.Payment.RequestID,
+			Currency:     o.Payment.Currency,
+			Provider:     o.Payment.Provider,
+			Amount:       int64(o.Payment.Amount),
+			PaymentDt:    o.Payment.PaymentDT,
+			Bank:         o.Payment.Bank,
+			DeliveryCost: int64(o.Payment.DeliveryCost),
+			GoodsTotal:   int64(o.Payment.GoodsTotal),
+			CustomFee:    int64(o.Payment.CustomFee),
+		},
+		Items:             items,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerId:        o.CustomerID,
+		DeliveryService:   o.DeliveryService,
+		Shardkey:          o.Shardkey,
+		SmId:              int64(o.SmID),
+		DateCreated:       o.DateCreated,
+		OofShard:          o.OofShard,
+	}
+}
+
+// toProtoEvent переводит событие шины в сообщение потока WatchOrders
+func toProtoEvent(e events.Event, order domain.Order) *ordersv1.OrderEvent {
+	return &ordersv1.OrderEvent{
+		Id:    e.ID,
+		Type:  e.Type,
+		Time:  timestamppb.New(e.Time),
+		Order: toProtoOrder(order),
+	}
+}
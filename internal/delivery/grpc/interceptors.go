@@ -0,0 +1,162 @@
+package grpcdelivery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	ordersv1 "WBtech_l0/api/orders/v1"
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/telemetry"
+)
+
+// requestIDKey — ключ метаданных с идентификатором запроса (аналог X-Request-ID)
+const requestIDKey = "x-request-id"
+
+// wrappedStream подменяет контекст серверного потока
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+// unaryInterceptor — общая цепочка для unary-вызовов: идентификатор запроса,
+// метрики и access-лог, аутентификация
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx = s.withRequestID(ctx)
+	start := time.Now()
+
+	var resp interface{}
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	s.observe(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// streamInterceptor — та же цепочка для потоковых вызовов
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := s.withRequestID(ss.Context())
+	start := time.Now()
+
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err == nil {
+		err = handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+	s.observe(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// withRequestID берёт x-request-id из метаданных или генерирует новый, возвращает его
+// в заголовках ответа и кладёт в контекст и в атрибуты текущего спана
+func (s *Server) withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDKey); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id)); err != nil {
+		slog.WarnContext(ctx, "gRPC: failed to set request id header", logging.Err(err))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("rpc.request_id", id))
+	return logging.WithRequestID(ctx, id)
+}
+
+// authenticate проверяет учётные данные для методов OrderService. Health и reflection
+// доступны без аутентификации, как /livez и /readyz в HTTP. Обращения пишутся в аудит-лог
+func (s *Server) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if !strings.HasPrefix(fullMethod, "/"+ordersv1.OrderService_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+	const scope = auth.ScopeOrdersRead
+
+	var apiKey, authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(strings.ToLower(auth.APIKeyHeader)); len(v) > 0 {
+			apiKey = v[0]
+		}
+		if v := md.Get("authorization"); len(v) > 0 {
+			authorization = v[0]
+		}
+	}
+
+	principal, err := s.authn.AuthenticateCredentials(apiKey, authorization)
+	if err != nil {
+		slog.WarnContext(ctx, "audit", slog.String("result", "unauthenticated"), slog.String("scope", string(scope)),
+			slog.String("rpc", fullMethod), slog.String("remote", peerAddr(ctx)), logging.Err(err))
+		if errors.Is(err, auth.ErrNoCredentials) {
+			return ctx, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		return ctx, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	audit := principal.Method != auth.MethodDisabled
+	if !principal.HasScope(scope) {
+		if audit {
+			slog.WarnContext(ctx, "audit", slog.String("result", "forbidden"), slog.String("subject", principal.Subject),
+				slog.String("auth", principal.Method), slog.String("scope", string(scope)),
+				slog.String("rpc", fullMethod), slog.String("remote", peerAddr(ctx)))
+		}
+		return ctx, status.Error(codes.PermissionDenied, "insufficient scope: "+string(scope))
+	}
+	if audit {
+		slog.InfoContext(ctx, "audit", slog.String("result", "allowed"), slog.String("subject", principal.Subject),
+			slog.String("auth", principal.Method), slog.String("scope", string(scope)),
+			slog.String("rpc", fullMethod), slog.String("remote", peerAddr(ctx)))
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// observe записывает метрики и access-лог вызова
+func (s *Server) observe(ctx context.Context, fullMethod string, start time.Time, err error) {
+	duration := time.Since(start)
+	code := status.Code(err)
+
+	telemetry.GRPCRequestDuration.WithLabelValues(fullMethod, code.String()).Observe(duration.Seconds())
+
+	attrs := []slog.Attr{
+		slog.String("request_id", logging.RequestIDFromContext(ctx)),
+		slog.String("rpc", fullMethod),
+		slog.String("code", code.String()),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+		slog.String("client", peerAddr(ctx)),
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()))
+	}
+	level := slog.LevelInfo
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		level = slog.LevelError
+	}
+	s.logger.LogAttrs(ctx, level, "grpc request", attrs...)
+}
+
+// peerAddr возвращает адрес клиента или пустую строку
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
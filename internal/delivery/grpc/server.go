@@ -0,0 +1,155 @@
+// Package grpcdelivery реализует gRPC API заказов для внутренних сервисов
+package grpcdelivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+
+	ordersv1 "WBtech_l0/api/orders/v1"
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/events"
+	"WBtech_l0/internal/health"
+)
+
+// Server — gRPC-сервер с OrderService, стандартным health-сервисом и reflection
+type Server struct {
+	cfg    *config.Config
+	checks *health.Registry
+	authn  *auth.Authenticator
+	logger *slog.Logger
+	health *grpchealth.Server
+	server *grpc.Server
+
+	healthInterval time.Duration // как часто статус health-сервиса сверяется с проверками готовности
+
+	done     chan struct{} // закрывается при остановке: завершает потоки WatchOrders
+	stopOnce sync.Once
+}
+
+// Deps — зависимости gRPC-сервера
+type Deps struct {
+	Usecase domain.OrderUsecase
+	Broker  *events.Broker
+	Checks  *health.Registry
+	Authn   *auth.Authenticator
+	Logger  *slog.Logger // access-лог; nil — slog.Default()
+}
+
+// NewServer создаёт gRPC-сервер. Трассировка общая с HTTP: otelgrpc использует
+// глобальный TracerProvider, настроенный telemetry.InitTracer
+func NewServer(cfg *config.Config, deps Deps) *Server {
+	s := &Server{
+		cfg:    cfg,
+		checks: deps.Checks,
+		authn:  deps.Authn,
+		logger: deps.Logger,
+		health: grpchealth.NewServer(),
+		done:   make(chan struct{}),
+
+		healthInterval: 5 * time.Second,
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+
+	s.server = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+		// Пинги держат долгие потоки WatchOrders живыми через прокси и NAT
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: cfg.Events.HeartbeatInterval}),
+	)
+	ordersv1.RegisterOrderServiceServer(s.server, &orderService{usecase: deps.Usecase, broker: deps.Broker, done: s.done})
+	healthpb.RegisterHealthServer(s.server, s.health)
+	if cfg.GRPCServer.Reflection {
+		reflection.Register(s.server)
+	}
+
+	// До первой проверки готовности сервис не принимает трафик
+	s.setServing(false)
+	return s
+}
+
+// Run слушает адрес из конфига и обслуживает вызовы до Shutdown
+func (s *Server) Run() error {
+	addr := fmt.Sprintf("%s:%s", s.cfg.GRPCServer.Host, s.cfg.GRPCServer.Port)
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("grpc listen: %w", err)
+	}
+	slog.Info("starting gRPC server", slog.String("addr", addr), slog.Bool("reflection", s.cfg.GRPCServer.Reflection))
+	return s.Serve(lis)
+}
+
+// Serve обслуживает вызовы на переданном listener
+func (s *Server) Serve(lis net.Listener) error {
+	go s.watchHealth()
+	if err := s.server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("grpc server failed: %w", err)
+	}
+	return nil
+}
+
+// Shutdown переводит health в NOT_SERVING, закрывает потоки WatchOrders и ждёт
+// завершения текущих вызовов. По истечении ctx соединения закрываются принудительно
+func (s *Server) Shutdown(ctx context.Context) error {
+	slog.Info("shutting down gRPC server")
+	s.stopOnce.Do(func() {
+		s.health.Shutdown()
+		close(s.done)
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return fmt.Errorf("grpc graceful stop: %w", ctx.Err())
+	}
+}
+
+// watchHealth периодически переносит результат проверок готовности в health-сервис
+func (s *Server) watchHealth() {
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), s.healthInterval)
+		s.setServing(s.checks.Check(ctx).OK())
+		cancel()
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setServing выставляет статус сервера в целом ("") и OrderService
+func (s *Server) setServing(ok bool) {
+	st := healthpb.HealthCheckResponse_NOT_SERVING
+	if ok {
+		st = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", st)
+	s.health.SetServingStatus(ordersv1.OrderService_ServiceDesc.ServiceName, st)
+}
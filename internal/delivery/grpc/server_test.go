@@ -0,0 +1,310 @@
+package grpcdelivery
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	ordersv1 "WBtech_l0/api/orders/v1"
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/events"
+	"WBtech_l0/internal/health"
+)
+
+// fakeUsecase хранит заказы в памяти
+type fakeUsecase struct {
+	orders map[string]domain.Order
+}
+
+func (f *fakeUsecase) GetOrder(_ context.Context, uid string) (domain.Order, error) {
+	o, ok := f.orders[uid]
+	if !ok {
+		return domain.Order{}, domain.ErrOrderNotFound
+	}
+	return o, nil
+}
+
+func (f *fakeUsecase) GetOrders(_ context.Context, uids []string) ([]domain.Order, []string, error) {
+	var orders []domain.Order
+	var missing []string
+	seen := make(map[string]bool)
+	for _, uid := range uids {
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		if o, ok := f.orders[uid]; ok {
+			orders = append(orders, o)
+		} else {
+			missing = append(missing, uid)
+		}
+	}
+	return orders, missing, nil
+}
+
+func (f *fakeUsecase) SaveOrder(_ context.Context, o domain.Order) error {
+	f.orders[o.OrderUID] = o
+	return nil
+}
+
+func (f *fakeUsecase) ListOrders(_ context.Context, afterUID string, limit int) ([]domain.Order, error) {
+	uids := make([]string, 0, len(f.orders))
+	for uid := range f.orders {
+		if uid > afterUID {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+	var out []domain.Order
+	for _, uid := range uids {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, f.orders[uid])
+	}
+	return out, nil
+}
+
+func (f *fakeUsecase) TrackParcel(context.Context, string) (domain.Parcel, error) {
+	return domain.Parcel{}, domain.ErrParcelNotFound
+}
+
+const (
+	readerKey = "reader-key"
+	piiKey    = "pii-key"
+)
+
+type testEnv struct {
+	server *Server
+	conn   *grpc.ClientConn
+	client ordersv1.OrderServiceClient
+	broker *events.Broker
+	ready  *health.Flag
+}
+
+func newTestEnv(t *testing.T, authEnabled bool) *testEnv {
+	t.Helper()
+
+	authn, err := auth.NewAuthenticator(config.AuthConfig{
+		Enabled: authEnabled,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "reader", KeyHash: auth.HashAPIKey(readerKey), Scopes: []string{"orders:read"}},
+			{Name: "support", KeyHash: auth.HashAPIKey(piiKey), Scopes: []string{"orders:read", "orders:read_pii"}},
+			{Name: "writer", KeyHash: auth.HashAPIKey("writer-key"), Scopes: []string{"orders:write"}},
+		},
+	})
+	require.NoError(t, err)
+
+	usecase := &fakeUsecase{orders: map[string]domain.Order{}}
+	for _, uid := range []string{"a1", "b2", "c3"} {
+		usecase.orders[uid] = domain.Order{
+			OrderUID: uid,
+			Entry:    "WBIL",
+			Delivery: domain.Delivery{Name: "Test Testov", Phone: "+79001231234", Email: "test@example.com", City: "Moscow"},
+			Items:    []domain.Item{{ChrtID: 1, Name: "Mascaras", Price: 453}},
+		}
+	}
+
+	ready := health.NewFlag("cache", "cache warm-up in progress")
+	checks := health.NewRegistry()
+	checks.Register(ready, 0, 0)
+
+	cfg := &config.Config{
+		GRPCServer: config.GRPCServerConfig{Reflection: true},
+		Events:     config.EventsConfig{HeartbeatInterval: time.Minute},
+	}
+	broker := events.NewBroker(10)
+	s := NewServer(cfg, Deps{Usecase: usecase, Broker: broker, Checks: checks, Authn: authn})
+	s.healthInterval = 10 * time.Millisecond
+
+	lis := bufconn.Listen(1 << 20)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = s.Shutdown(ctx)
+		require.NoError(t, <-served)
+	})
+	return &testEnv{server: s, conn: conn, client: ordersv1.NewOrderServiceClient(conn), broker: broker, ready: ready}
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func TestGetOrder(t *testing.T) {
+	env := newTestEnv(t, false)
+
+	var header metadata.MD
+	resp, err := env.client.GetOrder(context.Background(), &ordersv1.GetOrderRequest{OrderUid: "a1"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, "a1", resp.GetOrder().GetOrderUid())
+	require.Equal(t, "Mascaras", resp.GetOrder().GetItems()[0].GetName())
+	require.Len(t, header.Get("x-request-id"), 1)
+
+	_, err = env.client.GetOrder(context.Background(), &ordersv1.GetOrderRequest{OrderUid: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = env.client.GetOrder(context.Background(), &ordersv1.GetOrderRequest{OrderUid: "bad uid"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAuthentication(t *testing.T) {
+	env := newTestEnv(t, true)
+	req := &ordersv1.GetOrderRequest{OrderUid: "a1"}
+
+	_, err := env.client.GetOrder(context.Background(), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = env.client.GetOrder(withKey("wrong"), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = env.client.GetOrder(withKey("writer-key"), req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err := env.client.GetOrder(withKey(readerKey), req)
+	require.NoError(t, err)
+	require.Equal(t, "+7******1234", resp.GetOrder().GetDelivery().GetPhone())
+	require.Equal(t, "Moscow", resp.GetOrder().GetDelivery().GetCity())
+
+	resp, err = env.client.GetOrder(withKey(piiKey), req)
+	require.NoError(t, err)
+	require.Equal(t, "+79001231234", resp.GetOrder().GetDelivery().GetPhone())
+
+	// health доступен без учётных данных
+	_, err = healthpb.NewHealthClient(env.conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+}
+
+func TestBatchGetOrders(t *testing.T) {
+	env := newTestEnv(t, false)
+
+	resp, err := env.client.BatchGetOrders(context.Background(), &ordersv1.BatchGetOrdersRequest{
+		OrderUids: []string{"c3", "nope", "a1", "c3"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetOrders(), 2)
+	require.Equal(t, "c3", resp.GetOrders()[0].GetOrderUid())
+	require.Equal(t, "a1", resp.GetOrders()[1].GetOrderUid())
+	require.Equal(t, []string{"nope"}, resp.GetMissing())
+
+	_, err = env.client.BatchGetOrders(context.Background(), &ordersv1.BatchGetOrdersRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = env.client.BatchGetOrders(context.Background(), &ordersv1.BatchGetOrdersRequest{
+		OrderUids: make([]string, domain.MaxBatchGetSize+1),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListOrders(t *testing.T) {
+	env := newTestEnv(t, false)
+
+	var uids []string
+	token := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "pagination does not terminate")
+		resp, err := env.client.ListOrders(context.Background(), &ordersv1.ListOrdersRequest{PageSize: 2, PageToken: token})
+		require.NoError(t, err)
+		for _, o := range resp.GetOrders() {
+			uids = append(uids, o.GetOrderUid())
+		}
+		token = resp.GetNextPageToken()
+		if token == "" {
+			break
+		}
+	}
+	require.Equal(t, []string{"a1", "b2", "c3"}, uids)
+
+	_, err := env.client.ListOrders(context.Background(), &ordersv1.ListOrdersRequest{PageToken: "!!!"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatchOrders(t *testing.T) {
+	env := newTestEnv(t, false)
+
+	first := env.broker.Publish(events.TypeOrderSaved, domain.Order{OrderUID: "old", Entry: "WBIL"})
+	env.broker.Publish(events.TypeOrderSaved, domain.Order{OrderUID: "replayed", Entry: "WBIL"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := env.client.WatchOrders(ctx, &ordersv1.WatchOrdersRequest{Entry: "WBIL", LastEventId: first.ID})
+	require.NoError(t, err)
+
+	e, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "replayed", e.GetOrder().GetOrderUid())
+
+	// Подписка регистрируется до отправки истории, поэтому новое событие не теряется
+	env.broker.Publish(events.TypeOrderSaved, domain.Order{OrderUID: "skipped", Entry: "OTHER"})
+	live := env.broker.Publish(events.TypeOrderSaved, domain.Order{OrderUID: "live", Entry: "WBIL"})
+	e, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, live.ID, e.GetId())
+	require.Equal(t, "live", e.GetOrder().GetOrderUid())
+	require.Equal(t, events.TypeOrderSaved, e.GetType())
+
+	// Остановка сервера закрывает поток, чтобы клиент переподключился к другому экземпляру
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second)
+	defer shutdownCancel()
+	require.NoError(t, env.server.Shutdown(shutdownCtx))
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestHealthFollowsReadiness(t *testing.T) {
+	env := newTestEnv(t, false)
+	client := healthpb.NewHealthClient(env.conn)
+	service := ordersv1.OrderService_ServiceDesc.ServiceName
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	env.ready.Set(true)
+	require.Eventually(t, func() bool {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReflection(t *testing.T) {
+	env := newTestEnv(t, true)
+
+	stream, err := reflectionpb.NewServerReflectionClient(env.conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	require.Contains(t, services, ordersv1.OrderService_ServiceDesc.ServiceName)
+	require.Contains(t, services, "grpc.health.v1.Health")
+}
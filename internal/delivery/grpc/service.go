@@ -0,0 +1,183 @@
+package grpcdelivery
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ordersv1 "WBtech_l0/api/orders/v1"
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/events"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/telemetry"
+)
+
+// Ограничения запросов OrderService
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// orderService реализует ordersv1.OrderServiceServer поверх domain.OrderUsecase
+type orderService struct {
+	ordersv1.UnimplementedOrderServiceServer
+
+	usecase domain.OrderUsecase
+	broker  *events.Broker
+	done    <-chan struct{} // закрывается при остановке сервера
+}
+
+// GetOrder возвращает заказ по order_uid
+func (s *orderService) GetOrder(ctx context.Context, req *ordersv1.GetOrderRequest) (*ordersv1.GetOrderResponse, error) {
+	uid := req.GetOrderUid()
+	if !domain.ValidOrderUID(uid) {
+		telemetry.OrdersProcessed.WithLabelValues("grpc", "error").Inc()
+		return nil, status.Error(codes.InvalidArgument, "invalid order_uid format")
+	}
+
+	order, err := s.usecase.GetOrder(ctx, uid)
+	if err != nil {
+		telemetry.OrdersProcessed.WithLabelValues("grpc", "error").Inc()
+		return nil, orderError(ctx, uid, err)
+	}
+	telemetry.OrdersProcessed.WithLabelValues("grpc", "success").Inc()
+	return &ordersv1.GetOrderResponse{Order: toProtoOrder(auth.VisibleOrder(ctx, order))}, nil
+}
+
+// BatchGetOrders возвращает найденные заказы в порядке запроса и список отсутствующих:
+// сначала из кеша, промахи — одним запросом к БД
+func (s *orderService) BatchGetOrders(ctx context.Context, req *ordersv1.BatchGetOrdersRequest) (*ordersv1.BatchGetOrdersResponse, error) {
+	uids := req.GetOrderUids()
+	if len(uids) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "order_uids required")
+	}
+	if len(uids) > domain.MaxBatchGetSize {
+		return nil, status.Errorf(codes.InvalidArgument, "too many order_uids: %d, max %d", len(uids), domain.MaxBatchGetSize)
+	}
+	for _, uid := range uids {
+		if !domain.ValidOrderUID(uid) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid order_uid format: %q", uid)
+		}
+	}
+
+	orders, missing, err := s.usecase.GetOrders(ctx, uids)
+	if err != nil {
+		telemetry.OrdersProcessed.WithLabelValues("grpc", "error").Inc()
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.FromContextError(err).Err()
+		}
+		slog.ErrorContext(ctx, "gRPC: failed to get orders", logging.Err(err))
+		return nil, status.Error(codes.Internal, "failed to get orders")
+	}
+	telemetry.OrdersProcessed.WithLabelValues("grpc", "success").Add(float64(len(orders)))
+
+	resp := &ordersv1.BatchGetOrdersResponse{Missing: missing}
+	for _, o := range orders {
+		resp.Orders = append(resp.Orders, toProtoOrder(auth.VisibleOrder(ctx, o)))
+	}
+	return resp, nil
+}
+
+// ListOrders отдаёт страницу заказов в порядке order_uid. page_token — это
+// закодированный order_uid последнего заказа предыдущей страницы
+func (s *orderService) ListOrders(ctx context.Context, req *ordersv1.ListOrdersRequest) (*ordersv1.ListOrdersResponse, error) {
+	size := int(req.GetPageSize())
+	switch {
+	case size < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+	after, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Берём на один заказ больше, чтобы узнать, есть ли следующая страница
+	orders, err := s.usecase.ListOrders(ctx, after, size+1)
+	if err != nil {
+		slog.ErrorContext(ctx, "gRPC: failed to list orders", logging.Err(err))
+		return nil, status.Error(codes.Internal, "failed to list orders")
+	}
+
+	resp := &ordersv1.ListOrdersResponse{}
+	if len(orders) > size {
+		orders = orders[:size]
+		resp.NextPageToken = encodePageToken(orders[size-1].OrderUID)
+	}
+	resp.Orders = make([]*ordersv1.Order, 0, len(orders))
+	for _, o := range orders {
+		resp.Orders = append(resp.Orders, toProtoOrder(auth.VisibleOrder(ctx, o)))
+	}
+	return resp, nil
+}
+
+// WatchOrders отдаёт пропущенные после last_event_id события, затем новые.
+// Если клиент не успевает читать поток или сервер останавливается, поток закрывается
+// с UNAVAILABLE, и клиент переподключается с id последнего полученного события
+func (s *orderService) WatchOrders(req *ordersv1.WatchOrdersRequest, stream ordersv1.OrderService_WatchOrdersServer) error {
+	filter := events.Filter{
+		Entry:           req.GetEntry(),
+		DeliveryService: req.GetDeliveryService(),
+		CustomerID:      req.GetCustomerId(),
+	}
+	sub, replay := s.broker.Subscribe(filter, req.GetLastEventId())
+	defer s.broker.Unsubscribe(sub)
+
+	ctx := stream.Context()
+	for _, e := range replay {
+		if err := stream.Send(toProtoEvent(e, auth.VisibleOrder(ctx, e.Order))); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server is shutting down, resume with last_event_id")
+		case e, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "subscriber is too slow, resume with last_event_id")
+			}
+			if err := stream.Send(toProtoEvent(e, auth.VisibleOrder(ctx, e.Order))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// orderError переводит ошибку usecase в статус gRPC, не раскрывая детали БД клиенту
+func orderError(ctx context.Context, uid string, err error) error {
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		return status.Errorf(codes.NotFound, "order %s not found", uid)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	slog.ErrorContext(ctx, "gRPC: failed to get order", logging.OrderUID(uid), logging.Err(err))
+	return status.Error(codes.Internal, "failed to get order")
+}
+
+func encodePageToken(lastUID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastUID))
+}
+
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !domain.ValidOrderUID(string(raw)) {
+		return "", fmt.Errorf("invalid page_token")
+	}
+	return string(raw), nil
+}
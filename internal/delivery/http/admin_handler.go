@@ -0,0 +1,322 @@
+package httpdelivery
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/repository/cache"
+)
+
+// adminPrefix — префикс административного API
+const adminPrefix = "/admin"
+
+// Ограничения списка записей кеша в GET /admin/cache
+const (
+	defaultAdminCacheLimit = 100
+	maxAdminCacheLimit     = 1000
+)
+
+// ConsumerControl — управление Kafka consumer из административного API
+type ConsumerControl interface {
+	Pause()
+	Resume()
+	Paused() bool
+}
+
+// AdminCacheEntry — запись кеша в ответах административного API
+type AdminCacheEntry struct {
+	OrderUID   string        `json:"order_uid"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	TTLSeconds float64       `json:"ttl_seconds"`
+	Order      *domain.Order `json:"order,omitempty"`
+}
+
+// AdminCacheList — данные ответа GET /admin/cache
+type AdminCacheList struct {
+	Size              int               `json:"size"`
+	MaxSize           int               `json:"max_size"`
+	DefaultTTLSeconds float64           `json:"default_ttl_seconds"`
+	Warmup            AdminWarmup       `json:"warmup"`
+	Entries           []AdminCacheEntry `json:"entries"`
+	Truncated         bool              `json:"truncated"`
+}
+
+// AdminWarmup — ход загрузки кеша из БД
+type AdminWarmup struct {
+	Running         bool    `json:"running"`
+	Total           int     `json:"total"`
+	Loaded          int     `json:"loaded"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// AdminEvictRequest — тело запроса POST /admin/cache:evict
+type AdminEvictRequest struct {
+	Pattern string `json:"pattern"`
+}
+
+// AdminEvictResult — число удалённых из кеша записей
+type AdminEvictResult struct {
+	Evicted int `json:"evicted"`
+}
+
+// AdminConsumerStatus — состояние Kafka consumer
+type AdminConsumerStatus struct {
+	Paused bool `json:"paused"`
+}
+
+// AdminLogLevel — уровень логирования; Previous заполняется при изменении
+type AdminLogLevel struct {
+	Level    string `json:"level"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// adminOnly пропускает в административное API только аутентифицированных клиентов.
+// При auth.enabled=false любой клиент получил бы право admin, поэтому API закрыто
+func adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := auth.PrincipalFromContext(r.Context()); !ok || p.Method == auth.MethodDisabled {
+			auditAdmin(r, "denied", slog.String("result", "forbidden"), slog.String("reason", "authentication disabled"))
+			writeJSON(w, http.StatusForbidden, JSONResponse{Success: false, Error: "admin API requires authentication (auth.enabled)"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// auditAdmin пишет в аудит-лог административное действие и его параметры
+func auditAdmin(r *http.Request, action string, attrs ...slog.Attr) {
+	args := []any{slog.String("action", action)}
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+		args = append(args, slog.String("subject", p.Subject), slog.String("auth", p.Method))
+	}
+	args = append(args, slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.String("remote", r.RemoteAddr))
+	for _, a := range attrs {
+		args = append(args, a)
+	}
+	slog.InfoContext(r.Context(), "audit", args...)
+}
+
+func newAdminCacheEntry(uid string, expiresAt time.Time) AdminCacheEntry {
+	return AdminCacheEntry{OrderUID: uid, ExpiresAt: expiresAt.UTC(), TTLSeconds: time.Until(expiresAt).Seconds()}
+}
+
+// MakeAdminCacheListHandler возвращает размер, лимиты и ход прогрева кеша и записи
+// с оставшимся TTL. Параметр pattern отбирает order_uid по шаблону ("test-*"), limit
+// ограничивает число записей
+func MakeAdminCacheListHandler(c *cache.OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pattern := r.URL.Query().Get("pattern")
+		limit := defaultAdminCacheLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxAdminCacheLimit {
+				writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "limit must be between 1 and " + strconv.Itoa(maxAdminCacheLimit)})
+				return
+			}
+			limit = n
+		}
+		entries, err := c.Entries(pattern)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "invalid pattern: " + err.Error()})
+			return
+		}
+		auditAdmin(r, "cache.list", slog.String("pattern", pattern), slog.Int("entries", len(entries)))
+
+		ttl, maxSize := c.Limits()
+		warmup := c.Warmup()
+		list := AdminCacheList{
+			Size:              c.GetStats().Size,
+			MaxSize:           maxSize,
+			DefaultTTLSeconds: ttl.Seconds(),
+			Warmup: AdminWarmup{
+				Running:         warmup.Running,
+				Total:           warmup.Total,
+				Loaded:          warmup.Loaded,
+				DurationSeconds: warmup.Duration.Seconds(),
+			},
+			Entries:   make([]AdminCacheEntry, 0, min(len(entries), limit)),
+			Truncated: len(entries) > limit,
+		}
+		for _, e := range entries[:min(len(entries), limit)] {
+			list.Entries = append(list.Entries, newAdminCacheEntry(e.OrderUID, e.ExpiresAt))
+		}
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: list})
+	}
+}
+
+// MakeAdminCacheEntryHandler возвращает запись кеша с заказом и оставшимся TTL.
+// Статистика попаданий при этом не меняется
+func MakeAdminCacheEntryHandler(c *cache.OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := r.PathValue("uid")
+		item, ok := c.Peek(uid)
+		auditAdmin(r, "cache.get", logging.OrderUID(uid), slog.Bool("found", ok))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, JSONResponse{Success: false, Error: "order is not in cache"})
+			return
+		}
+		entry := newAdminCacheEntry(uid, item.ExpiresAt)
+		order := auth.VisibleOrder(r.Context(), item.Order)
+		entry.Order = &order
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: entry})
+	}
+}
+
+// MakeAdminCacheEvictHandler удаляет из кеша один заказ
+func MakeAdminCacheEvictHandler(c *cache.OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := r.PathValue("uid")
+		ok := c.Remove(uid)
+		auditAdmin(r, "cache.evict", logging.OrderUID(uid), slog.Bool("found", ok))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, JSONResponse{Success: false, Error: "order is not in cache"})
+			return
+		}
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: AdminEvictResult{Evicted: 1}})
+	}
+}
+
+// MakeAdminCacheEvictPatternHandler удаляет из кеша заказы, order_uid которых
+// подходит под шаблон из тела запроса
+func MakeAdminCacheEvictPatternHandler(c *cache.OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AdminEvictRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "invalid request body"})
+			return
+		}
+		if req.Pattern == "" {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "pattern required"})
+			return
+		}
+		n, err := c.RemoveMatching(req.Pattern)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "invalid pattern: " + err.Error()})
+			return
+		}
+		auditAdmin(r, "cache.evict_pattern", slog.String("pattern", req.Pattern), slog.Int("evicted", n))
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: AdminEvictResult{Evicted: n}})
+	}
+}
+
+// MakeAdminCacheClearHandler очищает кеш
+func MakeAdminCacheClearHandler(c *cache.OrderCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := c.GetStats().Size
+		c.Clear()
+		auditAdmin(r, "cache.clear", slog.Int("evicted", n))
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: AdminEvictResult{Evicted: n}})
+	}
+}
+
+// MakeAdminCacheRewarmHandler запускает повторную загрузку кеша из БД в фоне и сразу
+// отвечает 202; ход загрузки виден в GET /admin/cache. Одновременно идёт одна загрузка
+func MakeAdminCacheRewarmHandler(rewarm func(context.Context) error) http.HandlerFunc {
+	var running atomic.Bool
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rewarm == nil {
+			writeJSON(w, http.StatusServiceUnavailable, JSONResponse{Success: false, Error: "cache re-warm is not available"})
+			return
+		}
+		if !running.CompareAndSwap(false, true) {
+			auditAdmin(r, "cache.rewarm", slog.Bool("started", false))
+			writeJSON(w, http.StatusConflict, JSONResponse{Success: false, Error: "cache re-warm is already running"})
+			return
+		}
+		auditAdmin(r, "cache.rewarm", slog.Bool("started", true))
+
+		// Загрузка переживает запрос, но сохраняет его трейс и request_id в логах
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			defer running.Store(false)
+			start := time.Now()
+			if err := rewarm(ctx); err != nil {
+				slog.ErrorContext(ctx, "cache re-warm failed", logging.Err(err))
+				return
+			}
+			slog.InfoContext(ctx, "cache re-warmed", slog.Duration("duration", time.Since(start)))
+		}()
+		writeJSON(w, http.StatusAccepted, JSONResponse{Success: true, Data: map[string]string{"status": "started"}})
+	}
+}
+
+// MakeAdminConsumerHandler возвращает состояние Kafka consumer
+func MakeAdminConsumerHandler(consumer ConsumerControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if consumer == nil {
+			writeJSON(w, http.StatusServiceUnavailable, JSONResponse{Success: false, Error: "consumer is not available"})
+			return
+		}
+		auditAdmin(r, "consumer.status")
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: AdminConsumerStatus{Paused: consumer.Paused()}})
+	}
+}
+
+// MakeAdminConsumerPauseHandler приостанавливает (pause=true) или возобновляет
+// обработку сообщений Kafka
+func MakeAdminConsumerPauseHandler(consumer ConsumerControl, pause bool) http.HandlerFunc {
+	action := "consumer.resume"
+	if pause {
+		action = "consumer.pause"
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if consumer == nil {
+			writeJSON(w, http.StatusServiceUnavailable, JSONResponse{Success: false, Error: "consumer is not available"})
+			return
+		}
+		was := consumer.Paused()
+		if pause {
+			consumer.Pause()
+		} else {
+			consumer.Resume()
+		}
+		auditAdmin(r, action, slog.Bool("was_paused", was))
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: AdminConsumerStatus{Paused: consumer.Paused()}})
+	}
+}
+
+// levelName возвращает имя уровня в том виде, в каком он задаётся в log.level
+func levelName(l slog.Level) string {
+	return strings.ToLower(l.String())
+}
+
+// MakeAdminLogLevelHandler возвращает текущий уровень логирования
+func MakeAdminLogLevelHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auditAdmin(r, "log_level.get")
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: AdminLogLevel{Level: levelName(logging.Level())}})
+	}
+}
+
+// MakeAdminSetLogLevelHandler меняет уровень логирования до следующего перечитывания
+// конфигурации: тогда уровень снова берётся из log.level
+func MakeAdminSetLogLevelHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AdminLogLevel
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "invalid request body"})
+			return
+		}
+		previous := levelName(logging.Level())
+		if err := logging.SetLevel(req.Level); err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: err.Error()})
+			return
+		}
+		level := levelName(logging.Level())
+		auditAdmin(r, "log_level.set", slog.String("level", level), slog.String("previous", previous))
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: AdminLogLevel{Level: level, Previous: previous}})
+	}
+}
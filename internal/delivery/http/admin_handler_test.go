@@ -0,0 +1,235 @@
+package httpdelivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/repository/cache"
+)
+
+type mockConsumer struct {
+	mu     sync.Mutex
+	paused bool
+}
+
+func (m *mockConsumer) Pause()  { m.mu.Lock(); m.paused = true; m.mu.Unlock() }
+func (m *mockConsumer) Resume() { m.mu.Lock(); m.paused = false; m.mu.Unlock() }
+func (m *mockConsumer) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+// captureLogs перенаправляет логи по умолчанию в буфер до конца теста
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(logging.New(&buf, "json"))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func adminConfig() *config.Config {
+	return &config.Config{Auth: config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+		{Name: "ops", KeyHash: auth.HashAPIKey("admin-key"), Scopes: []string{"admin"}},
+		{Name: "reader", KeyHash: auth.HashAPIKey("reader-key"), Scopes: []string{"orders:read"}},
+	}}}
+}
+
+func TestAdminAPI(t *testing.T) {
+	logs := captureLogs(t)
+	orderCache := cache.NewOrderCache(time.Minute, 10)
+	for _, uid := range []string{"b563feb7b2b84b6test", "test-1", "test-2"} {
+		orderCache.Set(domain.Order{OrderUID: uid})
+	}
+	consumer := &mockConsumer{}
+	rewarmed := make(chan struct{})
+	s := newTestServerWithDeps(t, &MockUsecase{}, adminConfig(), func(d *Deps) {
+		d.Cache = orderCache
+		d.Consumer = consumer
+		d.Rewarm = func(context.Context) error {
+			orderCache.Set(domain.Order{OrderUID: "rewarmed"})
+			close(rewarmed)
+			return nil
+		}
+	})
+	t.Cleanup(func() { require.NoError(t, logging.SetLevel("info")) })
+
+	do := func(method, path, body string) (int, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(auth.APIKeyHeader, "admin-key")
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		return w.Code, resp
+	}
+
+	// список записей с TTL и фильтром по шаблону
+	code, resp := do("GET", "/admin/cache?pattern=test-*&limit=1", "")
+	require.Equal(t, http.StatusOK, code)
+	list := resp["data"].(map[string]any)
+	require.Equal(t, 3.0, list["size"])
+	require.Equal(t, true, list["truncated"])
+	entry := list["entries"].([]any)[0].(map[string]any)
+	require.Equal(t, "test-1", entry["order_uid"])
+	require.Greater(t, entry["ttl_seconds"], 0.0)
+
+	code, resp = do("GET", "/admin/cache/b563feb7b2b84b6test", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "b563feb7b2b84b6test", resp["data"].(map[string]any)["order"].(map[string]any)["order_uid"])
+	require.Zero(t, orderCache.GetStats().Hits, "inspecting an entry must not count as a hit")
+
+	code, _ = do("DELETE", "/admin/cache/b563feb7b2b84b6test", "")
+	require.Equal(t, http.StatusOK, code)
+	code, _ = do("DELETE", "/admin/cache/b563feb7b2b84b6test", "")
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, resp = do("POST", "/admin/cache:evict", `{"pattern":"test-*"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 2.0, resp["data"].(map[string]any)["evicted"])
+	code, _ = do("POST", "/admin/cache:evict", `{"pattern":"["}`)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = do("POST", "/admin/cache:rewarm", "")
+	require.Equal(t, http.StatusAccepted, code)
+	<-rewarmed
+	code, resp = do("POST", "/admin/cache:clear", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 1.0, resp["data"].(map[string]any)["evicted"])
+
+	code, resp = do("POST", "/admin/consumer:pause", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, true, resp["data"].(map[string]any)["paused"])
+	require.True(t, consumer.Paused())
+	code, _ = do("POST", "/admin/consumer:resume", "")
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, consumer.Paused())
+
+	code, resp = do("PUT", "/admin/log-level", `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]any{"level": "debug", "previous": "info"}, resp["data"])
+	require.Equal(t, slog.LevelDebug, logging.Level())
+	code, _ = do("PUT", "/admin/log-level", `{"level":"verbose"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = do("GET", "/admin/unknown", "")
+	require.Equal(t, http.StatusNotFound, code)
+
+	// каждое действие попадает в аудит-лог с субъектом и параметрами
+	actions := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var e map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		if e["msg"] == "audit" && e["action"] != nil {
+			require.Equal(t, "ops", e["subject"])
+			actions[e["action"].(string)] = true
+		}
+	}
+	for _, action := range []string{"cache.list", "cache.get", "cache.evict", "cache.evict_pattern", "cache.rewarm",
+		"cache.clear", "consumer.pause", "consumer.resume", "log_level.set"} {
+		require.True(t, actions[action], "no audit record for %s", action)
+	}
+}
+
+func TestAdminAPI_Access(t *testing.T) {
+	captureLogs(t)
+
+	tests := []struct {
+		name   string
+		cfg    *config.Config
+		key    string
+		status int
+	}{
+		{"no credentials", adminConfig(), "", http.StatusUnauthorized},
+		{"missing scope", adminConfig(), "reader-key", http.StatusForbidden},
+		// без аутентификации любой клиент получил бы право admin
+		{"auth disabled", &config.Config{}, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServerWithConfig(t, &MockUsecase{}, tt.cfg)
+			req := httptest.NewRequest("POST", "/admin/cache:clear", nil)
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			s.handler().ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestAdminAPI_LogLevelSurvivesReload(t *testing.T) {
+	captureLogs(t)
+	cfg := adminConfig()
+	cfg.Log.Level = "info"
+	cfg.Cache.DefaultTTL = time.Hour
+	require.NoError(t, logging.SetLevel(cfg.Log.Level))
+	t.Cleanup(func() { require.NoError(t, logging.SetLevel("info")) })
+
+	next := *cfg
+	reloader := config.NewReloader(cfg, func() (*config.Config, error) {
+		loaded := next
+		return &loaded, nil
+	})
+	reloader.OnReload(func(prev, c *config.Config) {
+		require.NoError(t, logging.ReloadLevel(prev.Log, c.Log))
+	})
+
+	s := newTestServerWithConfig(t, &MockUsecase{}, cfg)
+	req := httptest.NewRequest("PUT", "/admin/log-level", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set(auth.APIKeyHeader, "admin-key")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// перечитывание другого ключа не сбрасывает уровень, заданный через admin API
+	next.Cache.DefaultTTL = 2 * time.Hour
+	require.NoError(t, reloader.Reload())
+	require.Equal(t, uint64(2), reloader.Status().Generation)
+	require.Equal(t, slog.LevelDebug, logging.Level())
+
+	// изменение log.level в конфиге применяется
+	next.Log.Level = "warn"
+	require.NoError(t, reloader.Reload())
+	require.Equal(t, slog.LevelWarn, logging.Level())
+}
+
+func TestAdminAPI_RewarmConflict(t *testing.T) {
+	captureLogs(t)
+	release := make(chan struct{})
+	h := MakeAdminCacheRewarmHandler(func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/admin/cache:rewarm", nil))
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/admin/cache:rewarm", nil))
+	require.Equal(t, http.StatusConflict, w.Code)
+	close(release)
+}
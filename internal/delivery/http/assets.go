@@ -0,0 +1,228 @@
+package httpdelivery
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/web"
+)
+
+// staticPrefix — URL-префикс статических файлов
+const staticPrefix = "/static/"
+
+// staticFile — статический файл в памяти
+type staticFile struct {
+	name    string // исходное имя, например index.css
+	content []byte
+	modTime time.Time
+}
+
+// assetSet — согласованный снимок шаблонов и статики
+type assetSet struct {
+	templates *template.Template
+	urls      map[string]string      // index.css -> /static/index.3f2a1b9c0d.css
+	files     map[string]*staticFile // index.3f2a1b9c0d.css -> файл
+	plain     map[string]*staticFile // index.css -> файл (без хеша, для старых ссылок)
+}
+
+// Assets отдаёт HTML-шаблоны и статические файлы. В обычном режиме они берутся
+// из встроенной в бинарник web.FS, в dev-режиме — с диска с перезагрузкой при изменениях.
+// К именам статических файлов добавляется хеш содержимого, поэтому их можно
+// кешировать в браузере бессрочно
+type Assets struct {
+	fsys    fs.FS
+	dev     bool
+	watcher *fsnotify.Watcher
+
+	mu  sync.RWMutex
+	set *assetSet
+}
+
+// NewAssets загружает шаблоны и статику согласно настройкам
+func NewAssets(cfg config.WebConfig) (*Assets, error) {
+	a := &Assets{dev: cfg.DevMode}
+	if cfg.DevMode {
+		a.fsys = os.DirFS(cfg.Dir)
+	} else {
+		a.fsys = web.FS
+	}
+
+	if err := a.reload(); err != nil {
+		return nil, err
+	}
+	if cfg.DevMode {
+		if err := a.watch(cfg.Dir); err != nil {
+			return nil, fmt.Errorf("watch %s: %w", cfg.Dir, err)
+		}
+		slog.Info("web assets dev mode: serving and watching directory", slog.String("dir", cfg.Dir))
+	}
+	return a, nil
+}
+
+// Close останавливает отслеживание изменений в dev-режиме
+func (a *Assets) Close() error {
+	if a.watcher != nil {
+		return a.watcher.Close()
+	}
+	return nil
+}
+
+// reload заново читает шаблоны и статику. При ошибке предыдущий снимок сохраняется
+func (a *Assets) reload() error {
+	set := &assetSet{
+		urls:  make(map[string]string),
+		files: make(map[string]*staticFile),
+		plain: make(map[string]*staticFile),
+	}
+
+	err := fs.WalkDir(a.fsys, "static", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(a.fsys, p)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", p, err)
+		}
+		name := strings.TrimPrefix(p, "static/")
+		sum := sha256.Sum256(content)
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:5]) + ext
+
+		f := &staticFile{name: name, content: content, modTime: time.Now()}
+		set.urls[name] = staticPrefix + hashed
+		set.files[hashed] = f
+		set.plain[name] = f
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("load static files: %w", err)
+	}
+
+	funcs := template.FuncMap{
+		// asset возвращает URL статического файла с хешем содержимого
+		"asset": func(name string) (string, error) {
+			url, ok := set.urls[name]
+			if !ok {
+				return "", fmt.Errorf("unknown asset %q", name)
+			}
+			return url, nil
+		},
+	}
+	for k, v := range templateFunctions {
+		funcs[k] = v
+	}
+	tmpl, err := template.New("").Funcs(funcs).ParseFS(a.fsys, "*.html")
+	if err != nil {
+		return fmt.Errorf("parse templates: %w", err)
+	}
+	set.templates = tmpl
+
+	a.mu.Lock()
+	a.set = set
+	a.mu.Unlock()
+	return nil
+}
+
+// watch перезагружает ресурсы при изменении файлов в dir и dir/static
+func (a *Assets) watch(dir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	for _, d := range []string{dir, filepath.Join(dir, "static")} {
+		if err := watcher.Add(d); err != nil {
+			_ = watcher.Close()
+			return fmt.Errorf("watch %s: %w", d, err)
+		}
+	}
+	a.watcher = watcher
+
+	go func() {
+		// Редакторы сохраняют файл несколькими событиями — перезагружаемся один раз после паузы
+		var debounce <-chan time.Time
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				debounce = time.After(100 * time.Millisecond)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("web assets watcher failed", logging.Err(err))
+			case <-debounce:
+				if err := a.reload(); err != nil {
+					slog.Error("web assets reload failed, keeping previous version", logging.Err(err))
+				} else {
+					slog.Info("web assets reloaded")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Template возвращает шаблон по имени файла
+func (a *Assets) Template(name string) (*template.Template, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	tmpl := a.set.templates.Lookup(name)
+	if tmpl == nil {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+	return tmpl, nil
+}
+
+// URL возвращает адрес статического файла с хешем содержимого
+func (a *Assets) URL(name string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.set.urls[name]
+}
+
+// ServeStatic отдаёт файл из /static/. Файлы с хешем в имени кешируются бессрочно,
+// обращения по исходному имени — с обязательной перепроверкой
+func (a *Assets) ServeStatic(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, staticPrefix)
+
+	a.mu.RLock()
+	f, hashed := a.set.files[name]
+	if !hashed {
+		f = a.set.plain[name]
+	}
+	a.mu.RUnlock()
+
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if ctype := mime.TypeByExtension(path.Ext(f.name)); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	if hashed && !a.dev {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	http.ServeContent(w, r, f.name, f.modTime, bytes.NewReader(f.content))
+}
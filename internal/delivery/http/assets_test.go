@@ -0,0 +1,83 @@
+package httpdelivery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/config"
+)
+
+func TestNewAssets_Embedded(t *testing.T) {
+	// встроенные шаблоны должны разбираться без обращения к диску
+	a, err := NewAssets(config.WebConfig{})
+	require.NoError(t, err)
+
+	for _, name := range []string{"index.html", "order_template.html"} {
+		_, err := a.Template(name)
+		require.NoError(t, err, name)
+	}
+	require.Regexp(t, `^/static/index\.[0-9a-f]{10}\.js$`, a.URL("index.js"))
+}
+
+func TestAssets_ServeStatic(t *testing.T) {
+	a := newTestAssets(t)
+	hashedURL := a.URL("order.css")
+	require.True(t, strings.HasPrefix(hashedURL, staticPrefix+"order."))
+
+	w := httptest.NewRecorder()
+	a.ServeStatic(w, httptest.NewRequest("GET", hashedURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "body { color: red; }", w.Body.String())
+	require.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+	require.Contains(t, w.Header().Get("Content-Type"), "text/css")
+
+	// по исходному имени файл тоже доступен, но без долгого кеширования
+	w = httptest.NewRecorder()
+	a.ServeStatic(w, httptest.NewRequest("GET", "/static/order.css", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+	w = httptest.NewRecorder()
+	a.ServeStatic(w, httptest.NewRequest("GET", "/static/missing.js", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAssets_DevModeReload(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "static"), 0755))
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("index.html", `v1 {{asset "app.js"}}`)
+	write("static/app.js", "one")
+
+	a, err := NewAssets(config.WebConfig{DevMode: true, Dir: dir})
+	require.NoError(t, err)
+	defer func() { _ = a.Close() }()
+	oldURL := a.URL("app.js")
+
+	write("index.html", `v2 {{asset "app.js"}}`)
+	write("static/app.js", "two")
+
+	require.Eventually(t, func() bool {
+		tmpl, err := a.Template("index.html")
+		if err != nil {
+			return false
+		}
+		var sb strings.Builder
+		return tmpl.Execute(&sb, nil) == nil && strings.HasPrefix(sb.String(), "v2") && a.URL("app.js") != oldURL
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// ошибка в шаблоне не ломает уже загруженную версию
+	write("index.html", `{{broken`)
+	time.Sleep(300 * time.Millisecond)
+	_, err = a.Template("index.html")
+	require.NoError(t, err)
+}
@@ -0,0 +1,79 @@
+package httpdelivery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/logging"
+)
+
+// authResultKey — ключ контекста с результатом authenticate
+type authResultKey struct{}
+
+// authResult — результат аутентификации запроса: клиент или ошибка
+type authResult struct {
+	principal auth.Principal
+	err       error
+}
+
+// authenticate проверяет учётные данные до ограничения частоты запросов и кладёт результат
+// в контекст: успешный — как auth.Principal, ошибку — для requireScope. Так запросы
+// с неверным ключом или токеном считаются в лимите по IP, и перебор ключей упирается в 429
+func authenticate(authn *auth.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authn.Authenticate(r)
+		ctx := context.WithValue(r.Context(), authResultKey{}, authResult{principal: principal, err: err})
+		if err == nil {
+			ctx = auth.WithPrincipal(ctx, principal)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireScope пропускает запрос дальше, только если клиент аутентифицирован
+// и обладает нужным правом. Каждое обращение пишется в аудит-лог. Если перед ним
+// стоит authenticate, используется её результат, иначе запрос аутентифицируется здесь
+func requireScope(authn *auth.Authenticator, scope auth.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, ok := r.Context().Value(authResultKey{}).(authResult)
+		if !ok {
+			res.principal, res.err = authn.Authenticate(r)
+		}
+		principal, err := res.principal, res.err
+		if err != nil {
+			slog.WarnContext(r.Context(), "audit", slog.String("result", "unauthenticated"), slog.String("scope", string(scope)),
+				slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.String("remote", r.RemoteAddr), logging.Err(err))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="order-service"`)
+			status := http.StatusUnauthorized
+			msg := "authentication required"
+			if !errors.Is(err, auth.ErrNoCredentials) {
+				msg = "invalid credentials"
+			}
+			writeJSON(w, status, JSONResponse{Success: false, Error: msg})
+			return
+		}
+
+		audit := principal.Method != auth.MethodDisabled
+		if !principal.HasScope(scope) {
+			if audit {
+				slog.WarnContext(r.Context(), "audit", slog.String("result", "forbidden"), slog.String("subject", principal.Subject),
+					slog.String("auth", principal.Method), slog.String("scope", string(scope)),
+					slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.String("remote", r.RemoteAddr))
+			}
+			writeJSON(w, http.StatusForbidden, JSONResponse{Success: false, Error: "insufficient scope: " + string(scope)})
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		if audit {
+			slog.InfoContext(r.Context(), "audit", slog.String("result", "allowed"), slog.String("subject", principal.Subject),
+				slog.String("auth", principal.Method), slog.String("scope", string(scope)),
+				slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.String("remote", r.RemoteAddr),
+				slog.Int("status", rec.status))
+		}
+	})
+}
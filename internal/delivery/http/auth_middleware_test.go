@@ -0,0 +1,53 @@
+package httpdelivery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+)
+
+func TestRequireScope(t *testing.T) {
+	authn, err := auth.NewAuthenticator(config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "reader", KeyHash: auth.HashAPIKey("reader-key"), Scopes: []string{"orders:read"}},
+			{Name: "writer", KeyHash: auth.HashAPIKey("writer-key"), Scopes: []string{"orders:write"}},
+		},
+	})
+	require.NoError(t, err)
+
+	var gotSubject string
+	handler := requireScope(authn, auth.ScopeOrdersRead, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := auth.PrincipalFromContext(r.Context())
+		gotSubject = p.Subject
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"no credentials", "", http.StatusUnauthorized},
+		{"unknown key", "bad-key", http.StatusUnauthorized},
+		{"missing scope", "writer-key", http.StatusForbidden},
+		{"allowed", "reader-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/order/1", nil)
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+		})
+	}
+	require.Equal(t, "reader", gotSubject)
+}
@@ -0,0 +1,323 @@
+package httpdelivery
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/logging"
+)
+
+// Поддерживаемые кодировки ответа в порядке предпочтения при равном q
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+)
+
+var (
+	gzipPool = sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}
+	zstdPool = sync.Pool{New: func() interface{} {
+		// Одна горутина на кодировщик: параллелизм даёт число запросов, а не один ответ
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedDefault))
+		return w
+	}}
+)
+
+// negotiateEncoding выбирает кодировку по Accept-Encoding с учётом q-значений.
+// Пустая строка — сжимать нельзя (клиент не поддерживает ни zstd, ни gzip)
+func negotiateEncoding(acceptEncoding string) string {
+	best, bestQ := "", 0.0
+	wildcard := -1.0
+	seen := map[string]bool{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		switch name {
+		case "*":
+			wildcard = q
+			continue
+		case encodingZstd, encodingGzip:
+		default:
+			continue
+		}
+		seen[name] = true
+		if q > bestQ || (q == bestQ && q > 0 && name == encodingZstd) {
+			best, bestQ = name, q
+		}
+	}
+	// "*" разрешает кодировки, не названные явно
+	if wildcard > 0 {
+		for _, name := range []string{encodingZstd, encodingGzip} {
+			if !seen[name] && wildcard > bestQ {
+				best, bestQ = name, wildcard
+			}
+		}
+	}
+	if bestQ <= 0 {
+		return ""
+	}
+	return best
+}
+
+// compressible сообщает, имеет ли смысл сжимать ответ с таким Content-Type. Поток SSE
+// не сжимается: события должны уходить клиенту сразу, а не копиться в кодировщике
+func compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mt == "text/event-stream":
+		return false
+	case strings.HasPrefix(mt, "text/"):
+		return true
+	case strings.HasSuffix(mt, "+json"), strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+	switch mt {
+	case "application/json", "application/x-ndjson", "application/javascript",
+		"application/xml", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compress сжимает ответы zstd или gzip, если клиент их принимает, тип содержимого
+// сжимаемый, а тело не меньше cfg.MinSize. До порога ответ буферизуется, поэтому
+// короткие ответы уходят как есть
+func compress(cfg config.CompressionConfig, next http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: cfg.MinSize}
+		defer func() {
+			// При панике (например, http.ErrAbortHandler посреди выгрузки) поток не
+			// завершается: иначе обрезанный ответ выглядел бы для клиента целым
+			if p := recover(); p != nil {
+				panic(p)
+			}
+			if err := cw.Close(); err != nil {
+				slog.Warn("compress: failed to finish response", logging.Err(err))
+			}
+		}()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter откладывает решение о сжатии до заполнения буфера размером minSize
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status   int
+	buf      bytes.Buffer
+	decided  bool      // заголовки отправлены, дальше пишем в enc или напрямую
+	enc      io.Writer // nil — ответ не сжимается
+	hijacked bool
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if c.decided || c.status != 0 {
+		return
+	}
+	// Информационные ответы (103 Early Hints) уходят сразу и не влияют на решение
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		c.ResponseWriter.WriteHeader(code)
+		return
+	}
+	c.status = code
+	// Без тела или с уже известной длиной ниже порога — решаем сразу
+	if !bodyAllowed(code) || c.knownSmall() {
+		_ = c.start(false) // без буфера ошибки записи нет
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.decided {
+		if c.enc != nil {
+			return c.enc.Write(p)
+		}
+		return c.ResponseWriter.Write(p)
+	}
+	if c.ResponseWriter.Header().Get("Content-Type") == "" {
+		c.ResponseWriter.Header().Set("Content-Type", http.DetectContentType(p))
+	}
+	c.buf.Write(p)
+	if c.buf.Len() >= c.minSize {
+		if err := c.start(c.shouldCompress()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush отправляет накопленное. Для несжимаемых ответов решение принимается сразу,
+// чтобы потоковые ответы не ждали заполнения буфера
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		if c.status == 0 {
+			c.status = http.StatusOK
+		}
+		if err := c.start(c.shouldCompress()); err != nil {
+			return
+		}
+	}
+	if f, ok := c.enc.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack нужен WebSocket: соединение забирается до записи ответа
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		c.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap даёт http.ResponseController доступ к исходному ResponseWriter (дедлайны записи)
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Close дописывает буфер и завершает поток кодировщика
+func (c *compressWriter) Close() error {
+	if c.hijacked {
+		return nil
+	}
+	if !c.decided {
+		if c.status == 0 {
+			// обработчик ничего не записал — net/http сам ответит 200 с пустым телом
+			return nil
+		}
+		if err := c.start(c.buf.Len() >= c.minSize && c.shouldCompress()); err != nil {
+			return err
+		}
+	}
+	switch enc := c.enc.(type) {
+	case *gzip.Writer:
+		err := enc.Close()
+		enc.Reset(io.Discard)
+		gzipPool.Put(enc)
+		return err
+	case *zstd.Encoder:
+		err := enc.Close()
+		enc.Reset(io.Discard)
+		zstdPool.Put(enc)
+		return err
+	}
+	return nil
+}
+
+func (c *compressWriter) knownSmall() bool {
+	n, err := strconv.Atoi(c.ResponseWriter.Header().Get("Content-Length"))
+	return err == nil && n < c.minSize
+}
+
+func (c *compressWriter) shouldCompress() bool {
+	h := c.ResponseWriter.Header()
+	return bodyAllowed(c.status) && c.status != http.StatusPartialContent &&
+		h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type"))
+}
+
+// start отправляет заголовки и накопленный буфер, при необходимости включая сжатие
+func (c *compressWriter) start(compressed bool) error {
+	c.decided = true
+	// Обработчик мог заменить Vary через Header().Set — ответ всё равно зависит от Accept-Encoding
+	addVary(c.ResponseWriter.Header(), "Accept-Encoding")
+	if compressed {
+		h := c.ResponseWriter.Header()
+		h.Set("Content-Encoding", c.encoding)
+		h.Del("Content-Length")
+		// Сильный ETag описывает несжатое представление
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		switch c.encoding {
+		case encodingZstd:
+			enc := zstdPool.Get().(*zstd.Encoder)
+			enc.Reset(c.ResponseWriter)
+			c.enc = enc
+		default:
+			enc := gzipPool.Get().(*gzip.Writer)
+			enc.Reset(c.ResponseWriter)
+			c.enc = enc
+		}
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+	if c.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if c.enc != nil {
+		_, err = c.enc.Write(c.buf.Bytes())
+	} else {
+		_, err = c.ResponseWriter.Write(c.buf.Bytes())
+	}
+	c.buf = bytes.Buffer{}
+	if err != nil && !errors.Is(err, http.ErrBodyNotAllowed) {
+		return err
+	}
+	return nil
+}
+
+// bodyAllowed сообщает, может ли ответ с таким статусом иметь тело
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// addVary добавляет field в Vary, если его там ещё нет
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
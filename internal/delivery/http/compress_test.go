@@ -0,0 +1,191 @@
+package httpdelivery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/config"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"gzip, deflate, br, zstd": "zstd",
+		"zstd;q=0.5, gzip":        "gzip",
+		"gzip;q=0, zstd;q=0":      "",
+		"*":                       "zstd",
+		"gzip;q=0.8, *;q=0.1":     "gzip",
+		"GZIP;q=1.0":              "gzip",
+		"zstd;q=0, *":             "gzip",
+		"br":                      "",
+		"gzip;q=abc":              "",
+	}
+	for header, want := range tests {
+		require.Equal(t, want, negotiateEncoding(header), "Accept-Encoding: %q", header)
+	}
+}
+
+func decodeBody(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = zr
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		return string(body)
+	}
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"order_uid":"b563feb7b2b84b6test"}`, 100)
+	handler := compress(config.CompressionConfig{Enabled: true, MinSize: 1024}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"success":true}`)
+		case "/parquet":
+			w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+			_, _ = io.WriteString(w, large)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", "3500")
+			// запись частями: решение принимается по накопленному размеру
+			for i := 0; i < 100; i++ {
+				_, _ = io.WriteString(w, `{"order_uid":"b563feb7b2b84b6test"}`)
+			}
+		}
+	}))
+
+	tests := []struct {
+		name, path, accept, wantEncoding string
+	}{
+		{"zstd preferred", "/large", "gzip, zstd", "zstd"},
+		{"gzip", "/large", "gzip", "gzip"},
+		{"not accepted", "/large", "", ""},
+		{"below threshold", "/small", "gzip", ""},
+		{"incompressible type", "/parquet", "gzip", ""},
+		{"no body", "/empty", "gzip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantEncoding, w.Header().Get("Content-Encoding"))
+			require.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+			if tt.wantEncoding != "" {
+				require.Empty(t, w.Header().Get("Content-Length"))
+				require.Less(t, w.Body.Len(), len(large))
+			}
+			switch tt.path {
+			case "/large", "/parquet":
+				require.Equal(t, large, decodeBody(t, tt.wantEncoding, w.Body.Bytes()))
+			case "/empty":
+				require.Equal(t, http.StatusNoContent, w.Code)
+			}
+		})
+	}
+}
+
+func TestCompress_PagesVary(t *testing.T) {
+	s := newTestServerWithConfig(t, newTrackUsecase(), &config.Config{
+		HTTPServer: config.HTTPServerConfig{Compression: config.CompressionConfig{Enabled: true, MinSize: 256}},
+	})
+
+	// страницы зависят от Accept-Language, а сжатие — от Accept-Encoding: общий кеш
+	// не должен отдать сжатую страницу клиенту, который сжатие не принимает
+	for _, path := range []string{"/track/WBILMTESTTRACK", "/track/UNKNOWNTRACK"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+
+		require.Equal(t, "gzip", w.Header().Get("Content-Encoding"), path)
+		require.ElementsMatch(t, []string{"Accept-Encoding", "Accept-Language"}, w.Header().Values("Vary"), path)
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	h := http.Header{}
+	h.Set("Vary", "Accept-Language, accept-encoding")
+	addVary(h, "Accept-Encoding")
+	require.Equal(t, []string{"Accept-Language, accept-encoding"}, h.Values("Vary"))
+
+	addVary(h, "Origin")
+	require.Equal(t, []string{"Accept-Language, accept-encoding", "Origin"}, h.Values("Vary"))
+}
+
+func TestCompress_Disabled(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("a", 4096))
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	compress(config.CompressionConfig{Enabled: false}, h).ServeHTTP(w, req)
+	require.Empty(t, w.Header().Get("Content-Encoding"))
+	require.Equal(t, 4096, w.Body.Len())
+}
+
+func TestCompress_StreamingFlush(t *testing.T) {
+	// SSE не сжимается и уходит клиенту при первом Flush, не дожидаясь порога
+	flushed := make(chan string, 1)
+	h := compress(config.CompressionConfig{Enabled: true, MinSize: 1024}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "retry: 3000\n\n")
+		require.NoError(t, http.NewResponseController(w).Flush())
+		flushed <- w.(interface{ Unwrap() http.ResponseWriter }).Unwrap().(*httptest.ResponseRecorder).Body.String()
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	require.Equal(t, "retry: 3000\n\n", <-flushed)
+	require.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+func TestCompress_AbortLeavesStreamUnfinished(t *testing.T) {
+	h := compress(config.CompressionConfig{Enabled: true, MinSize: 16}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = io.WriteString(w, strings.Repeat(`{"order_uid":"a"}`+"\n", 10))
+		panic(http.ErrAbortHandler)
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() { h.ServeHTTP(w, req) })
+
+	// без завершающего блока gzip клиент видит обрыв, а не целый ответ
+	zr, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+	if err == nil {
+		_, err = io.ReadAll(zr)
+	}
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,135 @@
+package httpdelivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/diagnostics"
+	"WBtech_l0/internal/logging"
+)
+
+// defaultProfileSeconds — длительность CPU-профиля в архиве, если seconds не задан (как в pprof)
+const defaultProfileSeconds = 30
+
+// ProfileBundle — данные ответа POST /debug/profiles
+type ProfileBundle struct {
+	File            string   `json:"file"`
+	SizeBytes       int64    `json:"size_bytes"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	Files           []string `json:"files"`
+}
+
+// DiagnosticsServer — отдельный HTTP-сервер для разбора проблем производительности:
+// net/http/pprof, состояние рантайма и архивы профилей. Маршруты доступны только
+// клиентам со scope admin, на основном порту ничего не регистрируется
+type DiagnosticsServer struct {
+	cfg    config.DiagnosticsConfig
+	router *http.ServeMux
+	server *http.Server
+}
+
+// NewDiagnosticsServer создаёт сервер диагностики
+func NewDiagnosticsServer(cfg config.DiagnosticsConfig, authn *auth.Authenticator) *DiagnosticsServer {
+	s := &DiagnosticsServer{cfg: cfg, router: http.NewServeMux()}
+	handle := func(pattern string, h http.Handler) {
+		s.router.Handle(pattern, requireScope(authn, auth.ScopeAdmin, adminOnly(h)))
+	}
+
+	// Обработчики pprof подключаются к своему ServeMux: импорт net/http/pprof
+	// регистрирует их и в http.DefaultServeMux, который здесь не используется
+	handle("GET /debug/pprof/", http.HandlerFunc(pprof.Index))
+	handle("GET /debug/pprof/cmdline", http.HandlerFunc(pprof.Cmdline))
+	handle("GET /debug/pprof/profile", http.HandlerFunc(pprof.Profile))
+	handle("GET /debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
+	handle("POST /debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
+	handle("GET /debug/pprof/trace", http.HandlerFunc(pprof.Trace))
+
+	handle("GET /debug/runtime", MakeRuntimeStatsHandler())
+	handle("POST /debug/profiles", MakeProfileBundleHandler(cfg.ProfileDir, cfg.MaxProfileDuration))
+	return s
+}
+
+// MakeRuntimeStatsHandler возвращает число горутин, использование памяти и работу сборщика мусора
+func MakeRuntimeStatsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: diagnostics.ReadRuntimeStats()})
+	}
+}
+
+// MakeProfileBundleHandler снимает CPU-профиль за ?seconds=N (по умолчанию 30, не дольше
+// maxDuration), затем профили кучи и горутин и записывает архив в каталог dir на сервере.
+// Ответ приходит после записи архива; если CPU-профиль уже снимается — 409
+func MakeProfileBundleHandler(dir string, maxDuration time.Duration) http.HandlerFunc {
+	maxSeconds := int(maxDuration / time.Second)
+	return func(w http.ResponseWriter, r *http.Request) {
+		seconds := min(defaultProfileSeconds, maxSeconds)
+		if raw := r.URL.Query().Get("seconds"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxSeconds {
+				writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "seconds must be between 1 and " + strconv.Itoa(maxSeconds)})
+				return
+			}
+			seconds = n
+		}
+		auditAdmin(r, "diagnostics.profile", slog.Int("seconds", seconds))
+
+		bundle, err := diagnostics.CaptureBundle(r.Context(), dir, time.Duration(seconds)*time.Second)
+		switch {
+		case errors.Is(err, diagnostics.ErrProfilingBusy):
+			writeJSON(w, http.StatusConflict, JSONResponse{Success: false, Error: "CPU profiling is already in progress"})
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "failed to capture profile bundle", logging.Err(err))
+			writeJSON(w, http.StatusInternalServerError, JSONResponse{Success: false, Error: "failed to capture profiles"})
+			return
+		}
+		slog.InfoContext(r.Context(), "profile bundle written", slog.String("file", bundle.Path), slog.Int64("size_bytes", bundle.Size))
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: ProfileBundle{
+			File:            bundle.Path,
+			SizeBytes:       bundle.Size,
+			DurationSeconds: bundle.Duration.Seconds(),
+			Files:           bundle.Files,
+		}})
+	}
+}
+
+// Run запускает сервер диагностики. Таймаут записи больше максимальной длительности
+// профиля: /debug/pprof/profile, /debug/pprof/trace и архив отвечают только по её истечении
+func (s *DiagnosticsServer) Run() error {
+	addr := fmt.Sprintf("%s:%s", s.cfg.Host, s.cfg.Port)
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      s.router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: s.cfg.MaxProfileDuration + 15*time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+	slog.Info("starting diagnostics server",
+		slog.String("addr", addr),
+		slog.String("profile_dir", s.cfg.ProfileDir),
+		slog.Duration("max_profile_duration", s.cfg.MaxProfileDuration))
+
+	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("diagnostics server failed: %w", err)
+	}
+	return nil
+}
+
+// Shutdown останавливает сервер диагностики
+func (s *DiagnosticsServer) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	if err := s.server.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("diagnostics server shutdown error: %w", err)
+	}
+	return nil
+}
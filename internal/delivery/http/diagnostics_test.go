@@ -0,0 +1,108 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+)
+
+func newTestDiagnosticsServer(t *testing.T, cfg *config.Config) *DiagnosticsServer {
+	t.Helper()
+	authn, err := auth.NewAuthenticator(cfg.Auth)
+	require.NoError(t, err)
+	return NewDiagnosticsServer(config.DiagnosticsConfig{
+		ProfileDir:         filepath.Join(t.TempDir(), "profiles"),
+		MaxProfileDuration: 5 * time.Second,
+	}, authn)
+}
+
+func TestDiagnosticsServer(t *testing.T) {
+	captureLogs(t)
+	s := newTestDiagnosticsServer(t, adminConfig())
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(auth.APIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/debug/runtime")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data struct {
+			Goroutines int `json:"goroutines"`
+			Memory     struct {
+				HeapAlloc uint64 `json:"heap_alloc_bytes"`
+			} `json:"memory"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Positive(t, resp.Data.Goroutines)
+	require.Positive(t, resp.Data.Memory.HeapAlloc)
+
+	require.Equal(t, http.StatusOK, do("GET", "/debug/pprof/").Code)
+	require.Equal(t, http.StatusOK, do("GET", "/debug/pprof/heap").Code)
+
+	require.Equal(t, http.StatusBadRequest, do("POST", "/debug/profiles?seconds=0").Code)
+	require.Equal(t, http.StatusBadRequest, do("POST", "/debug/profiles?seconds=6").Code)
+
+	w = do("POST", "/debug/profiles?seconds=1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var bundle struct {
+		Data ProfileBundle `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bundle))
+	info, err := os.Stat(bundle.Data.File)
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), bundle.Data.SizeBytes)
+	require.Contains(t, bundle.Data.Files, "cpu.pprof")
+
+	// CPU-профиль уже снимается, например через /debug/pprof/profile
+	require.NoError(t, pprof.StartCPUProfile(io.Discard))
+	w = do("POST", "/debug/profiles?seconds=1")
+	pprof.StopCPUProfile()
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestDiagnosticsServer_Access(t *testing.T) {
+	captureLogs(t)
+
+	tests := []struct {
+		name   string
+		cfg    *config.Config
+		key    string
+		status int
+	}{
+		{"no credentials", adminConfig(), "", http.StatusUnauthorized},
+		{"missing scope", adminConfig(), "reader-key", http.StatusForbidden},
+		{"auth disabled", &config.Config{}, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestDiagnosticsServer(t, tt.cfg)
+			for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine", "/debug/runtime"} {
+				req := httptest.NewRequest("GET", path, nil)
+				if tt.key != "" {
+					req.Header.Set(auth.APIKeyHeader, tt.key)
+				}
+				w := httptest.NewRecorder()
+				s.router.ServeHTTP(w, req)
+				require.Equal(t, tt.status, w.Code, path)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package httpdelivery
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/export"
+	"WBtech_l0/internal/logging"
+)
+
+// exportResponse откладывает заголовки ответа до первой записи: ошибку до начала
+// выгрузки (например, БД недоступна) ещё можно вернуть обычным JSON-ответом
+type exportResponse struct {
+	w       http.ResponseWriter
+	format  export.Format
+	started bool
+}
+
+// start отправляет заголовки успешного ответа
+func (e *exportResponse) start() {
+	if e.started {
+		return
+	}
+	e.started = true
+	h := e.w.Header()
+	h.Set("Content-Type", e.format.ContentType())
+	h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="orders-%s.%s"`,
+		time.Now().UTC().Format("20060102T150405Z"), e.format.Extension()))
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Accel-Buffering", "no")
+	e.w.WriteHeader(http.StatusOK)
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	e.start()
+	// Буфер ResponseWriter небольшой и сбрасывается по заполнению, поэтому данные
+	// уходят клиенту по мере выгрузки, не накапливаясь в памяти
+	return e.w.Write(p)
+}
+
+// MakeExportHandler выгружает заказы потоком в формате из параметра format
+// (ndjson, csv, parquet) с фильтром по date_created: from включительно, to — нет.
+// Заказы читаются страницами по pageSize, поэтому память не зависит от объёма выгрузки
+func MakeExportHandler(exporter domain.OrderExporter, pageSize int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		format, err := export.ParseFormat(q.Get("format"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: err.Error()})
+			return
+		}
+		filter, err := export.ParseFilter(q.Get("from"), q.Get("to"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: err.Error()})
+			return
+		}
+
+		// Выгрузка длится дольше WriteTimeout сервера — снимаем дедлайн для этого запроса
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			slog.WarnContext(r.Context(), "export: failed to reset write deadline", logging.Err(err))
+		}
+
+		out := &exportResponse{w: w, format: format}
+		ew, err := export.NewWriter(format, out)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: err.Error()})
+			return
+		}
+		n, err := export.Run(r.Context(), exporter, filter, pageSize, ew, func(o domain.Order) domain.Order {
+			return auth.VisibleOrder(r.Context(), o)
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "export failed", slog.String("format", string(format)), slog.Int("orders", n), logging.Err(err))
+			if !out.started {
+				writeJSON(w, http.StatusInternalServerError, JSONResponse{Success: false, Error: "failed to export orders"})
+				return
+			}
+			// Заголовки уже отправлены: обрываем соединение, чтобы клиент не принял
+			// неполный файл за целый
+			panic(http.ErrAbortHandler)
+		}
+		// Пустая выгрузка в NDJSON не пишет ни байта, но заголовки нужны и ей
+		out.start()
+	}
+}
@@ -0,0 +1,133 @@
+package httpdelivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/domain"
+)
+
+// exporterFunc — domain.OrderExporter из функции
+type exporterFunc func(ctx context.Context, filter domain.ExportFilter, pageSize int, fn func([]domain.Order) error) error
+
+func (f exporterFunc) ExportOrders(ctx context.Context, filter domain.ExportFilter, pageSize int, fn func([]domain.Order) error) error {
+	return f(ctx, filter, pageSize, fn)
+}
+
+// pagesExporter отдаёт заказы страницами по pageSize и запоминает фильтр
+func pagesExporter(orders []domain.Order, got *domain.ExportFilter) exporterFunc {
+	return func(_ context.Context, filter domain.ExportFilter, pageSize int, fn func([]domain.Order) error) error {
+		*got = filter
+		for i := 0; i < len(orders); i += pageSize {
+			if err := fn(orders[i:min(i+pageSize, len(orders))]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestMakeExportHandler_NDJSON(t *testing.T) {
+	orders := []domain.Order{
+		{OrderUID: "a", Delivery: domain.Delivery{Name: "Ivan Petrov", Phone: "+79991234567"}},
+		{OrderUID: "b"},
+		{OrderUID: "c"},
+	}
+	var filter domain.ExportFilter
+	h := MakeExportHandler(pagesExporter(orders, &filter), 2)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orders/export?from=2024-01-01&to=2024-02-01", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Header().Get("Content-Disposition"), ".ndjson")
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), filter.From)
+	require.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), filter.To)
+
+	dec := json.NewDecoder(w.Body)
+	var uids []string
+	for dec.More() {
+		var o domain.Order
+		require.NoError(t, dec.Decode(&o))
+		uids = append(uids, o.OrderUID)
+		if o.OrderUID == "a" {
+			// без orders:read_pii персональные данные маскируются
+			require.NotEqual(t, "+79991234567", o.Delivery.Phone)
+		}
+	}
+	require.Equal(t, []string{"a", "b", "c"}, uids)
+}
+
+func TestMakeExportHandler_CSV(t *testing.T) {
+	var filter domain.ExportFilter
+	orders := []domain.Order{{OrderUID: "a", Items: []domain.Item{{Name: "x"}, {Name: "y"}}}}
+	h := MakeExportHandler(pagesExporter(orders, &filter), 10)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orders/export?format=csv", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	rows, err := csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.True(t, filter.From.IsZero() && filter.To.IsZero())
+}
+
+func TestMakeExportHandler_EmptyResult(t *testing.T) {
+	var filter domain.ExportFilter
+	h := MakeExportHandler(pagesExporter(nil, &filter), 10)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orders/export", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	require.Zero(t, w.Body.Len())
+}
+
+func TestMakeExportHandler_BadRequest(t *testing.T) {
+	h := MakeExportHandler(exporterFunc(func(context.Context, domain.ExportFilter, int, func([]domain.Order) error) error {
+		t.Fatal("exporter must not be called")
+		return nil
+	}), 10)
+
+	for _, query := range []string{"format=xml", "from=yesterday", "from=2024-02-01&to=2024-01-01"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orders/export?"+query, nil))
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestMakeExportHandler_Error(t *testing.T) {
+	// ошибка до первой записи — обычный JSON-ответ 500
+	h := MakeExportHandler(exporterFunc(func(context.Context, domain.ExportFilter, int, func([]domain.Order) error) error {
+		return errors.New("connection refused")
+	}), 10)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orders/export", nil))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	// ошибка посреди выгрузки — соединение обрывается
+	h = MakeExportHandler(exporterFunc(func(_ context.Context, _ domain.ExportFilter, _ int, fn func([]domain.Order) error) error {
+		if err := fn([]domain.Order{{OrderUID: "a"}}); err != nil {
+			return err
+		}
+		return errors.New("connection reset")
+	}), 10)
+	w = httptest.NewRecorder()
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/orders/export", nil))
+	})
+}
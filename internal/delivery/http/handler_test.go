@@ -5,73 +5,80 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 
+	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/i18n"
 )
 
-// setupTestTemplate создаёт временную копию шаблона и переходит в эту директорию.
-// Возвращает функцию для восстановления исходной директории.
-func setupTestTemplate(t *testing.T) func() {
+// newTestAssets создаёт набор ресурсов с минимальными шаблонами для тестов.
+func newTestAssets(t *testing.T) *Assets {
 	// Содержимое минимального шаблона для тестов
 	templateContent := `<!doctype html>
 <html>
-<head><title>Test</title></head>
+<head><title>Test</title><link rel="stylesheet" href="{{asset "order.css"}}"></head>
 <body>
     <h1>Order {{.Order.OrderUID}}</h1>
     {{if .Found}}Found{{else}}Not Found{{end}}
 </body>
 </html>`
 
-	// Создаём временную директорию
-	tmpDir := t.TempDir()
-
-	// Создаём поддиректорию web
-	webDir := filepath.Join(tmpDir, "web")
-	err := os.Mkdir(webDir, 0755)
-	require.NoError(t, err)
-
-	// Записываем файл шаблона
-	tmplPath := filepath.Join(webDir, "order_template.html")
-	err = os.WriteFile(tmplPath, []byte(templateContent), 0644)
-	require.NoError(t, err)
-
-	// Сохраняем текущую рабочую директорию
-	origDir, err := os.Getwd()
-	require.NoError(t, err)
+	a := &Assets{fsys: fstest.MapFS{
+		"order_template.html": {Data: []byte(templateContent)},
+		"index.html":          {Data: []byte(`<script src="{{asset "index.js"}}"></script>`)},
+		"not_found.html":      {Data: []byte(`<html lang="{{.L.Lang}}">{{.L.T "not_found.message" .OrderUID}}</html>`)},
+		"static/order.css":    {Data: []byte("body { color: red; }")},
+		"static/index.js":     {Data: []byte("console.log('ok');")},
+	}}
+	require.NoError(t, a.reload())
+	return a
+}
 
-	// Переходим во временную директорию
-	err = os.Chdir(tmpDir)
+// newTestBundle создаёт каталоги сообщений с настройками по умолчанию.
+func newTestBundle(t *testing.T) *i18n.Bundle {
+	b, err := i18n.New(config.I18nConfig{DefaultLocale: "ru", TimeZone: "Europe/Moscow"})
 	require.NoError(t, err)
+	return b
+}
 
-	// Возвращаем функцию для восстановления
-	return func() {
-		if err := os.Chdir(origDir); err != nil {
-			t.Logf("failed to restore working directory: %v", err)
-		}
-	}
+// newRouteRequest создаёт GET-запрос с параметром маршрута {uid}, как его заполняет ServeMux.
+func newRouteRequest(path, uid string) *http.Request {
+	req := httptest.NewRequest("GET", path, nil)
+	req.SetPathValue("uid", uid)
+	return req
 }
 
 // MockUsecase реализует domain.OrderUsecase для тестов.
 type MockUsecase struct {
-	GetOrderFunc  func(ctx context.Context, orderUID string) (domain.Order, error)
-	SaveOrderFunc func(ctx context.Context, order domain.Order) error
+	GetOrderFunc    func(ctx context.Context, orderUID string) (domain.Order, error)
+	GetOrdersFunc   func(ctx context.Context, orderUIDs []string) ([]domain.Order, []string, error)
+	SaveOrderFunc   func(ctx context.Context, order domain.Order) error
+	ListOrdersFunc  func(ctx context.Context, afterUID string, limit int) ([]domain.Order, error)
+	TrackParcelFunc func(ctx context.Context, trackNumber string) (domain.Parcel, error)
 }
 
 func (m *MockUsecase) GetOrder(ctx context.Context, orderUID string) (domain.Order, error) {
 	return m.GetOrderFunc(ctx, orderUID)
 }
+func (m *MockUsecase) GetOrders(ctx context.Context, orderUIDs []string) ([]domain.Order, []string, error) {
+	return m.GetOrdersFunc(ctx, orderUIDs)
+}
 func (m *MockUsecase) SaveOrder(ctx context.Context, order domain.Order) error {
 	return m.SaveOrderFunc(ctx, order)
 }
+func (m *MockUsecase) ListOrders(ctx context.Context, afterUID string, limit int) ([]domain.Order, error) {
+	return m.ListOrdersFunc(ctx, afterUID, limit)
+}
+func (m *MockUsecase) TrackParcel(ctx context.Context, trackNumber string) (domain.Parcel, error) {
+	return m.TrackParcelFunc(ctx, trackNumber)
+}
 
 func TestMakeOrderHandler_Success(t *testing.T) {
-	defer setupTestTemplate(t)()
 	// given
 	expectedOrder := domain.Order{OrderUID: "12345"}
 	usecase := &MockUsecase{
@@ -82,9 +89,9 @@ func TestMakeOrderHandler_Success(t *testing.T) {
 			return domain.Order{}, errors.New("not found")
 		},
 	}
-	handler := MakeOrderHandler(usecase)
+	handler := MakeOrderHandler(usecase, newTestAssets(t), newTestBundle(t))
 
-	req := httptest.NewRequest("GET", "/order/12345", nil)
+	req := newRouteRequest("/order/12345", "12345")
 	w := httptest.NewRecorder()
 
 	// when
@@ -101,15 +108,14 @@ func TestMakeOrderHandler_Success(t *testing.T) {
 }
 
 func TestMakeOrderHandler_NotFound(t *testing.T) {
-	defer setupTestTemplate(t)()
 	usecase := &MockUsecase{
 		GetOrderFunc: func(_ context.Context, _ string) (domain.Order, error) {
 			return domain.Order{}, errors.New("not found")
 		},
 	}
-	handler := MakeOrderHandler(usecase)
+	handler := MakeOrderHandler(usecase, newTestAssets(t), newTestBundle(t))
 
-	req := httptest.NewRequest("GET", "/order/unknown", nil)
+	req := newRouteRequest("/order/unknown", "unknown")
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -120,9 +126,8 @@ func TestMakeOrderHandler_NotFound(t *testing.T) {
 }
 
 func TestMakeOrderHandler_InvalidUID(t *testing.T) {
-	defer setupTestTemplate(t)()
 	usecase := &MockUsecase{}
-	handler := MakeOrderHandler(usecase)
+	handler := MakeOrderHandler(usecase, newTestAssets(t), newTestBundle(t))
 
 	req := httptest.NewRequest("GET", "/order/", nil)
 	w := httptest.NewRecorder()
@@ -133,3 +138,63 @@ func TestMakeOrderHandler_InvalidUID(t *testing.T) {
 		t.Errorf("expected BadRequest, got %d", w.Code)
 	}
 }
+
+func TestMakeOrderHandler_Localized(t *testing.T) {
+	// given: настоящие шаблоны из web/
+	assets, err := NewAssets(config.WebConfig{})
+	require.NoError(t, err)
+	order := domain.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		Locale:      "en",
+		DateCreated: "2021-11-26T06:22:19Z",
+		Payment:     domain.Payment{Currency: "USD", Amount: 1817, PaymentDT: 1637907727},
+		Items:       []domain.Item{{Name: "Mascaras", Price: 453, Status: 202}},
+	}
+	usecase := &MockUsecase{
+		GetOrderFunc: func(_ context.Context, uid string) (domain.Order, error) {
+			if uid == order.OrderUID {
+				return order, nil
+			}
+			return domain.Order{}, errors.New("not found")
+		},
+	}
+	handler := MakeOrderHandler(usecase, assets, newTestBundle(t))
+	get := func(path, acceptLanguage string) *httptest.ResponseRecorder {
+		req := newRouteRequest(path, path[strings.LastIndex(path, "/")+1:])
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// when: язык берётся из локали заказа
+	w := get("/order/b563feb7b2b84b6test", "")
+
+	// then
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	require.Contains(t, body, `<html lang="en">`)
+	require.Contains(t, body, "$1,817.00")
+	require.Contains(t, body, "$453.00")
+	require.Contains(t, body, "Nov 26, 2021, 9:22 AM MSK")
+	require.Contains(t, body, "Delivered")
+	require.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+
+	// when: Accept-Language перекрывает локаль заказа
+	body = get("/order/b563feb7b2b84b6test", "ru-RU,ru;q=0.9").Body.String()
+
+	// then
+	require.Contains(t, body, `<html lang="ru">`)
+	require.Contains(t, body, "1\u00a0817,00\u00a0$")
+	require.Contains(t, body, "26.11.2021 09:22 MSK")
+	require.Contains(t, body, "Доставлен")
+
+	// when: страница 404 тоже локализована
+	w = get("/order/missing", "en")
+
+	// then
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Body.String(), "Order “missing” was not found")
+}
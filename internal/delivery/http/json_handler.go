@@ -1,13 +1,18 @@
 package httpdelivery
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"strings"
 	"time"
 
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/health"
+	"WBtech_l0/internal/logging"
 	"WBtech_l0/internal/repository/cache"
 	"WBtech_l0/internal/telemetry"
 )
@@ -19,105 +24,199 @@ type JSONResponse struct {
 	Error   string      `json:"error,omitempty"`
 }
 
-// MakeJSONOrderHandler возвращает JSON с данными заказа
+// maxRequestBodyBytes — предельный размер тела JSON-запроса
+const maxRequestBodyBytes = 64 << 10
+
+// BatchGetRequest — тело запроса POST /api/v1/orders:batchGet
+type BatchGetRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+// BatchGetResult — данные ответа пакетного чтения: найденные заказы в порядке запроса
+// и order_uid, которых нет ни в кеше, ни в БД
+type BatchGetResult struct {
+	Orders  []domain.Order `json:"orders"`
+	Missing []string       `json:"missing"`
+}
+
+// writeJSON пишет ответ API с указанным кодом статуса
+func writeJSON(w http.ResponseWriter, status int, resp JSONResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(resp); err != nil {
+		slog.Warn("failed to encode response", logging.Err(err))
+	}
+}
+
+// MakeJSONOrderHandler возвращает JSON с данными заказа. order_uid берётся
+// из параметра маршрута {uid}
 func MakeJSONOrderHandler(usecase domain.OrderUsecase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Устанавливаем заголовок для JSON ответа
-		w.Header().Set("Content-Type", "application/json")
-
-		// Извлекаем order_uid из URL
-		parts := strings.Split(r.URL.Path, "/")
-		if len(parts) < 4 {
-			w.WriteHeader(http.StatusBadRequest)
-			// Используем форматированный вывод даже для ошибок
-			enc := json.NewEncoder(w)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(JSONResponse{
-				Success: false,
-				Error:   "order_uid required",
-			}); err != nil {
-				log.Printf("failed to encode error response: %v", err)
-			}
+		orderUID := r.PathValue("uid")
+		if orderUID == "" {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "order_uid required"})
 			telemetry.OrdersProcessed.WithLabelValues("http", "error").Inc()
 			return
 		}
 
-		orderUID := parts[3]
-
 		// Валидация orderUID
-		if !isValidOrderUID(orderUID) {
-			w.WriteHeader(http.StatusBadRequest)
-			enc := json.NewEncoder(w)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(JSONResponse{
-				Success: false,
-				Error:   "Invalid order_uid format",
-			}); err != nil {
-				log.Printf("failed to encode error response: %v", err)
-			}
-			telemetry.OrdersProcessed.WithLabelValues("http", "error").Inc() //
+		if !domain.ValidOrderUID(orderUID) {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "Invalid order_uid format"})
+			telemetry.OrdersProcessed.WithLabelValues("http", "error").Inc()
 			return
 		}
 
 		// Получаем заказ
 		order, err := usecase.GetOrder(r.Context(), orderUID)
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
-			enc := json.NewEncoder(w)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(JSONResponse{
-				Success: false,
-				Error:   "Order not found",
-			}); err != nil {
-				log.Printf("failed to encode error response: %v", err)
-			}
-			telemetry.OrdersProcessed.WithLabelValues("http", "error").Inc() //
+			writeJSON(w, http.StatusNotFound, JSONResponse{Success: false, Error: "Order not found"})
+			telemetry.OrdersProcessed.WithLabelValues("http", "error").Inc()
 			return
 		}
 		telemetry.OrdersProcessed.WithLabelValues("http", "success").Inc()
 
-		// Всегда форматированный вывод
-		enc := json.NewEncoder(w)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(JSONResponse{
-			Success: true,
-			Data:    order,
-		}); err != nil {
-			log.Printf("failed to encode success response: %v", err) // FIXED: log ignored error
-		}
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: auth.VisibleOrder(r.Context(), order)})
+	}
+}
+
+// MakeJSONNotFoundHandler отвечает 404 в формате API для неизвестных путей под /api/ и /admin/
+func MakeJSONNotFoundHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusNotFound, JSONResponse{Success: false, Error: "not found"})
 	}
 }
 
-// MakeJSONHealthHandler возвращает статус сервиса
-func MakeJSONHealthHandler(cache *cache.OrderCache, db DBPinger) http.HandlerFunc {
+// MakeJSONHealthHandler возвращает статус сервиса и результаты проверок готовности.
+// Тексты ошибок проверок (адреса и сообщения драйверов Postgres и Kafka), статистика кеша
+// и, с reloads, поколение конфигурации видны только клиентам с правом admin. Если какая-то
+// проверка не прошла, отвечает 503
+func MakeJSONHealthHandler(cache *cache.OrderCache, checks *health.Registry, reloads func() config.ReloadStatus) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		// Проверяем подключение к БД
-		dbStatus := "ok"
-		if err := db.PingContext(r.Context()); err != nil {
-			dbStatus = "error"
-			log.Printf("Health check DB ping failed: %v", err)
+		report := checks.Check(r.Context())
+		status, code := "healthy", http.StatusOK
+		if !report.OK() {
+			status, code = "unhealthy", http.StatusServiceUnavailable
 		}
 
-		// Получаем статистику кеша
-		stats := cache.GetStats()
-
 		response := map[string]interface{}{
-			"status": "healthy",
-			"database": map[string]string{
-				"status": dbStatus,
-			},
-			"cache": map[string]interface{}{
+			"status":    status,
+			"timestamp": time.Now().Unix(),
+		}
+		if p, ok := auth.PrincipalFromContext(r.Context()); ok && p.HasScope(auth.ScopeAdmin) {
+			// Получаем статистику кеша
+			stats := cache.GetStats()
+			response["checks"] = report.Checks
+			response["cache"] = map[string]interface{}{
 				"size":   stats.Size,
 				"hits":   stats.Hits,
 				"misses": stats.Misses,
-			},
-			"timestamp": time.Now().Unix(),
+			}
+			if reloads != nil {
+				response["config"] = reloads()
+			}
+		} else {
+			response["checks"] = publicChecks(r.Context(), report)
 		}
 
+		w.WriteHeader(code)
 		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("failed to encode response: %v", err)
+			slog.Warn("failed to encode response", logging.Err(err))
+		}
+	}
+}
+
+// MakeLivenessHandler отвечает 200, пока процесс жив и обслуживает запросы.
+// Зависимости здесь не проверяются: их недоступность не повод перезапускать процесс
+func MakeLivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]string{"status": health.StatusOK}); err != nil {
+			slog.Warn("failed to encode response", logging.Err(err))
+		}
+	}
+}
+
+// MakeReadinessHandler отвечает 200, только если все проверки готовности прошли, иначе 503.
+// Проба доступна без аутентификации, поэтому возвращает только статусы проверок,
+// а тексты ошибок пишет в лог
+func MakeReadinessHandler(checks *health.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checks.Check(r.Context())
+		code := http.StatusOK
+		if !report.OK() {
+			code = http.StatusServiceUnavailable
+		}
+		report.Checks = publicChecks(r.Context(), report)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			slog.Warn("failed to encode response", logging.Err(err))
+		}
+	}
+}
+
+// publicChecks возвращает результаты проверок без текстов ошибок: в них адреса, порты
+// и пользователи Postgres и Kafka. Ошибки не пропадают, а пишутся в лог
+func publicChecks(ctx context.Context, report health.Report) map[string]health.Result {
+	checks := make(map[string]health.Result, len(report.Checks))
+	for name, res := range report.Checks {
+		if res.Error != "" {
+			slog.WarnContext(ctx, "health check failed", slog.String("check", name), slog.String(logging.KeyError, res.Error))
+			res.Error = ""
+		}
+		checks[name] = res
+	}
+	return checks
+}
+
+// MakeJSONBatchGetHandler возвращает до domain.MaxBatchGetSize заказов за запрос:
+// из кеша, а промахи — одним запросом к БД
+func MakeJSONBatchGetHandler(usecase domain.OrderUsecase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BatchGetRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "invalid request body"})
+			return
+		}
+		switch {
+		case len(req.OrderUIDs) == 0:
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "order_uids required"})
+			return
+		case len(req.OrderUIDs) > domain.MaxBatchGetSize:
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false,
+				Error: fmt.Sprintf("too many order_uids: max %d", domain.MaxBatchGetSize)})
+			return
+		}
+		for _, uid := range req.OrderUIDs {
+			if !domain.ValidOrderUID(uid) {
+				writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "Invalid order_uid format"})
+				return
+			}
+		}
+
+		orders, missing, err := usecase.GetOrders(r.Context(), req.OrderUIDs)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "batch get orders failed", logging.Err(err))
+			writeJSON(w, http.StatusInternalServerError, JSONResponse{Success: false, Error: "failed to get orders"})
+			telemetry.OrdersProcessed.WithLabelValues("http", "error").Inc()
+			return
+		}
+		telemetry.OrdersProcessed.WithLabelValues("http", "success").Add(float64(len(orders)))
+
+		result := BatchGetResult{Orders: make([]domain.Order, 0, len(orders)), Missing: missing}
+		for _, order := range orders {
+			result.Orders = append(result.Orders, auth.VisibleOrder(r.Context(), order))
+		}
+		if result.Missing == nil {
+			result.Missing = []string{}
 		}
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: result})
 	}
 }
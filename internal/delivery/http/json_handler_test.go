@@ -6,9 +6,15 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/health"
+	"WBtech_l0/internal/repository/cache"
 )
 
 func TestMakeJSONOrderHandler_Success(t *testing.T) {
@@ -23,7 +29,7 @@ func TestMakeJSONOrderHandler_Success(t *testing.T) {
 	}
 	handler := MakeJSONOrderHandler(usecase)
 
-	req := httptest.NewRequest("GET", "/api/order/json123", nil)
+	req := newRouteRequest("/api/order/json123", "json123")
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -55,7 +61,7 @@ func TestMakeJSONOrderHandler_NotFound(t *testing.T) {
 	}
 	handler := MakeJSONOrderHandler(usecase)
 
-	req := httptest.NewRequest("GET", "/api/order/unknown", nil)
+	req := newRouteRequest("/api/order/unknown", "unknown")
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -79,7 +85,7 @@ func TestMakeJSONOrderHandler_InvalidUID(t *testing.T) {
 	usecase := &MockUsecase{}
 	handler := MakeJSONOrderHandler(usecase)
 
-	// слишком длинный UID (более 255 символов) – но в isValidOrderUID есть проверка длины, проще проверить пустой сегмент
+	// слишком длинный UID (более 255 символов) – но в domain.ValidOrderUID есть проверка длины, проще проверить пустой сегмент
 	req := httptest.NewRequest("GET", "/api/order/", nil) // missing UID
 	w := httptest.NewRecorder()
 
@@ -89,3 +95,225 @@ func TestMakeJSONOrderHandler_InvalidUID(t *testing.T) {
 		t.Errorf("expected BadRequest, got %d", w.Code)
 	}
 }
+
+func TestMakeJSONOrderHandler_PIIMasking(t *testing.T) {
+	order := domain.Order{
+		OrderUID: "pii1",
+		Delivery: domain.Delivery{Name: "Test Testov", Phone: "+79001231234", Email: "test@example.com", City: "Moscow"},
+	}
+	usecase := &MockUsecase{
+		GetOrderFunc: func(_ context.Context, _ string) (domain.Order, error) {
+			return order, nil
+		},
+	}
+	handler := MakeJSONOrderHandler(usecase)
+
+	tests := []struct {
+		name      string
+		scopes    []auth.Scope
+		wantPhone string
+		wantEmail string
+	}{
+		{"without pii scope", []auth.Scope{auth.ScopeOrdersRead}, "+7******1234", "t***@example.com"},
+		{"with pii scope", []auth.Scope{auth.ScopeOrdersRead, auth.ScopeOrdersReadPII}, "+79001231234", "test@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRouteRequest("/api/order/pii1", "pii1")
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "client", Scopes: tt.scopes}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var resp struct {
+				Data domain.Order `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Data.Delivery.Phone != tt.wantPhone {
+				t.Errorf("expected phone %s, got %s", tt.wantPhone, resp.Data.Delivery.Phone)
+			}
+			if resp.Data.Delivery.Email != tt.wantEmail {
+				t.Errorf("expected email %s, got %s", tt.wantEmail, resp.Data.Delivery.Email)
+			}
+			if resp.Data.Delivery.City != "Moscow" {
+				t.Errorf("city must not be masked, got %s", resp.Data.Delivery.City)
+			}
+		})
+	}
+}
+
+func TestProbeHandlers(t *testing.T) {
+	logs := captureLogs(t)
+	cacheReady := health.NewFlag("cache", "cache warm-up in progress")
+	checks := health.NewRegistry()
+	checks.Register(cacheReady, 0, 0)
+	orderCache := cache.NewOrderCache(time.Minute, 10)
+
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	if w := get(MakeLivenessHandler(), "/livez"); w.Code != http.StatusOK {
+		t.Errorf("livez: expected 200, got %d", w.Code)
+	}
+
+	w := get(MakeReadinessHandler(checks), "/readyz")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz before warm-up: expected 503, got %d", w.Code)
+	}
+	var report health.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	// readyz доступна без аутентификации: текст ошибки только в логе
+	if res := report.Checks["cache"]; res.Status != health.StatusFail || res.Error != "" {
+		t.Errorf("unexpected cache check result: %+v", res)
+	}
+	if !strings.Contains(logs.String(), "cache warm-up in progress") {
+		t.Errorf("check error must be logged: %s", logs.String())
+	}
+
+	w = get(MakeJSONHealthHandler(orderCache, checks, nil), "/api/health")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"unhealthy"`) {
+		t.Errorf("health before warm-up: expected 503 unhealthy, got %d %s", w.Code, w.Body.String())
+	}
+
+	cacheReady.Set(true)
+	if w := get(MakeReadinessHandler(checks), "/readyz"); w.Code != http.StatusOK {
+		t.Errorf("readyz after warm-up: expected 200, got %d", w.Code)
+	}
+	if w := get(MakeJSONHealthHandler(orderCache, checks, nil), "/api/health"); w.Code != http.StatusOK {
+		t.Errorf("health after warm-up: expected 200, got %d", w.Code)
+	}
+}
+
+func TestMakeJSONHealthHandler_ConfigStatus(t *testing.T) {
+	loadedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	reloads := func() config.ReloadStatus {
+		return config.ReloadStatus{Generation: 3, LoadedAt: loadedAt, LastError: "log.level: bad"}
+	}
+	handler := MakeJSONHealthHandler(cache.NewOrderCache(time.Minute, 10), health.NewRegistry(), reloads)
+	req := httptest.NewRequest("GET", "/api/health", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "ops", Scopes: []auth.Scope{auth.ScopeAdmin}}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var resp struct {
+		Config config.ReloadStatus `json:"config"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Config.Generation != 3 || !resp.Config.LoadedAt.Equal(loadedAt) || resp.Config.LastError != "log.level: bad" {
+		t.Errorf("unexpected config status: %+v", resp.Config)
+	}
+}
+
+func TestMakeJSONHealthHandler_Details(t *testing.T) {
+	checks := health.NewRegistry()
+	checks.Register(health.CheckerFunc("postgres", func(context.Context) error {
+		return errors.New("dial tcp 10.0.3.7:5432: connect: connection refused")
+	}), time.Second, 0)
+	handler := MakeJSONHealthHandler(cache.NewOrderCache(time.Minute, 10), checks, nil)
+
+	get := func(p *auth.Principal) string {
+		req := httptest.NewRequest("GET", "/api/health", nil)
+		if p != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), *p))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d", w.Code)
+		}
+		return w.Body.String()
+	}
+
+	// анонимный клиент видит статусы проверок, но не адреса и ошибки зависимостей
+	body := get(nil)
+	if strings.Contains(body, "10.0.3.7") || strings.Contains(body, `"cache"`) {
+		t.Errorf("anonymous response leaks details: %s", body)
+	}
+	if !strings.Contains(body, `"postgres":{"status":"fail"`) {
+		t.Errorf("anonymous response must contain check status: %s", body)
+	}
+
+	reader := &auth.Principal{Subject: "dashboard", Scopes: []auth.Scope{auth.ScopeOrdersRead}}
+	if body := get(reader); strings.Contains(body, "10.0.3.7") {
+		t.Errorf("orders:read client must not see check errors: %s", body)
+	}
+	admin := &auth.Principal{Subject: "ops", Scopes: []auth.Scope{auth.ScopeAdmin}}
+	if body := get(admin); !strings.Contains(body, "10.0.3.7") {
+		t.Errorf("admin must see check errors: %s", body)
+	}
+}
+
+func TestMakeJSONBatchGetHandler(t *testing.T) {
+	usecase := &MockUsecase{
+		GetOrdersFunc: func(_ context.Context, uids []string) ([]domain.Order, []string, error) {
+			return []domain.Order{{
+				OrderUID: uids[0],
+				Delivery: domain.Delivery{Phone: "+79001231234", City: "Moscow"},
+			}}, uids[1:], nil
+		},
+	}
+	handler := MakeJSONBatchGetHandler(usecase)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/orders:batchGet", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`{"order_uids":["a1","missing"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data BatchGetResult `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Data.Orders) != 1 || resp.Data.Orders[0].OrderUID != "a1" {
+		t.Errorf("unexpected orders: %+v", resp.Data.Orders)
+	}
+	if resp.Data.Orders[0].Delivery.Phone != "+7******1234" {
+		t.Errorf("phone must be masked without pii scope, got %s", resp.Data.Orders[0].Delivery.Phone)
+	}
+	if len(resp.Data.Missing) != 1 || resp.Data.Missing[0] != "missing" {
+		t.Errorf("unexpected missing: %v", resp.Data.Missing)
+	}
+
+	tooMany := `{"order_uids":["a"` + strings.Repeat(`,"a"`, domain.MaxBatchGetSize) + `]}`
+	for name, body := range map[string]string{
+		"invalid json": `{"order_uids":`,
+		"empty":        `{"order_uids":[]}`,
+		"invalid uid":  `{"order_uids":["bad uid"]}`,
+		"too many":     tooMany,
+	} {
+		if w := post(body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestMakeJSONBatchGetHandler_Error(t *testing.T) {
+	usecase := &MockUsecase{
+		GetOrdersFunc: func(_ context.Context, _ []string) ([]domain.Order, []string, error) {
+			return nil, nil, errors.New("connection refused")
+		},
+	}
+	w := httptest.NewRecorder()
+	MakeJSONBatchGetHandler(usecase).ServeHTTP(w,
+		httptest.NewRequest("POST", "/api/v1/orders:batchGet", strings.NewReader(`{"order_uids":["a1"]}`)))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "connection refused") {
+		t.Error("internal error details must not leak to the client")
+	}
+}
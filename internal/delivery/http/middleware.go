@@ -0,0 +1,143 @@
+package httpdelivery
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/telemetry"
+)
+
+// RequestIDHeader — заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// statusRecorder запоминает код ответа и размер тела для аудита, метрик и access-лога
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush нужен потоковым ответам (SSE)
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack нужен для апгрейда соединения до WebSocket
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	r.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requestID берёт X-Request-ID из запроса или генерирует новый, возвращает его
+// в ответе и кладёт в контекст и в атрибуты текущего спана
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.request_id", id))
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// instrument записывает метрики и access-лог запроса. Метрики помечаются шаблоном
+// маршрута, а не путём запроса, чтобы число рядов не зависело от order_uid
+func (s *Server) instrument(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		duration := time.Since(start)
+
+		telemetry.HTTPRequestDuration.
+			WithLabelValues(route, metricMethod(r.Method), strconv.Itoa(rec.status)).
+			Observe(duration.Seconds())
+
+		attrs := []slog.Attr{
+			slog.String("request_id", logging.RequestIDFromContext(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+			slog.String("client", clientIP(r, s.cfg.RateLimit.TrustForwardedFor)),
+			slog.String("user_agent", r.UserAgent()),
+		}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			attrs = append(attrs,
+				slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()))
+		}
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		s.logger.LogAttrs(r.Context(), level, "http request", attrs...)
+	})
+}
+
+// metricMethod ограничивает значения метки method стандартными методами HTTP
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// Сроки жизни устаревших маршрутов без версии (/api/order/{uid} и др.)
+var (
+	legacyDeprecatedAt = time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	legacySunsetAt     = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// deprecated помечает ответы устаревшего маршрута заголовками Deprecation (RFC 9745)
+// и Sunset (RFC 8594) со ссылкой на маршрут-преемник
+func deprecated(successor func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(legacyDeprecatedAt.Unix(), 10))
+		w.Header().Set("Sunset", legacySunsetAt.Format(http.TimeFormat))
+		w.Header().Add("Link", "<"+successor(r)+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// successorPath возвращает преемника, не зависящего от параметров запроса
+func successorPath(path string) func(*http.Request) string {
+	return func(*http.Request) string { return path }
+}
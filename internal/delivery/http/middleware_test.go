@@ -0,0 +1,102 @@
+package httpdelivery
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/logging"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := requestID(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestIDFromContext(r.Context())
+	}))
+
+	// входящий идентификатор сохраняется
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, "abc-123", seen)
+	require.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+
+	// без заголовка и с недопустимым значением генерируется новый
+	for _, incoming := range []string{"", "bad\nvalue", strings.Repeat("a", logging.MaxRequestIDLen+1)} {
+		req = httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		require.Len(t, seen, 32)
+		require.NotEqual(t, incoming, seen)
+		require.Equal(t, seen, w.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestInstrument_AccessLogAndRouteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Server{cfg: &config.Config{}, logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+	h := requestID(s.instrument("/test/instrument/", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("short and stout"))
+	})))
+
+	for _, uid := range []string{"first", "second"} {
+		req := httptest.NewRequest("GET", "/test/instrument/"+uid+"?customer_id=secret", nil)
+		req.Header.Set(RequestIDHeader, "req-"+uid)
+		req.RemoteAddr = "10.0.0.1:5555"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// access-лог: одна JSON-строка на запрос
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	require.Equal(t, "http request", entry["msg"])
+	require.Equal(t, "req-first", entry["request_id"])
+	require.Equal(t, "/test/instrument/", entry["route"])
+	require.Equal(t, "/test/instrument/first", entry["path"])
+	require.EqualValues(t, http.StatusTeapot, entry["status"])
+	require.EqualValues(t, len("short and stout"), entry["bytes"])
+	require.Equal(t, "10.0.0.1", entry["client"])
+	require.Contains(t, entry, "duration_ms")
+	require.NotContains(t, lines[0], "secret", "query string must not be logged")
+
+	// метрики: один ряд на шаблон маршрута, а не на каждый путь
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	var samples uint64
+	for _, mf := range families {
+		if mf.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			require.NotContains(t, labels["route"], "first")
+			if labels["route"] == "/test/instrument/" {
+				require.Equal(t, "418", labels["code"])
+				require.Equal(t, "GET", labels["method"])
+				samples += m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	require.EqualValues(t, 2, samples)
+}
+
+func TestMetricMethod(t *testing.T) {
+	require.Equal(t, "GET", metricMethod("GET"))
+	require.Equal(t, "OTHER", metricMethod("BREW"))
+}
@@ -0,0 +1,261 @@
+package httpdelivery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+
+	"WBtech_l0/api"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/logging"
+)
+
+// APISpec — спецификация OpenAPI из пакета api и проверка запросов и ответов по ней
+type APISpec struct {
+	doc               *openapi3.T
+	validateRequests  bool
+	validateResponses bool
+	options           *openapi3filter.Options
+}
+
+// NewAPISpec разбирает и проверяет встроенную спецификацию
+func NewAPISpec(cfg config.OpenAPIConfig) (*APISpec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(api.OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("parse OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	return &APISpec{
+		doc:               doc,
+		validateRequests:  cfg.ValidateRequests,
+		validateResponses: cfg.ValidateResponses,
+		options: &openapi3filter.Options{
+			// Аутентификацию выполняет requireScope, здесь проверяются только параметры
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+			IncludeResponseStatus: true,
+		},
+	}, nil
+}
+
+// route возвращает операцию спецификации для шаблона маршрута вида "GET /api/v1/orders/{uid}"
+func (s *APISpec) route(pattern string) *routers.Route {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return nil
+	}
+	item := s.doc.Paths.Value(path)
+	if item == nil {
+		return nil
+	}
+	op := item.GetOperation(method)
+	if op == nil {
+		return nil
+	}
+	return &routers.Route{Spec: s.doc, Path: path, PathItem: item, Method: method, Operation: op}
+}
+
+// Validate оборачивает обработчик маршрута pattern проверкой запросов по спецификации
+// и, если включено, проверкой JSON-ответов. Маршруты вне спецификации не проверяются
+func (s *APISpec) Validate(pattern string, next http.Handler) http.Handler {
+	route := s.route(pattern)
+	if route == nil || (!s.validateRequests && !s.validateResponses) {
+		return next
+	}
+	// Потоковые и HTML ответы не буферизуются: проверяются только операции с JSON-ответом
+	checkResponse := s.validateResponses && returnsJSON(route.Operation)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Тело читается целиком до обработчика, поэтому размер ограничивается здесь
+		if route.Operation.RequestBody != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams(route, r),
+			Route:      route,
+			Options:    s.options,
+		}
+		if s.validateRequests {
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "invalid request: " + err.Error()})
+				return
+			}
+		}
+		if !checkResponse {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+		out := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 buf.status,
+			Header:                 buf.header,
+			Options:                s.options,
+		}
+		if err := openapi3filter.ValidateResponse(r.Context(), out.SetBodyBytes(buf.body.Bytes())); err != nil {
+			slog.ErrorContext(r.Context(), "openapi: response does not match the specification", slog.String("route", pattern), logging.Err(err))
+			writeJSON(w, http.StatusInternalServerError, JSONResponse{Success: false, Error: "response does not match API specification"})
+			return
+		}
+		for k, v := range buf.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(buf.status)
+		if _, err := buf.body.WriteTo(w); err != nil {
+			slog.WarnContext(r.Context(), "failed to write response", logging.Err(err))
+		}
+	})
+}
+
+// pathParams собирает параметры пути из ServeMux: имена в спецификации и в шаблоне совпадают
+func pathParams(route *routers.Route, r *http.Request) map[string]string {
+	params := make(map[string]string)
+	for _, list := range []openapi3.Parameters{route.PathItem.Parameters, route.Operation.Parameters} {
+		for _, p := range list {
+			if p.Value != nil && p.Value.In == openapi3.ParameterInPath {
+				params[p.Value.Name] = r.PathValue(p.Value.Name)
+			}
+		}
+	}
+	return params
+}
+
+// returnsJSON сообщает, что успешные (2xx) ответы операции — JSON. Ответы с ошибками
+// у всех операций одинаковые, поэтому HTML-страницы и потоки можно не буферизовать
+func returnsJSON(op *openapi3.Operation) bool {
+	found := false
+	for code, resp := range op.Responses.Map() {
+		if !strings.HasPrefix(code, "2") || resp.Value == nil {
+			continue
+		}
+		for ct := range resp.Value.Content {
+			mt, _, err := mime.ParseMediaType(ct)
+			if err != nil || mt != "application/json" {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// bufferedResponse накапливает ответ для проверки перед отправкой клиенту
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(code int)        { b.status = code }
+
+// Operations возвращает шаблоны маршрутов всех операций спецификации, например "GET /livez"
+func (s *APISpec) Operations() []string {
+	var ops []string
+	for path, item := range s.doc.Paths.Map() {
+		for method := range item.Operations() {
+			ops = append(ops, method+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// MakeOpenAPIHandler отдаёт спецификацию в исходном виде
+func MakeOpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		if _, err := w.Write(api.OpenAPI); err != nil {
+			slog.Warn("failed to write response", logging.Err(err))
+		}
+	}
+}
+
+// docsOperation — операция для страницы документации
+type docsOperation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Deprecated  bool
+	Secured     bool
+	Params      []*openapi3.Parameter
+	Responses   []docsResponse
+}
+
+type docsResponse struct {
+	Code        string
+	Description string
+}
+
+// docsData — данные шаблона api_docs.html
+type docsData struct {
+	Title       string
+	Version     string
+	Description string
+	Operations  []docsOperation
+}
+
+// docs готовит описание операций для страницы документации
+func (s *APISpec) docs() docsData {
+	data := docsData{
+		Title:       s.doc.Info.Title,
+		Version:     s.doc.Info.Version,
+		Description: s.doc.Info.Description,
+	}
+	for _, pattern := range s.Operations() {
+		route := s.route(pattern)
+		op := route.Operation
+		d := docsOperation{
+			ID:          op.OperationID,
+			Method:      route.Method,
+			Path:        route.Path,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Deprecated:  op.Deprecated,
+			Secured:     op.Security == nil || len(*op.Security) > 0,
+		}
+		for _, p := range op.Parameters {
+			d.Params = append(d.Params, p.Value)
+		}
+		for code, resp := range op.Responses.Map() {
+			desc := ""
+			if resp.Value != nil && resp.Value.Description != nil {
+				desc = *resp.Value.Description
+			}
+			d.Responses = append(d.Responses, docsResponse{Code: code, Description: desc})
+		}
+		sort.Slice(d.Responses, func(i, j int) bool { return d.Responses[i].Code < d.Responses[j].Code })
+		data.Operations = append(data.Operations, d)
+	}
+	// Сначала версионированные маршруты, устаревшие — в конце
+	sort.SliceStable(data.Operations, func(i, j int) bool {
+		return !data.Operations[i].Deprecated && data.Operations[j].Deprecated
+	})
+	return data
+}
+
+// MakeDocsHandler отдаёт HTML-страницу документации, построенную по спецификации
+func MakeDocsHandler(spec *APISpec, assets *Assets) http.HandlerFunc {
+	data := spec.docs()
+	return func(w http.ResponseWriter, _ *http.Request) {
+		renderPage(w, assets, docsTemplateName, http.StatusOK, data)
+	}
+}
@@ -0,0 +1,164 @@
+package httpdelivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/health"
+)
+
+// routesOutsideSpec — маршруты, которые намеренно не входят в контракт API
+var routesOutsideSpec = map[string]bool{
+	"/":                   true, // главная страница (SPA)
+	"/api/":               true, // 404 для неизвестных путей API
+	adminPrefix + "/":     true, // 404 для неизвестных путей административного API
+	"GET " + staticPrefix: true, // статика
+}
+
+func TestAPISpec_CoversAllRoutes(t *testing.T) {
+	s := newTestServer(t, &MockUsecase{})
+
+	registered := map[string]bool{}
+	for _, p := range s.patterns {
+		registered[p] = true
+		if !routesOutsideSpec[p] {
+			require.NotNil(t, s.spec.route(p), "route %q is not described in api/openapi.json", p)
+		}
+	}
+	for _, op := range s.spec.Operations() {
+		require.True(t, registered[op], "operation %q from api/openapi.json has no route", op)
+	}
+}
+
+func TestAPISpec_ResponsesMatchHandlers(t *testing.T) {
+	// given: заказ из model.json, чтобы спецификация сверялась с реальной формой данных
+	raw, err := os.ReadFile("../../../model.json")
+	require.NoError(t, err)
+	var order domain.Order
+	require.NoError(t, json.Unmarshal(raw, &order))
+
+	usecase := &MockUsecase{
+		GetOrderFunc: func(_ context.Context, uid string) (domain.Order, error) {
+			if uid == order.OrderUID {
+				return order, nil
+			}
+			return domain.Order{}, errors.New("not found")
+		},
+		GetOrdersFunc: func(_ context.Context, uids []string) ([]domain.Order, []string, error) {
+			return []domain.Order{order}, uids[1:], nil
+		},
+	}
+	cfg := &config.Config{
+		Auth: config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+			{Name: "reader", KeyHash: auth.HashAPIKey("reader-key"), Scopes: []string{"orders:read", "orders:read_pii"}},
+			{Name: "writer", KeyHash: auth.HashAPIKey("writer-key"), Scopes: []string{"orders:write"}},
+		}},
+		RateLimit: config.RateLimitConfig{Enabled: true, Routes: []config.RateLimitRule{
+			{Route: "/api/v1/orders/{uid}", RPS: 1000, Burst: 1000},
+			{Route: "/api/order/{uid}", RPS: 0.001, Burst: 1},
+		}},
+	}
+	s := newTestServerWithConfig(t, usecase, cfg)
+
+	// when/then: при расхождении ответа со спецификацией middleware вернул бы 500
+	tests := []struct {
+		name, path, key string
+		status          int
+	}{
+		{"order", "/api/v1/orders/" + order.OrderUID, "reader-key", http.StatusOK},
+		{"legacy order", "/api/order/" + order.OrderUID, "reader-key", http.StatusOK},
+		{"rate limited", "/api/order/" + order.OrderUID, "reader-key", http.StatusTooManyRequests},
+		{"not found", "/api/v1/orders/unknown", "reader-key", http.StatusNotFound},
+		{"invalid uid", "/api/v1/orders/bad$uid", "reader-key", http.StatusBadRequest},
+		{"no credentials", "/api/v1/orders/" + order.OrderUID, "", http.StatusUnauthorized},
+		{"wrong scope", "/api/v1/orders/" + order.OrderUID, "writer-key", http.StatusForbidden},
+		{"health", "/api/health", "", http.StatusOK},
+		{"liveness", "/livez", "", http.StatusOK},
+		{"readiness", "/readyz", "", http.StatusOK},
+		{"spec", "/api/openapi.json", "", http.StatusOK},
+		{"docs", "/api/docs", "", http.StatusOK},
+		{"invalid stream filter", "/api/v1/orders/stream?last_event_id=abc", "reader-key", http.StatusBadRequest},
+		{"export", "/api/v1/orders/export?format=csv&from=2024-01-01", "reader-key", http.StatusOK},
+		{"export unknown format", "/api/v1/orders/export?format=xml", "reader-key", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.key != "" {
+			req.Header.Set(auth.APIKeyHeader, tt.key)
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		require.Equal(t, tt.status, w.Code, "%s: %s", tt.name, w.Body.String())
+	}
+
+	batch := []struct {
+		name, body string
+		status     int
+	}{
+		{"batch get", `{"order_uids":["` + order.OrderUID + `","unknown"]}`, http.StatusOK},
+		{"batch get empty", `{"order_uids":[]}`, http.StatusBadRequest},
+		{"batch get unknown field", `{"uids":["a"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range batch {
+		req := httptest.NewRequest("POST", "/api/v1/orders:batchGet", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(auth.APIKeyHeader, "reader-key")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		require.Equal(t, tt.status, w.Code, "%s: %s", tt.name, w.Body.String())
+	}
+
+	// when: проверки не прошли — 503 тоже описан в спецификации
+	s.checks.Register(health.CheckerFunc("postgres", func(context.Context) error {
+		return errors.New("connection refused")
+	}), 0, 0)
+	for _, path := range []string{"/api/health", "/readyz"} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code, "%s: %s", path, w.Body.String())
+	}
+}
+
+func TestAPISpec_DetectsDrift(t *testing.T) {
+	spec, err := NewAPISpec(config.OpenAPIConfig{ValidateRequests: true, ValidateResponses: true})
+	require.NoError(t, err)
+
+	// обработчик «забыл» обязательные поля заказа и добавил лишнее
+	h := spec.Validate("GET /api/v1/orders/{uid}", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: map[string]string{"order_uid": "x", "extra": "y"}})
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newRouteRequest("/api/v1/orders/x", "x"))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// без проверки ответов тот же ответ проходит
+	spec, err = NewAPISpec(config.OpenAPIConfig{ValidateRequests: true})
+	require.NoError(t, err)
+	h = spec.Validate("GET /api/v1/orders/{uid}", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: map[string]string{"order_uid": "x"}})
+	}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newRouteRequest("/api/v1/orders/x", "x"))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMakeDocsHandler(t *testing.T) {
+	s := newTestServer(t, &MockUsecase{})
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest("GET", "/api/docs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	require.Contains(t, body, "/api/v1/orders/{uid}")
+	require.Contains(t, body, `href="/api/openapi.json"`)
+}
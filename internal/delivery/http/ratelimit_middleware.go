@@ -0,0 +1,73 @@
+package httpdelivery
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/ratelimit"
+	"WBtech_l0/internal/telemetry"
+)
+
+// clientKey определяет, по какому ключу считать лимит: по аутентифицированному
+// клиенту (API-ключ или субъект JWT), а для анонимных запросов — по IP
+func clientKey(r *http.Request, trustForwardedFor bool) (key, clientType string) {
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok && p.Method != auth.MethodDisabled {
+		return p.Method + ":" + p.Subject, p.Method
+	}
+	return "ip:" + clientIP(r, trustForwardedFor), "ip"
+}
+
+// clientIP возвращает IP клиента. X-Forwarded-For учитывается только по явной настройке,
+// иначе клиент мог бы обходить лимит, подставляя произвольный заголовок
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimit ограничивает частоту запросов к маршруту route. При превышении лимита
+// отвечает 429 с заголовком Retry-After. Ошибка backend'а не блокирует запрос
+func rateLimit(limiter *ratelimit.Limiter, route string, trustForwardedFor bool, next http.Handler) http.Handler {
+	if limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, clientType := clientKey(r, trustForwardedFor)
+		res, err := limiter.Allow(r.Context(), route, key)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "rate limiter backend failed", logging.Err(err))
+			telemetry.RateLimitBackendErrors.Inc()
+			next.ServeHTTP(w, r)
+			return
+		}
+		if res.Limit > 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		}
+		if !res.Allowed {
+			retryAfter := int(math.Ceil(res.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			telemetry.HTTPRequestsThrottled.WithLabelValues(route, clientType).Inc()
+			writeJSON(w, http.StatusTooManyRequests, JSONResponse{Success: false, Error: "rate limit exceeded"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,89 @@
+package httpdelivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryBackend(0), config.RateLimitConfig{
+		Default: config.RateLimitRule{RPS: 1, Burst: 2},
+	})
+	handler := rateLimit(limiter, "/api/order/", false, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/order/1", nil)
+		req.RemoteAddr = remoteAddr
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), *principal))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, send("10.0.0.1:5000", nil).Code)
+	require.Equal(t, http.StatusOK, send("10.0.0.1:5001", nil).Code)
+
+	w := send("10.0.0.1:5002", nil)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+	require.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	// другой IP и аутентифицированный клиент считаются отдельно
+	require.Equal(t, http.StatusOK, send("10.0.0.2:5000", nil).Code)
+	p := &auth.Principal{Subject: "dashboard", Method: auth.MethodAPIKey}
+	require.Equal(t, http.StatusOK, send("10.0.0.1:5003", p).Code)
+}
+
+func TestRateLimit_AuthFailures(t *testing.T) {
+	captureLogs(t)
+	cfg := &config.Config{
+		Auth: config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+			{Name: "reader", KeyHash: auth.HashAPIKey("reader-key"), Scopes: []string{"orders:read"}},
+		}},
+		RateLimit: config.RateLimitConfig{Enabled: true, Default: config.RateLimitRule{RPS: 0.001, Burst: 2}},
+	}
+	s := newTestServerWithConfig(t, &MockUsecase{
+		GetOrderFunc: func(_ context.Context, uid string) (domain.Order, error) {
+			return domain.Order{OrderUID: uid}, nil
+		},
+	}, cfg)
+
+	send := func(key string) int {
+		req := httptest.NewRequest("GET", "/api/v1/orders/b563feb7b2b84b6test", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set(auth.APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// подбор ключей расходует лимит IP и упирается в 429
+	require.Equal(t, http.StatusUnauthorized, send("guess-1"))
+	require.Equal(t, http.StatusUnauthorized, send("guess-2"))
+	require.Equal(t, http.StatusTooManyRequests, send("guess-3"))
+
+	// у аутентифицированного клиента своя корзина
+	require.Equal(t, http.StatusOK, send("reader-key"))
+}
+
+func TestClientIP_ForwardedFor(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	require.Equal(t, "10.0.0.1", clientIP(req, false))
+	require.Equal(t, "203.0.113.7", clientIP(req, true))
+}
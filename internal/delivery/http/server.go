@@ -2,141 +2,328 @@ package httpdelivery
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
+	"WBtech_l0/internal/auth"
 	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/events"
+	"WBtech_l0/internal/health"
+	"WBtech_l0/internal/i18n"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/ratelimit"
 	"WBtech_l0/internal/repository/cache"
-	"WBtech_l0/internal/telemetry"
 )
 
-// DBPinger используется для health check, позволяет замокировать БД
-type DBPinger interface {
-	PingContext(ctx context.Context) error
-}
+// Server — HTTP-сервер веб-интерфейса и JSON API
 type Server struct {
-	cfg     *config.Config
-	usecase domain.OrderUsecase
-	db      DBPinger
-	cache   *cache.OrderCache
-	router  *http.ServeMux
-	server  *http.Server
+	cfg      *config.Config
+	usecase  domain.OrderUsecase
+	exporter domain.OrderExporter
+	checks   *health.Registry
+	cache    *cache.OrderCache
+	authn    *auth.Authenticator
+	limiter  *ratelimit.Limiter
+	lookup   *ratelimit.Limiter // лимит публичного поиска посылки, действует всегда
+	broker   *events.Broker
+	assets   *Assets
+	i18n     *i18n.Bundle
+	logger   *slog.Logger
+	spec     *APISpec
+	reloads  func() config.ReloadStatus
+	consumer ConsumerControl
+	rewarm   func(context.Context) error
+	router   *http.ServeMux
+	server   *http.Server
+	certs    *certReloader // nil без TLS
+
+	patterns []string // зарегистрированные шаблоны маршрутов
+
+	ownsLookup bool // lookup создан сервером и закрывается в Shutdown
+}
+
+// Deps — зависимости HTTP-сервера
+type Deps struct {
+	Usecase  domain.OrderUsecase
+	Exporter domain.OrderExporter
+	Checks   *health.Registry
+	Cache    *cache.OrderCache
+	Authn    *auth.Authenticator
+	Limiter  *ratelimit.Limiter // nil — без ограничения частоты запросов
+	Lookup   *ratelimit.Limiter // лимит маршрутов /track; nil — по cfg.RateLimit.PublicLookup
+	Broker   *events.Broker
+	Assets   *Assets
+	I18n     *i18n.Bundle
+	Logger   *slog.Logger // access-лог; nil — slog.Default()
+	Spec     *APISpec
+	Reloads  func() config.ReloadStatus  // поколение конфигурации для /api/health; nil — не выводится
+	Consumer ConsumerControl             // пауза Kafka consumer из /admin; nil — недоступна
+	Rewarm   func(context.Context) error // повторная загрузка кеша из БД для /admin; nil — недоступна
 }
 
 // NewServer создает новый экземпляр сервера
-func NewServer(cfg *config.Config, usecase domain.OrderUsecase, db DBPinger, cache *cache.OrderCache) *Server {
+func NewServer(cfg *config.Config, deps Deps) *Server {
 	s := &Server{
-		cfg:     cfg,
-		usecase: usecase,
-		db:      db,
-		cache:   cache,
-		router:  http.NewServeMux(),
+		cfg:      cfg,
+		usecase:  deps.Usecase,
+		exporter: deps.Exporter,
+		checks:   deps.Checks,
+		cache:    deps.Cache,
+		authn:    deps.Authn,
+		limiter:  deps.Limiter,
+		lookup:   deps.Lookup,
+		broker:   deps.Broker,
+		assets:   deps.Assets,
+		i18n:     deps.I18n,
+		logger:   deps.Logger,
+		spec:     deps.Spec,
+		reloads:  deps.Reloads,
+		consumer: deps.Consumer,
+		rewarm:   deps.Rewarm,
+		router:   http.NewServeMux(),
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	if s.lookup == nil {
+		s.lookup = ratelimit.NewLimiter(ratelimit.NewMemoryBackend(10*time.Minute), cfg.RateLimit.PublicLookup.Config())
+		s.ownsLookup = true
 	}
 	s.setupRoutes()
 	return s
 }
 
+// apiV1 — префикс текущей версии JSON API
+const apiV1 = "/api/v1"
+
 // setupRoutes настраивает маршруты
 func (s *Server) setupRoutes() {
-	// Создаём middleware для измерения времени запросов (метрики)
-	metricsMiddleware := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
-			next.ServeHTTP(w, r)
-			duration := time.Since(start).Seconds()
-			telemetry.HTTPRequestDuration.WithLabelValues(r.URL.Path, r.Method).Observe(duration)
-		})
-	}
-
-	// HTML интерфейс (существующий)
-	s.router.Handle("/order/", otelhttp.NewHandler(
-		metricsMiddleware(MakeOrderHandler(s.usecase)),
-		"http-request",
-	))
-
-	// JSON API (новые маршруты)
-	s.router.Handle("/api/order/", otelhttp.NewHandler(
-		metricsMiddleware(MakeJSONOrderHandler(s.usecase)),
-		"http-request",
-	))
-	s.router.Handle("/api/health", otelhttp.NewHandler(
-		metricsMiddleware(MakeJSONHealthHandler(s.cache, s.db)),
-		"http-request",
-	))
-	//  Статические файлы и главная страница
-	s.router.HandleFunc("/", s.staticFileHandler)
+	heartbeat := s.cfg.Events.HeartbeatInterval
+
+	// HTML интерфейс
+	s.handle("GET /order/{uid}", auth.ScopeOrdersRead, MakeOrderHandler(s.usecase, s.assets, s.i18n))
+
+	// Публичное отслеживание посылки: без аутентификации, но всегда с лимитом по IP
+	// против перебора трек-номеров, независимо от rate_limit.enabled
+	s.handleLimited("GET /track/{track_number}", "", s.lookup, MakeTrackHandler(s.usecase, s.assets, s.i18n))
+	s.handleLimited("GET "+apiV1+"/track/{track_number}", "", s.lookup, MakeJSONTrackHandler(s.usecase))
+
+	// JSON API v1
+	s.handle("GET "+apiV1+"/orders/{uid}", auth.ScopeOrdersRead, MakeJSONOrderHandler(s.usecase))
+	s.handle("POST "+apiV1+"/orders:batchGet", auth.ScopeOrdersRead, MakeJSONBatchGetHandler(s.usecase))
+	s.handle("GET "+apiV1+"/orders/export", auth.ScopeOrdersRead, MakeExportHandler(s.exporter, s.cfg.Export.PageSize))
+	s.handle("GET "+apiV1+"/orders/stream", auth.ScopeOrdersRead, MakeSSEHandler(s.broker, heartbeat))
+	s.handle("GET "+apiV1+"/orders/ws", auth.ScopeOrdersRead, MakeWebSocketHandler(s.broker, heartbeat))
+
+	// Устаревшие маршруты без версии: те же ответы плюс заголовки Deprecation и Sunset
+	s.handle("GET /api/order/{uid}", auth.ScopeOrdersRead, deprecated(func(r *http.Request) string {
+		return apiV1 + "/orders/" + url.PathEscape(r.PathValue("uid"))
+	}, MakeJSONOrderHandler(s.usecase)))
+	s.handle("GET /api/orders/stream", auth.ScopeOrdersRead,
+		deprecated(successorPath(apiV1+"/orders/stream"), MakeSSEHandler(s.broker, heartbeat)))
+	s.handle("GET /api/orders/ws", auth.ScopeOrdersRead,
+		deprecated(successorPath(apiV1+"/orders/ws"), MakeWebSocketHandler(s.broker, heartbeat)))
+
+	// Статус — без аутентификации; подробности проверок — клиентам с правом admin
+	s.handle("GET /api/health", "", authenticate(s.authn, MakeJSONHealthHandler(s.cache, s.checks, s.reloads)))
+
+	// Спецификация OpenAPI и документация
+	s.handle("GET /api/openapi.json", "", MakeOpenAPIHandler())
+	s.handle("GET /api/docs", "", MakeDocsHandler(s.spec, s.assets))
+
+	// Административное API: только для аутентифицированных клиентов с правом admin
+	s.handleAdmin("GET "+adminPrefix+"/cache", MakeAdminCacheListHandler(s.cache))
+	s.handleAdmin("GET "+adminPrefix+"/cache/{uid}", MakeAdminCacheEntryHandler(s.cache))
+	s.handleAdmin("DELETE "+adminPrefix+"/cache/{uid}", MakeAdminCacheEvictHandler(s.cache))
+	s.handleAdmin("POST "+adminPrefix+"/cache:evict", MakeAdminCacheEvictPatternHandler(s.cache))
+	s.handleAdmin("POST "+adminPrefix+"/cache:clear", MakeAdminCacheClearHandler(s.cache))
+	s.handleAdmin("POST "+adminPrefix+"/cache:rewarm", MakeAdminCacheRewarmHandler(s.rewarm))
+	s.handleAdmin("GET "+adminPrefix+"/consumer", MakeAdminConsumerHandler(s.consumer))
+	s.handleAdmin("POST "+adminPrefix+"/consumer:pause", MakeAdminConsumerPauseHandler(s.consumer, true))
+	s.handleAdmin("POST "+adminPrefix+"/consumer:resume", MakeAdminConsumerPauseHandler(s.consumer, false))
+	s.handleAdmin("GET "+adminPrefix+"/log-level", MakeAdminLogLevelHandler())
+	s.handleAdmin("PUT "+adminPrefix+"/log-level", MakeAdminSetLogLevelHandler())
+
+	// Пробы для оркестратора: без трассировки и лимитов, чтобы не засорять трейсы
+	s.register("GET /livez", s.spec.Validate("GET /livez", MakeLivenessHandler()))
+	s.register("GET /readyz", s.spec.Validate("GET /readyz", MakeReadinessHandler(s.checks)))
+
+	// Неизвестные пути API отвечают 404 в формате API, а не главной страницей
+	s.register("/api/", s.observe("/api/", MakeJSONNotFoundHandler()))
+	s.register(adminPrefix+"/", s.observe(adminPrefix+"/", MakeJSONNotFoundHandler()))
+
+	// Статические файлы с хешем в имени
+	s.register("GET "+staticPrefix, s.observe(staticPrefix, http.HandlerFunc(s.assets.ServeStatic)))
+
+	// Главная страница. Шаблон без метода: "GET /" конфликтует с "/api/"
+	s.register("/", s.observe("/", http.HandlerFunc(s.indexHandler)))
 }
 
-// staticFileHandler обрабатывает статические файлы
-func (s *Server) staticFileHandler(w http.ResponseWriter, r *http.Request) {
-	// Если запрос к API order, пропускаем его к order handler
-	if len(r.URL.Path) >= 5 && r.URL.Path[:5] == "/api/" {
-		http.NotFound(w, r)
-		return
-	}
+// handle регистрирует маршрут с общей цепочкой middleware: трассировка, X-Request-ID,
+// метрики и access-лог, аутентификация (если указан scope), ограничение частоты запросов
+// и проверка права. Лимит стоит до ответов 401/403, иначе перебор ключей не ограничивался бы.
+// Метрики, логи и лимиты используют шаблон пути без метода, например /api/v1/orders/{uid}
+func (s *Server) handle(pattern string, scope auth.Scope, h http.Handler) {
+	s.handleLimited(pattern, scope, s.limiter, h)
+}
 
-	// Если запрос к HTML order, пропускаем его к order handler
-	if len(r.URL.Path) >= 7 && r.URL.Path[:7] == "/order/" {
-		MakeOrderHandler(s.usecase)(w, r)
-		return
+// handleLimited — handle с отдельным ограничителем частоты запросов limiter
+func (s *Server) handleLimited(pattern string, scope auth.Scope, limiter *ratelimit.Limiter, h http.Handler) {
+	route := routeName(pattern)
+	if scope != "" {
+		h = requireScope(s.authn, scope, h)
+	}
+	h = rateLimit(limiter, route, s.cfg.RateLimit.TrustForwardedFor, h)
+	if scope != "" {
+		h = authenticate(s.authn, h)
 	}
+	// Проверка по OpenAPI стоит снаружи аутентификации, чтобы проверялись и ответы 401/403/429
+	s.register(pattern, s.observe(route, s.spec.Validate(pattern, h)))
+}
 
-	// Определяем путь к файлу
-	path := r.URL.Path
-	if path == "/" {
-		path = "/index.html"
+// handleAdmin регистрирует маршрут административного API: право admin
+// и обязательная аутентификация, даже если auth.enabled=false
+func (s *Server) handleAdmin(pattern string, h http.Handler) {
+	s.handle(pattern, auth.ScopeAdmin, adminOnly(h))
+}
+
+// register добавляет маршрут в ServeMux и запоминает его шаблон
+func (s *Server) register(pattern string, h http.Handler) {
+	s.patterns = append(s.patterns, pattern)
+	s.router.Handle(pattern, h)
+}
+
+// routeName отбрасывает метод из шаблона маршрута: "GET /order/{uid}" -> "/order/{uid}"
+func routeName(pattern string) string {
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		return pattern[i+1:]
 	}
+	return pattern
+}
 
-	// Путь к папке web
-	webPath := filepath.Join("web", path)
+// observe добавляет трассировку, X-Request-ID, метрики и access-лог маршрута route
+func (s *Server) observe(route string, h http.Handler) http.Handler {
+	return otelhttp.NewHandler(requestID(s.instrument(route, h)), "http-request")
+}
 
-	// Проверяем существование файла
-	if _, err := os.Stat(webPath); os.IsNotExist(err) {
-		// Если файл не найден, отдаем index.html (для SPA routing)
-		http.ServeFile(w, r, filepath.Join("web", "index.html"))
+// indexHandler отдаёт главную страницу на любой путь без собственного маршрута (для SPA routing)
+func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-
-	// Отдаем статический файл
-	http.ServeFile(w, r, webPath)
+	tmpl, err := s.assets.Template("index.html")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to load template", slog.String("template", "index.html"), logging.Err(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := tmpl.Execute(w, nil); err != nil {
+		slog.ErrorContext(r.Context(), "failed to execute template", slog.String("template", "index.html"), logging.Err(err))
+	}
 }
 
-// Run запускает HTTP сервер
+// Run запускает HTTP сервер: HTTP/1.1 и, с TLS, HTTP/2 через ALPN. При http_server.h2c
+// сервер принимает и HTTP/2 без TLS (prior knowledge) для внутренних клиентов
 func (s *Server) Run() error {
 	addr := fmt.Sprintf("%s:%s", s.cfg.HTTPServer.Host, s.cfg.HTTPServer.Port)
+	tlsCfg := s.cfg.HTTPServer.TLS
 
 	// Создаем http.Server с таймаутами
 	s.server = &http.Server{
 		Addr:         addr,
-		Handler:      s.router,
+		Handler:      s.handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		Protocols:    s.protocols(),
+	}
+	scheme := "http"
+	if tlsCfg.Enabled {
+		certs, err := newCertReloader(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		s.certs = certs
+		s.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+		scheme = "https"
 	}
 
-	log.Printf("Starting HTTP server at %s\n", addr)
-	log.Printf("Web interface available at http://%s\n", addr)
-	log.Printf("HTML order view: http://%s/order/{order_uid}\n", addr)
-	log.Printf("JSON API: http://%s/api/order/{order_uid}\n", addr)
-	log.Printf("Health check: http://%s/api/health\n", addr)
-	log.Printf("Serving static files from: web/\n")
-	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	base := scheme + "://" + addr
+	slog.Info("starting HTTP server",
+		slog.String("addr", addr),
+		slog.String("scheme", scheme),
+		slog.Bool("h2c", s.cfg.HTTPServer.H2C),
+		slog.Bool("compression", s.cfg.HTTPServer.Compression.Enabled),
+		slog.Bool("web_dev_mode", s.cfg.Web.DevMode),
+		slog.Group("urls",
+			slog.String("web", base+"/order/{order_uid}"),
+			slog.String("api", base+apiV1+"/orders/{order_uid}"),
+			slog.String("health", base+"/api/health"),
+			slog.String("docs", base+"/api/docs"),
+			slog.String("sse", base+apiV1+"/orders/stream"),
+			slog.String("ws", strings.Replace(base, "http", "ws", 1)+apiV1+"/orders/ws"),
+		))
+
+	var err error
+	if tlsCfg.Enabled {
+		// Файлы не передаются: сертификат берётся из TLSConfig.GetCertificate
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server failed: %w", err)
 	}
 
 	return nil
 }
 
+// handler — корневой обработчик сервера: маршруты со сжатием ответов
+func (s *Server) handler() http.Handler {
+	return compress(s.cfg.HTTPServer.Compression, s.router)
+}
+
+// protocols возвращает протоколы сервера. HTTP/2 поверх TLS включается только вместе
+// с TLS, h2c — отдельной настройкой: наружу без TLS сервис не смотрит
+func (s *Server) protocols() *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(s.cfg.HTTPServer.TLS.Enabled)
+	p.SetUnencryptedHTTP2(s.cfg.HTTPServer.H2C)
+	return p
+}
+
 // Shutdown с использованием http.Server
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Println("Shutting down HTTP server...")
+	slog.Info("shutting down HTTP server")
+	if err := s.assets.Close(); err != nil {
+		slog.Warn("failed to stop web assets watcher", logging.Err(err))
+	}
+	if s.certs != nil {
+		if err := s.certs.Close(); err != nil {
+			slog.Warn("failed to stop TLS certificate watcher", logging.Err(err))
+		}
+	}
+	if s.ownsLookup {
+		if err := s.lookup.Close(); err != nil {
+			slog.Warn("failed to stop rate limiter cleanup", logging.Err(err))
+		}
+	}
 	if s.server != nil {
 		if err := s.server.Shutdown(ctx); err != nil {
 			if err != http.ErrServerClosed {
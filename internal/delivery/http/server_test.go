@@ -0,0 +1,148 @@
+package httpdelivery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/events"
+	"WBtech_l0/internal/health"
+	"WBtech_l0/internal/ratelimit"
+	"WBtech_l0/internal/repository/cache"
+)
+
+// newTestServer собирает сервер со встроенными шаблонами. Ответы проверяются по спецификации
+// OpenAPI, поэтому любой тест через сервер заодно ловит расхождение обработчиков с контрактом.
+func newTestServer(t *testing.T, usecase domain.OrderUsecase) *Server {
+	return newTestServerWithConfig(t, usecase, &config.Config{})
+}
+
+func newTestServerWithConfig(t *testing.T, usecase domain.OrderUsecase, cfg *config.Config) *Server {
+	return newTestServerWithDeps(t, usecase, cfg, nil)
+}
+
+// newTestServerWithDeps создаёт тестовый сервер; override может дополнить зависимости
+func newTestServerWithDeps(t *testing.T, usecase domain.OrderUsecase, cfg *config.Config, override func(*Deps)) *Server {
+	cfg.Events.HeartbeatInterval = time.Hour
+	authn, err := auth.NewAuthenticator(cfg.Auth)
+	require.NoError(t, err)
+	assets, err := NewAssets(config.WebConfig{})
+	require.NoError(t, err)
+	spec, err := NewAPISpec(config.OpenAPIConfig{ValidateRequests: true, ValidateResponses: true})
+	require.NoError(t, err)
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryBackend(time.Minute), cfg.RateLimit)
+		t.Cleanup(func() { require.NoError(t, limiter.Close()) })
+	}
+	deps := Deps{
+		Usecase: usecase,
+		Exporter: exporterFunc(func(context.Context, domain.ExportFilter, int, func([]domain.Order) error) error {
+			return nil
+		}),
+		Checks:  health.NewRegistry(),
+		Cache:   cache.NewOrderCache(time.Minute, 10),
+		Authn:   authn,
+		Limiter: limiter,
+		Broker:  events.NewBroker(10),
+		Assets:  assets,
+		I18n:    newTestBundle(t),
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Spec:    spec,
+	}
+	if override != nil {
+		override(&deps)
+	}
+	s := NewServer(cfg, deps)
+	t.Cleanup(func() { require.NoError(t, s.Shutdown(context.Background())) })
+	return s
+}
+
+func TestServer_Routes(t *testing.T) {
+	usecase := &MockUsecase{
+		GetOrderFunc: func(_ context.Context, uid string) (domain.Order, error) {
+			if uid == "b563feb7b2b84b6test" {
+				return domain.Order{OrderUID: uid}, nil
+			}
+			return domain.Order{}, errors.New("not found")
+		},
+	}
+	s := newTestServer(t, usecase)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+		contentType  string
+		deprecated   bool
+	}{
+		{"GET", "/api/v1/orders/b563feb7b2b84b6test", http.StatusOK, "application/json", false},
+		{"GET", "/api/v1/orders/unknown", http.StatusNotFound, "application/json", false},
+		{"GET", "/api/order/b563feb7b2b84b6test", http.StatusOK, "application/json", true},
+		{"GET", "/order/b563feb7b2b84b6test", http.StatusOK, "text/html; charset=utf-8", false},
+		{"GET", "/order/unknown", http.StatusNotFound, "text/html; charset=utf-8", false},
+		{"POST", "/order/b563feb7b2b84b6test", http.StatusMethodNotAllowed, "", false},
+		{"GET", "/api/unknown", http.StatusNotFound, "application/json", false},
+		{"GET", "/api/health", http.StatusOK, "application/json", false},
+		{"GET", "/livez", http.StatusOK, "application/json", false},
+		{"GET", "/", http.StatusOK, "text/html; charset=utf-8", false},
+		{"GET", "/some/spa/route", http.StatusOK, "text/html; charset=utf-8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := do(tt.method, tt.path)
+			require.Equal(t, tt.status, w.Code)
+			if tt.contentType != "" {
+				require.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			}
+			if tt.deprecated {
+				require.Equal(t, "@1790812800", w.Header().Get("Deprecation"))
+				require.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+				require.Equal(t, `</api/v1/orders/b563feb7b2b84b6test>; rel="successor-version"`, w.Header().Get("Link"))
+			} else {
+				require.Empty(t, w.Header().Get("Deprecation"))
+			}
+		})
+	}
+}
+
+func TestRouteName(t *testing.T) {
+	require.Equal(t, "/api/v1/orders/{uid}", routeName("GET /api/v1/orders/{uid}"))
+	require.Equal(t, "/api/", routeName("/api/"))
+}
+
+func TestServer_H2C(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.HTTPServer.H2C = true
+	cfg.HTTPServer.Compression = config.CompressionConfig{Enabled: true, MinSize: 1024}
+	s := newTestServerWithConfig(t, &MockUsecase{}, cfg)
+
+	ts := httptest.NewUnstartedServer(s.handler())
+	ts.Config.Protocols = s.protocols()
+	ts.Start()
+	defer ts.Close()
+
+	// клиент с prior knowledge: HTTP/2 без TLS и без Upgrade
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	resp, err := client.Get(ts.URL + "/livez")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, resp.ProtoMajor)
+}
@@ -0,0 +1,221 @@
+package httpdelivery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/events"
+	"WBtech_l0/internal/logging"
+)
+
+// streamMessage — формат события в SSE и WebSocket потоках
+type streamMessage struct {
+	ID    uint64      `json:"id"`
+	Type  string      `json:"type"`
+	Time  time.Time   `json:"time"`
+	Order interface{} `json:"order"`
+}
+
+// parseStreamParams читает фильтры и позицию, с которой продолжить поток.
+// Last-Event-ID берётся из заголовка (его выставляет EventSource при переподключении)
+// или из параметра last_event_id (для WebSocket и первого подключения)
+func parseStreamParams(r *http.Request) (events.Filter, uint64, error) {
+	q := r.URL.Query()
+	filter := events.Filter{
+		Entry:           q.Get("entry"),
+		DeliveryService: q.Get("delivery_service"),
+		CustomerID:      q.Get("customer_id"),
+	}
+
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = q.Get("last_event_id")
+	}
+	if raw == "" {
+		return filter, 0, nil
+	}
+	lastID, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return filter, 0, fmt.Errorf("invalid last event id %q", raw)
+	}
+	return filter, lastID, nil
+}
+
+// MakeSSEHandler отдаёт поток событий о заказах в формате Server-Sent Events
+func MakeSSEHandler(broker *events.Broker, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, lastID, err := parseStreamParams(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: err.Error()})
+			return
+		}
+
+		// Соединение живёт дольше WriteTimeout сервера — снимаем дедлайн для этого запроса
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			slog.WarnContext(r.Context(), "SSE: failed to reset write deadline", logging.Err(err))
+		}
+
+		sub, replay := broker.Subscribe(filter, lastID)
+		defer broker.Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+			return
+		}
+		for _, e := range replay {
+			if err := writeSSEEvent(w, r, e); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			slog.WarnContext(r.Context(), "SSE: flush not supported", logging.Err(err))
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					// подписчик отстал и был отключён; клиент переподключится с Last-Event-ID
+					return
+				}
+				if err := writeSSEEvent(w, r, e); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeSSEEvent пишет одно событие с учётом прав клиента на персональные данные
+func writeSSEEvent(w http.ResponseWriter, r *http.Request, e events.Event) error {
+	data, err := json.Marshal(streamMessage{
+		ID:    e.ID,
+		Type:  e.Type,
+		Time:  e.Time,
+		Order: auth.VisibleOrder(r.Context(), e.Order),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// wsWriteTimeout — максимальное время записи одного сообщения в WebSocket
+const wsWriteTimeout = 10 * time.Second
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// MakeWebSocketHandler отдаёт поток событий о заказах через WebSocket.
+// Сообщения имеют тот же JSON-формат, что и data в SSE; heartbeat — ping-кадры
+func MakeWebSocketHandler(broker *events.Broker, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, lastID, err := parseStreamParams(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: err.Error()})
+			return
+		}
+
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.WarnContext(r.Context(), "WebSocket upgrade failed", logging.Err(err))
+			return
+		}
+		defer func() {
+			if err := conn.Close(); err != nil {
+				slog.WarnContext(r.Context(), "failed to close WebSocket", logging.Err(err))
+			}
+		}()
+
+		sub, replay := broker.Subscribe(filter, lastID)
+		defer broker.Unsubscribe(sub)
+
+		// Читаем входящие кадры, чтобы обрабатывать pong и закрытие соединения клиентом
+		closed := make(chan struct{})
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+		})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		send := func(e events.Event) error {
+			if err := conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+				return err
+			}
+			return conn.WriteJSON(streamMessage{
+				ID:    e.ID,
+				Type:  e.Type,
+				Time:  e.Time,
+				Order: auth.VisibleOrder(r.Context(), e.Order),
+			})
+		}
+
+		for _, e := range replay {
+			if err := send(e); err != nil {
+				return
+			}
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case <-r.Context().Done():
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					_ = conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber too slow"),
+						time.Now().Add(wsWriteTimeout))
+					return
+				}
+				if err := send(e); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package httpdelivery
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/events"
+)
+
+// readSSEEvent читает поток до первого события и возвращает его id и data
+func readSSEEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	var id, data string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			return id, data
+		}
+	}
+}
+
+func TestMakeSSEHandler_FilterAndResume(t *testing.T) {
+	broker := events.NewBroker(10)
+	broker.PublishOrder(domain.Order{OrderUID: "old", Entry: "WBIL"})
+	missed := broker.Publish(events.TypeOrderSaved, domain.Order{OrderUID: "missed", Entry: "WBIL"})
+
+	srv := httptest.NewServer(MakeSSEHandler(broker, time.Hour))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"?entry=WBIL", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(missed.ID-1, 10))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	// сначала приходит пропущенное событие из истории
+	id, data := readSSEEvent(t, reader)
+	require.Equal(t, strconv.FormatUint(missed.ID, 10), id)
+	require.Contains(t, data, `"order_uid":"missed"`)
+
+	// затем новые события, подходящие под фильтр
+	broker.PublishOrder(domain.Order{OrderUID: "other", Entry: "OTHER"})
+	broker.PublishOrder(domain.Order{OrderUID: "live", Entry: "WBIL", Delivery: domain.Delivery{Phone: "+79001231234"}})
+	_, data = readSSEEvent(t, reader)
+
+	var msg struct {
+		Order domain.Order `json:"order"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(data), &msg))
+	require.Equal(t, "live", msg.Order.OrderUID)
+	require.Equal(t, "+7******1234", msg.Order.Delivery.Phone)
+}
+
+func TestMakeSSEHandler_InvalidLastEventID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/orders/stream?last_event_id=abc", nil)
+	w := httptest.NewRecorder()
+	MakeSSEHandler(events.NewBroker(10), time.Hour).ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMakeWebSocketHandler(t *testing.T) {
+	broker := events.NewBroker(10)
+	srv := httptest.NewServer(MakeWebSocketHandler(broker, time.Hour))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "?delivery_service=meest"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	defer func() { _ = conn.Close() }()
+
+	// подписка оформляется после апгрейда, поэтому публикуем, пока событие не дойдёт
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				broker.PublishOrder(domain.Order{OrderUID: "skipped", DeliveryService: "other"})
+				broker.PublishOrder(domain.Order{OrderUID: "ws-order", DeliveryService: "meest"})
+			}
+		}
+	}()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg struct {
+		Type  string       `json:"type"`
+		Order domain.Order `json:"order"`
+	}
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, "ws-order", msg.Order.OrderUID)
+	require.Equal(t, events.TypeOrderSaved, msg.Type)
+}
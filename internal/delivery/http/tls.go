@@ -0,0 +1,101 @@
+package httpdelivery
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"WBtech_l0/internal/logging"
+)
+
+// certReloader отдаёт TLS-сертификат из файлов и перечитывает его при их изменении.
+// Следит за каталогами, а не за файлами: cert-manager и Kubernetes подменяют
+// сертификат переименованием или сменой симлинка, и наблюдение за самим файлом теряется
+type certReloader struct {
+	certFile string
+	keyFile  string
+	watcher  *fsnotify.Watcher
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// newCertReloader загружает сертификат и начинает следить за изменениями файлов
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+	dirs := map[string]bool{filepath.Dir(certFile): true, filepath.Dir(keyFile): true}
+	for d := range dirs {
+		if err := watcher.Add(d); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("watch %s: %w", d, err)
+		}
+	}
+	c.watcher = watcher
+	go c.watch()
+	return c, nil
+}
+
+// reload читает пару сертификат/ключ. При ошибке остаётся предыдущий сертификат
+func (c *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *certReloader) watch() {
+	// Сертификат и ключ обновляются несколькими событиями — перечитываем один раз после паузы
+	var debounce <-chan time.Time
+	for {
+		select {
+		case _, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			debounce = time.After(100 * time.Millisecond)
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("TLS certificate watcher failed", logging.Err(err))
+		case <-debounce:
+			if err := c.reload(); err != nil {
+				slog.Error("TLS certificate reload failed, keeping previous certificate", logging.Err(err))
+			} else {
+				slog.Info("TLS certificate reloaded")
+			}
+		}
+	}
+}
+
+// GetCertificate используется в tls.Config: каждое новое соединение получает текущий сертификат
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// Close останавливает наблюдение за файлами
+func (c *certReloader) Close() error {
+	if c.watcher != nil {
+		return c.watcher.Close()
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package httpdelivery
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSigned записывает самоподписанный сертификат с заданным CN в certFile/keyFile
+func writeSelfSigned(t *testing.T, certFile, keyFile, cn string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+}
+
+func certCN(t *testing.T, c *certReloader) string {
+	t.Helper()
+	cert, err := c.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeSelfSigned(t, certFile, keyFile, "first")
+
+	c, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	defer c.Close()
+	require.Equal(t, "first", certCN(t, c))
+
+	// обновлённый сертификат подхватывается без перезапуска
+	writeSelfSigned(t, certFile, keyFile, "second")
+	require.Eventually(t, func() bool { return certCN(t, c) == "second" }, 5*time.Second, 20*time.Millisecond)
+
+	// битый файл не заменяет рабочий сертификат
+	require.NoError(t, os.WriteFile(certFile, []byte("garbage"), 0o644))
+	time.Sleep(300 * time.Millisecond)
+	require.Equal(t, "second", certCN(t, c))
+}
+
+func TestNewCertReloader_MissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := newCertReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	require.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package httpdelivery
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/i18n"
+	"WBtech_l0/internal/logging"
+)
+
+// trackTemplateName — публичная страница отслеживания посылки
+const trackTemplateName = "track.html"
+
+// trackData содержит данные для страницы отслеживания
+type trackData struct {
+	TrackNumber string
+	Parcel      domain.Parcel
+	Found       bool
+	L           *i18n.Localizer
+}
+
+// MakeTrackHandler — публичная HTML-страница посылки по трек-номеру из параметра
+// маршрута {track_number}. Показывает только названия и статусы товаров и город доставки
+func MakeTrackHandler(usecase domain.OrderUsecase, assets *Assets, bundle *i18n.Bundle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acceptLanguage := r.Header.Get("Accept-Language")
+		trackNumber := r.PathValue("track_number")
+		if !domain.ValidTrackNumber(trackNumber) {
+			http.Error(w, "Invalid track_number format", http.StatusBadRequest)
+			return
+		}
+
+		parcel, err := usecase.TrackParcel(r.Context(), trackNumber)
+		switch {
+		case errors.Is(err, domain.ErrParcelNotFound):
+			renderPage(w, assets, trackTemplateName, http.StatusNotFound,
+				trackData{TrackNumber: trackNumber, L: bundle.Localizer("", acceptLanguage)})
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "track parcel failed", logging.Err(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		renderPage(w, assets, trackTemplateName, http.StatusOK, trackData{
+			TrackNumber: trackNumber,
+			Parcel:      parcel,
+			Found:       true,
+			L:           bundle.Localizer(parcel.Locale, acceptLanguage),
+		})
+	}
+}
+
+// MakeJSONTrackHandler возвращает публичные сведения о посылке по трек-номеру
+func MakeJSONTrackHandler(usecase domain.OrderUsecase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		trackNumber := r.PathValue("track_number")
+		if !domain.ValidTrackNumber(trackNumber) {
+			writeJSON(w, http.StatusBadRequest, JSONResponse{Success: false, Error: "Invalid track_number format"})
+			return
+		}
+
+		parcel, err := usecase.TrackParcel(r.Context(), trackNumber)
+		switch {
+		case errors.Is(err, domain.ErrParcelNotFound):
+			writeJSON(w, http.StatusNotFound, JSONResponse{Success: false, Error: "Parcel not found"})
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "track parcel failed", logging.Err(err))
+			writeJSON(w, http.StatusInternalServerError, JSONResponse{Success: false, Error: "failed to track parcel"})
+			return
+		}
+		writeJSON(w, http.StatusOK, JSONResponse{Success: true, Data: parcel})
+	}
+}
@@ -0,0 +1,98 @@
+package httpdelivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/auth"
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+)
+
+func newTrackUsecase() *MockUsecase {
+	return &MockUsecase{
+		TrackParcelFunc: func(_ context.Context, track string) (domain.Parcel, error) {
+			switch track {
+			case "WBILMTESTTRACK":
+				return domain.Parcel{TrackNumber: track, City: "Kazan", Locale: "en", Items: []domain.ParcelItem{
+					{Name: "Mascaras", Status: 202},
+				}}, nil
+			case "broken":
+				return domain.Parcel{}, errors.New("connection refused")
+			}
+			return domain.Parcel{}, domain.ErrParcelNotFound
+		},
+	}
+}
+
+func TestMakeJSONTrackHandler(t *testing.T) {
+	// аутентификация включена, но отслеживание публичное
+	s := newTestServerWithConfig(t, newTrackUsecase(), &config.Config{
+		Auth: config.AuthConfig{Enabled: true, APIKeys: []config.APIKeyConfig{
+			{Name: "reader", KeyHash: auth.HashAPIKey("reader-key"), Scopes: []string{"orders:read"}},
+		}},
+	})
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/track/WBILMTESTTRACK", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Success bool                   `json:"success"`
+		Data    map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.True(t, resp.Success)
+	// только трек, город и товары — никаких полей оплаты и внутренних полей заказа
+	require.Len(t, resp.Data, 3)
+	require.Equal(t, "Kazan", resp.Data["city"])
+	require.Equal(t, []interface{}{map[string]interface{}{"name": "Mascaras", "status": float64(202)}}, resp.Data["items"])
+
+	for path, status := range map[string]int{
+		"/api/v1/track/unknown": http.StatusNotFound,
+		"/api/v1/track/bad$uid": http.StatusBadRequest,
+		"/api/v1/track/broken":  http.StatusInternalServerError,
+	} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, status, w.Code, "%s: %s", path, w.Body.String())
+	}
+}
+
+func TestMakeTrackHandler(t *testing.T) {
+	s := newTestServer(t, newTrackUsecase())
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest("GET", "/track/WBILMTESTTRACK", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	require.Contains(t, body, "Mascaras")
+	require.Contains(t, body, "Kazan")
+	// язык страницы — по локали заказа
+	require.Contains(t, body, `<html lang="en">`)
+
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest("GET", "/track/unknown", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Body.String(), "unknown")
+}
+
+func TestTrackRoutes_RateLimited(t *testing.T) {
+	// лимит публичного поиска действует и при выключенном rate_limit
+	s := newTestServerWithConfig(t, newTrackUsecase(), &config.Config{
+		RateLimit: config.RateLimitConfig{PublicLookup: config.PublicLookupLimit{RPS: 0.001, Burst: 1}},
+	})
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/track/WBILMTESTTRACK", nil))
+		codes = append(codes, w.Code)
+	}
+	require.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+}
@@ -0,0 +1,213 @@
+// Package diagnostics собирает состояние рантайма Go и архивы профилей pprof
+// для разбора проблем производительности
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+)
+
+// ErrProfilingBusy — CPU-профиль уже снимается (архивом или через /debug/pprof/profile)
+var ErrProfilingBusy = errors.New("cpu profiling is already in progress")
+
+// startTime — приблизительное время запуска процесса
+var startTime = time.Now()
+
+// RuntimeStats — состояние рантайма: горутины, память и сборщик мусора
+type RuntimeStats struct {
+	GoVersion     string      `json:"go_version"`
+	UptimeSeconds float64     `json:"uptime_seconds"`
+	Goroutines    int         `json:"goroutines"`
+	GOMAXPROCS    int         `json:"gomaxprocs"`
+	NumCPU        int         `json:"num_cpu"`
+	CgoCalls      int64       `json:"cgo_calls"`
+	Memory        MemoryStats `json:"memory"`
+	GC            GCStats     `json:"gc"`
+}
+
+// MemoryStats — использование памяти (байты, кроме числа объектов)
+type MemoryStats struct {
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapInuse    uint64 `json:"heap_inuse_bytes"`
+	HeapIdle     uint64 `json:"heap_idle_bytes"`
+	HeapReleased uint64 `json:"heap_released_bytes"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse_bytes"`
+	Sys          uint64 `json:"sys_bytes"`
+	TotalAlloc   uint64 `json:"total_alloc_bytes"`
+	Mallocs      uint64 `json:"mallocs"`
+	Frees        uint64 `json:"frees"`
+	Limit        int64  `json:"memory_limit_bytes"` // GOMEMLIMIT; math.MaxInt64 — без ограничения
+}
+
+// GCStats — работа сборщика мусора с момента запуска
+type GCStats struct {
+	NumGC             uint32     `json:"num_gc"`
+	NumForcedGC       uint32     `json:"num_forced_gc"`
+	NextGC            uint64     `json:"next_gc_bytes"` // размер кучи, при котором начнётся следующая сборка
+	LastGC            *time.Time `json:"last_gc,omitempty"`
+	LastPauseSeconds  float64    `json:"last_pause_seconds"`
+	PauseTotalSeconds float64    `json:"pause_total_seconds"`
+	CPUFraction       float64    `json:"cpu_fraction"` // доля процессорного времени на сборку мусора
+}
+
+// ReadRuntimeStats возвращает текущее состояние рантайма. runtime.ReadMemStats
+// ненадолго останавливает программу, поэтому вызывать его часто не стоит
+func ReadRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	s := RuntimeStats{
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: time.Since(startTime).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumCPU:        runtime.NumCPU(),
+		CgoCalls:      runtime.NumCgoCall(),
+		Memory: MemoryStats{
+			HeapAlloc:    m.HeapAlloc,
+			HeapInuse:    m.HeapInuse,
+			HeapIdle:     m.HeapIdle,
+			HeapReleased: m.HeapReleased,
+			HeapObjects:  m.HeapObjects,
+			StackInuse:   m.StackInuse,
+			Sys:          m.Sys,
+			TotalAlloc:   m.TotalAlloc,
+			Mallocs:      m.Mallocs,
+			Frees:        m.Frees,
+			Limit:        debug.SetMemoryLimit(-1), // отрицательное значение только читает лимит
+		},
+		GC: GCStats{
+			NumGC:             m.NumGC,
+			NumForcedGC:       m.NumForcedGC,
+			NextGC:            m.NextGC,
+			PauseTotalSeconds: time.Duration(m.PauseTotalNs).Seconds(),
+			CPUFraction:       m.GCCPUFraction,
+		},
+	}
+	if m.NumGC > 0 {
+		last := time.Unix(0, int64(m.LastGC)).UTC()
+		s.GC.LastGC = &last
+		s.GC.LastPauseSeconds = time.Duration(m.PauseNs[(m.NumGC+255)%256]).Seconds()
+	}
+	return s
+}
+
+// Bundle — архив профилей, записанный на диск
+type Bundle struct {
+	Path     string
+	Size     int64
+	Duration time.Duration // длительность CPU-профиля
+	Files    []string      // файлы внутри архива
+}
+
+// CaptureBundle снимает CPU-профиль за duration, затем профили кучи и горутин и состояние
+// рантайма и записывает их в каталог dir архивом profile-<время>-*.tar.gz. Одновременно
+// снимается только один CPU-профиль, иначе — ErrProfilingBusy. Отмена ctx прерывает
+// профилирование, архив при этом не создаётся
+func CaptureBundle(ctx context.Context, dir string, duration time.Duration) (Bundle, error) {
+	var cpu bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpu); err != nil {
+		return Bundle{}, fmt.Errorf("%w: %v", ErrProfilingBusy, err)
+	}
+	start := time.Now()
+	timer := time.NewTimer(duration)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+		pprof.StopCPUProfile()
+		return Bundle{}, ctx.Err()
+	}
+	pprof.StopCPUProfile()
+	elapsed := time.Since(start)
+
+	// сборка мусора перед снимком кучи, как /debug/pprof/heap?gc=1: иначе профиль
+	// показывает состояние на момент предыдущей сборки
+	runtime.GC()
+	var heap, goroutines bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return Bundle{}, fmt.Errorf("heap profile: %w", err)
+	}
+	// debug=2 — полные стеки всех горутин с временем ожидания, как при панике
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return Bundle{}, fmt.Errorf("goroutine profile: %w", err)
+	}
+	stats, err := json.MarshalIndent(ReadRuntimeStats(), "", "  ")
+	if err != nil {
+		return Bundle{}, fmt.Errorf("runtime stats: %w", err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"cpu.pprof", cpu.Bytes()},
+		{"heap.pprof", heap.Bytes()},
+		{"goroutine.txt", goroutines.Bytes()},
+		{"runtime.json", stats},
+	}
+	bundle := Bundle{Duration: elapsed, Files: make([]string, 0, len(files))}
+	for _, f := range files {
+		bundle.Files = append(bundle.Files, f.name)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Bundle{}, fmt.Errorf("create profile dir: %w", err)
+	}
+	out, err := os.CreateTemp(dir, "profile-"+start.UTC().Format("20060102T150405Z")+"-*.tar.gz")
+	if err != nil {
+		return Bundle{}, fmt.Errorf("create profile bundle: %w", err)
+	}
+	// удаляем недописанный архив при любой ошибке
+	ok := false
+	defer func() {
+		if !ok {
+			_ = out.Close()
+			_ = os.Remove(out.Name())
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0o600, Size: int64(len(f.data)), ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return Bundle{}, fmt.Errorf("write profile bundle: %w", err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return Bundle{}, fmt.Errorf("write profile bundle: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return Bundle{}, fmt.Errorf("write profile bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return Bundle{}, fmt.Errorf("write profile bundle: %w", err)
+	}
+	info, err := out.Stat()
+	if err != nil {
+		return Bundle{}, fmt.Errorf("write profile bundle: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return Bundle{}, fmt.Errorf("write profile bundle: %w", err)
+	}
+	ok = true
+
+	bundle.Path, bundle.Size = out.Name(), info.Size()
+	if abs, err := filepath.Abs(bundle.Path); err == nil {
+		bundle.Path = abs
+	}
+	return bundle, nil
+}
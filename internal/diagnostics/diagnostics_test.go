@@ -0,0 +1,89 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRuntimeStats(t *testing.T) {
+	runtime.GC()
+	s := ReadRuntimeStats()
+
+	require.Equal(t, runtime.Version(), s.GoVersion)
+	require.Positive(t, s.Goroutines)
+	require.Positive(t, s.Memory.HeapAlloc)
+	require.Positive(t, s.GC.NumGC)
+	require.NotNil(t, s.GC.LastGC)
+}
+
+func TestCaptureBundle(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+
+	bundle, err := CaptureBundle(context.Background(), dir, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, dir, filepath.Dir(bundle.Path))
+	require.GreaterOrEqual(t, bundle.Duration, 50*time.Millisecond)
+
+	f, err := os.Open(bundle.Path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), bundle.Size)
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	contents := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		contents[hdr.Name], err = io.ReadAll(tr)
+		require.NoError(t, err)
+	}
+	require.Len(t, contents, len(bundle.Files))
+	for _, name := range bundle.Files {
+		require.NotEmpty(t, contents[name], name)
+	}
+	require.Contains(t, string(contents["goroutine.txt"]), "TestCaptureBundle")
+	var stats RuntimeStats
+	require.NoError(t, json.Unmarshal(contents["runtime.json"], &stats))
+	require.Positive(t, stats.Goroutines)
+}
+
+func TestCaptureBundle_Busy(t *testing.T) {
+	require.NoError(t, pprof.StartCPUProfile(io.Discard))
+	_, err := CaptureBundle(context.Background(), t.TempDir(), time.Millisecond)
+	pprof.StopCPUProfile()
+	require.ErrorIs(t, err, ErrProfilingBusy)
+}
+
+func TestCaptureBundle_Canceled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CaptureBundle(ctx, dir, time.Minute)
+	require.ErrorIs(t, err, context.Canceled)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// профилирование остановлено и его можно начать снова
+	require.NoError(t, pprof.StartCPUProfile(io.Discard))
+	pprof.StopCPUProfile()
+}
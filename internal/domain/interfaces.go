@@ -27,12 +27,38 @@ type OrderCache interface {
 type OrderRepository interface {
 	SaveOrder(ctx context.Context, order Order) error
 	GetOrder(ctx context.Context, orderUID string) (Order, error)
+	GetOrders(ctx context.Context, orderUIDs []string) ([]Order, error)
 	LoadAllOrders(ctx context.Context) ([]Order, error)
+	ListOrders(ctx context.Context, afterUID string, limit int) ([]Order, error)
+	// FindByTrackNumber ищет заказы с этим трек-номером у заказа или у любого из товаров
+	FindByTrackNumber(ctx context.Context, trackNumber string) ([]Order, error)
 	ClearAll(ctx context.Context) error
 }
 
+// ExportFilter задаёт выборку для выгрузки заказов. Нулевые границы не ограничивают выборку
+type ExportFilter struct {
+	From time.Time // date_created >= From
+	To   time.Time // date_created < To
+}
+
+// OrderExporter постранично читает заказы для выгрузки: fn получает очередную страницу,
+// и следующая читается только после возврата из fn, поэтому память не зависит от объёма выборки
+type OrderExporter interface {
+	ExportOrders(ctx context.Context, filter ExportFilter, pageSize int, fn func(page []Order) error) error
+}
+
 // OrderUsecase объединяет бизнес-логику получения и сохранения заказов
 type OrderUsecase interface {
 	GetOrder(ctx context.Context, orderUID string) (Order, error)
+	// GetOrders возвращает найденные заказы в порядке запроса и order_uid, которых нет
+	GetOrders(ctx context.Context, orderUIDs []string) (orders []Order, missing []string, err error)
 	SaveOrder(ctx context.Context, order Order) error
+	ListOrders(ctx context.Context, afterUID string, limit int) ([]Order, error)
+	// TrackParcel возвращает публичные сведения о посылке; ErrParcelNotFound, если её нет
+	TrackParcel(ctx context.Context, trackNumber string) (Parcel, error)
+}
+
+// OrderPublisher уведомляет подписчиков о сохранённых заказах
+type OrderPublisher interface {
+	PublishOrder(order Order)
 }
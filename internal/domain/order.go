@@ -5,8 +5,91 @@ import (
 	"errors"
 	"regexp"
 	"time"
+	"unicode/utf8"
 )
 
+// ErrOrderNotFound — заказа с таким order_uid нет в хранилище
+var ErrOrderNotFound = errors.New("order not found")
+
+// MaxBatchGetSize — сколько order_uid можно запросить за один пакетный вызов
+// (совпадает с maxItems в api/openapi.json)
+const MaxBatchGetSize = 100
+
+// ValidOrderUID проверяет, что order_uid из запроса не пустой, имеет допустимую длину
+// и состоит из латиницы, цифр, '-' и '_'
+func ValidOrderUID(orderUID string) bool {
+	if orderUID == "" || utf8.RuneCountInString(orderUID) > 255 {
+		return false
+	}
+
+	for _, r := range orderUID {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') &&
+			(r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ErrParcelNotFound — ни заказ, ни товар не отправлены с таким трек-номером
+var ErrParcelNotFound = errors.New("parcel not found")
+
+// ValidTrackNumber проверяет трек-номер из запроса: те же правила, что и для order_uid
+func ValidTrackNumber(trackNumber string) bool {
+	return ValidOrderUID(trackNumber)
+}
+
+// Parcel — публичные сведения о посылке для страницы отслеживания. Содержит только
+// названия и статусы товаров и город доставки: без оплаты, персональных и внутренних полей
+type Parcel struct {
+	TrackNumber string       `json:"track_number"`
+	City        string       `json:"city"`
+	Items       []ParcelItem `json:"items"`
+	Locale      string       `json:"-"` // язык страницы, если его не задал Accept-Language
+}
+
+// ParcelItem — товар в посылке
+type ParcelItem struct {
+	Name   string `json:"name"`
+	Status int    `json:"status"`
+}
+
+// NewParcel собирает посылку trackNumber из заказов, найденных по этому трек-номеру.
+// Товары со своим трек-номером едут отдельными посылками: в посылку попадают товары
+// с этим трек-номером, а если он совпадает с треком заказа — ещё и товары без своего трека.
+// Если по треку заказа не нашлось ни одного такого товара, показываются все товары заказа
+func NewParcel(trackNumber string, orders []Order) (Parcel, error) {
+	p := Parcel{TrackNumber: trackNumber, Items: []ParcelItem{}}
+	found := false
+	for _, o := range orders {
+		orderTrack := o.TrackNumber == trackNumber
+		var items []ParcelItem
+		for _, it := range o.Items {
+			if it.TrackNumber == trackNumber || (orderTrack && it.TrackNumber == "") {
+				items = append(items, ParcelItem{Name: it.Name, Status: it.Status})
+			}
+		}
+		if orderTrack && len(items) == 0 {
+			for _, it := range o.Items {
+				items = append(items, ParcelItem{Name: it.Name, Status: it.Status})
+			}
+		}
+		if !orderTrack && len(items) == 0 {
+			continue
+		}
+		if !found {
+			p.City, p.Locale = o.Delivery.City, o.Locale
+			found = true
+		}
+		p.Items = append(p.Items, items...)
+	}
+	if !found {
+		return Parcel{}, ErrParcelNotFound
+	}
+	return p, nil
+}
+
 // Order — основная модель заказа
 type Order struct {
 	OrderUID          string   `json:"order_uid"`
@@ -0,0 +1,141 @@
+// Package events реализует in-process шину событий о заказах для потоковых API
+package events
+
+import (
+	"sync"
+	"time"
+
+	"WBtech_l0/internal/domain"
+)
+
+// TypeOrderSaved — тип события о новом или обновлённом заказе
+const TypeOrderSaved = "order.saved"
+
+// Event — событие о заказе
+type Event struct {
+	ID    uint64       `json:"id"`
+	Type  string       `json:"type"`
+	Time  time.Time    `json:"time"`
+	Order domain.Order `json:"order"`
+}
+
+// Filter ограничивает поток событий. Пустые поля не учитываются
+type Filter struct {
+	Entry           string
+	DeliveryService string
+	CustomerID      string
+}
+
+// Match проверяет, подходит ли событие под фильтр
+func (f Filter) Match(e Event) bool {
+	return (f.Entry == "" || f.Entry == e.Order.Entry) &&
+		(f.DeliveryService == "" || f.DeliveryService == e.Order.DeliveryService) &&
+		(f.CustomerID == "" || f.CustomerID == e.Order.CustomerID)
+}
+
+// Subscription — подписка на события. Канал C закрывается при отписке
+// или если подписчик не успевает читать события
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter Filter
+	once   sync.Once
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// Broker рассылает события подписчикам и хранит последние события
+// для продолжения потока по Last-Event-ID
+type Broker struct {
+	mu         sync.Mutex
+	nextID     uint64
+	history    []Event // кольцевой буфер
+	start      int     // индекс самого старого события в history
+	size       int     // количество событий в history
+	subs       map[*Subscription]struct{}
+	bufferSize int
+}
+
+// NewBroker создаёт шину, хранящую historySize последних событий.
+// Идентификаторы событий начинаются с текущего времени в микросекундах,
+// поэтому после перезапуска сервиса они продолжают возрастать
+func NewBroker(historySize int) *Broker {
+	if historySize <= 0 {
+		historySize = 1000
+	}
+	return &Broker{
+		nextID:     uint64(time.Now().UnixMicro()),
+		history:    make([]Event, historySize),
+		subs:       make(map[*Subscription]struct{}),
+		bufferSize: 64,
+	}
+}
+
+// PublishOrder реализует domain.OrderPublisher
+func (b *Broker) PublishOrder(order domain.Order) {
+	b.Publish(TypeOrderSaved, order)
+}
+
+// Publish сохраняет событие в истории и рассылает его подписчикам.
+// Подписчик с переполненным буфером отключается, чтобы не тормозить остальных:
+// клиент переподключится и догонит поток по Last-Event-ID
+func (b *Broker) Publish(eventType string, order domain.Order) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e := Event{ID: b.nextID, Type: eventType, Time: time.Now().UTC(), Order: order}
+
+	idx := (b.start + b.size) % len(b.history)
+	b.history[idx] = e
+	if b.size < len(b.history) {
+		b.size++
+	} else {
+		b.start = (b.start + 1) % len(b.history)
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			delete(b.subs, sub)
+			sub.close()
+		}
+	}
+	return e
+}
+
+// Subscribe подписывает на события, подходящие под фильтр. Если lastEventID > 0,
+// возвращает пропущенные события из истории, которые нужно отправить до новых
+func (b *Broker) Subscribe(filter Filter, lastEventID uint64) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, b.bufferSize)
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+	b.subs[sub] = struct{}{}
+
+	var replay []Event
+	if lastEventID > 0 {
+		for i := 0; i < b.size; i++ {
+			e := b.history[(b.start+i)%len(b.history)]
+			if e.ID > lastEventID && filter.Match(e) {
+				replay = append(replay, e)
+			}
+		}
+	}
+	return sub, replay
+}
+
+// Unsubscribe отменяет подписку и закрывает её канал
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, sub)
+	sub.close()
+}
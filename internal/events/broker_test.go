@@ -0,0 +1,65 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/domain"
+)
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	b := NewBroker(10)
+	sub, replay := b.Subscribe(Filter{Entry: "WBIL"}, 0)
+	defer b.Unsubscribe(sub)
+	require.Empty(t, replay)
+
+	b.PublishOrder(domain.Order{OrderUID: "skip", Entry: "OTHER"})
+	b.PublishOrder(domain.Order{OrderUID: "match", Entry: "WBIL"})
+
+	e := <-sub.C
+	require.Equal(t, "match", e.Order.OrderUID)
+	require.Equal(t, TypeOrderSaved, e.Type)
+	require.Empty(t, sub.C)
+}
+
+func TestBroker_ResumeFromLastEventID(t *testing.T) {
+	b := NewBroker(3)
+	var ids []uint64
+	for _, uid := range []string{"a", "b", "c", "d"} {
+		ids = append(ids, b.Publish(TypeOrderSaved, domain.Order{OrderUID: uid}).ID)
+	}
+
+	sub, replay := b.Subscribe(Filter{}, ids[1])
+	defer b.Unsubscribe(sub)
+	require.Len(t, replay, 2)
+	require.Equal(t, "c", replay[0].Order.OrderUID)
+	require.Equal(t, "d", replay[1].Order.OrderUID)
+
+	// событие "a" вытеснено из истории размером 3
+	sub2, replay := b.Subscribe(Filter{}, ids[0]-1)
+	defer b.Unsubscribe(sub2)
+	require.Len(t, replay, 3)
+	require.Equal(t, "b", replay[0].Order.OrderUID)
+}
+
+func TestBroker_SlowSubscriberIsDropped(t *testing.T) {
+	b := NewBroker(10)
+	b.bufferSize = 1
+	sub, _ := b.Subscribe(Filter{}, 0)
+
+	b.PublishOrder(domain.Order{OrderUID: "1"})
+	b.PublishOrder(domain.Order{OrderUID: "2"})
+
+	<-sub.C
+	_, open := <-sub.C
+	require.False(t, open)
+	b.Unsubscribe(sub) // повторная отписка безопасна
+}
+
+func TestFilter_Match(t *testing.T) {
+	e := Event{Order: domain.Order{Entry: "WBIL", DeliveryService: "meest", CustomerID: "c1"}}
+	require.True(t, Filter{}.Match(e))
+	require.True(t, Filter{DeliveryService: "meest", CustomerID: "c1"}.Match(e))
+	require.False(t, Filter{CustomerID: "c2"}.Match(e))
+}
@@ -0,0 +1,131 @@
+// Package export выгружает заказы потоком в NDJSON, CSV и Parquet.
+// Заказы читаются страницами из domain.OrderExporter и сразу пишутся в выход,
+// поэтому расход памяти определяется размером страницы, а не объёмом выборки
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"WBtech_l0/internal/domain"
+)
+
+// Format — формат выгрузки
+type Format string
+
+// Поддерживаемые форматы
+const (
+	FormatNDJSON  Format = "ndjson"
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+// Formats — все форматы в порядке перечисления в документации
+var Formats = []Format{FormatNDJSON, FormatCSV, FormatParquet}
+
+// ParseFormat разбирает название формата; пустая строка означает NDJSON
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatNDJSON, nil
+	case FormatNDJSON, FormatCSV, FormatParquet:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q (supported: ndjson, csv, parquet)", s)
+}
+
+// ContentType возвращает MIME-тип формата
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "application/x-ndjson"
+}
+
+// Extension возвращает расширение файла без точки
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// ParseFilter разбирает границы выгрузки в формате RFC3339 или YYYY-MM-DD (полночь UTC).
+// Пустая строка означает отсутствие границы; to не включается в выборку
+func ParseFilter(from, to string) (domain.ExportFilter, error) {
+	var filter domain.ExportFilter
+	var err error
+	if filter.From, err = parseTime(from); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = parseTime(to); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("from %s must be before to %s", from, to)
+	}
+	return filter, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC3339 nor YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// Writer пишет страницы заказов в выбранном формате. Close дописывает хвост
+// формата (например, метаданные Parquet), но не закрывает исходный io.Writer
+type Writer interface {
+	Write(orders []domain.Order) error
+	Close() error
+}
+
+// NewWriter создаёт Writer для формата f
+func NewWriter(f Format, out io.Writer) (Writer, error) {
+	switch f {
+	case FormatNDJSON:
+		return newNDJSONWriter(out), nil
+	case FormatCSV:
+		return newCSVWriter(out)
+	case FormatParquet:
+		return newParquetWriter(out), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// Run выгружает заказы, подходящие под filter, в w и возвращает их количество.
+// mask, если задан, применяется к каждому заказу перед записью (маскирование PII)
+func Run(ctx context.Context, src domain.OrderExporter, filter domain.ExportFilter, pageSize int,
+	w Writer, mask func(domain.Order) domain.Order) (int, error) {
+	total := 0
+	err := src.ExportOrders(ctx, filter, pageSize, func(page []domain.Order) error {
+		if mask != nil {
+			for i := range page {
+				page[i] = mask(page[i])
+			}
+		}
+		if err := w.Write(page); err != nil {
+			return fmt.Errorf("write page: %w", err)
+		}
+		total += len(page)
+		return nil
+	})
+	if err != nil {
+		return total, fmt.Errorf("export orders: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return total, fmt.Errorf("finish export: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/domain"
+)
+
+// pagedExporter отдаёт заказы страницами, как курсор в БД
+type pagedExporter struct {
+	orders []domain.Order
+	err    error // возвращается после первой страницы
+	pages  int
+}
+
+func (e *pagedExporter) ExportOrders(_ context.Context, _ domain.ExportFilter, pageSize int, fn func([]domain.Order) error) error {
+	for i := 0; i < len(e.orders); i += pageSize {
+		if e.err != nil && e.pages > 0 {
+			return e.err
+		}
+		page := append([]domain.Order(nil), e.orders[i:min(i+pageSize, len(e.orders))]...)
+		e.pages++
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testOrders() []domain.Order {
+	return []domain.Order{
+		{
+			OrderUID: "order1", TrackNumber: "TRACK1", Entry: "WBIL", Locale: "en", CustomerID: "c1",
+			DeliveryService: "meest", SmID: 99, DateCreated: "2024-01-02T03:04:05Z",
+			Delivery: domain.Delivery{Name: "Ivan", Phone: "+79990000000", City: "Moscow"},
+			Payment:  domain.Payment{Transaction: "order1", Currency: "RUB", Amount: 1817, PaymentDT: 1637907727},
+			Items: []domain.Item{
+				{ChrtID: 1, Name: "Mascaras", Price: 453, TotalPrice: 317, Status: 202},
+				{ChrtID: 2, Name: "Lipstick", Price: 100, TotalPrice: 100, Status: 202},
+			},
+		},
+		{OrderUID: "order2", DateCreated: "2024-01-03T00:00:00Z"},
+		{OrderUID: "order3", DateCreated: "not a date", Items: []domain.Item{{ChrtID: 3}}},
+	}
+}
+
+func runExport(t *testing.T, f Format, src domain.OrderExporter, mask func(domain.Order) domain.Order) ([]byte, int, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(f, &buf)
+	require.NoError(t, err)
+	n, err := Run(context.Background(), src, domain.ExportFilter{}, 2, w, mask)
+	return buf.Bytes(), n, err
+}
+
+func TestRun_NDJSON(t *testing.T) {
+	src := &pagedExporter{orders: testOrders()}
+	mask := func(o domain.Order) domain.Order {
+		o.Delivery.Phone = "***"
+		return o
+	}
+
+	out, n, err := runExport(t, FormatNDJSON, src, mask)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, 2, src.pages)
+
+	var got []domain.Order
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		var o domain.Order
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &o))
+		got = append(got, o)
+	}
+	require.Len(t, got, 3)
+	require.Equal(t, "order1", got[0].OrderUID)
+	require.Equal(t, "***", got[0].Delivery.Phone)
+	require.Len(t, got[0].Items, 2)
+}
+
+func TestRun_CSV(t *testing.T) {
+	out, n, err := runExport(t, FormatCSV, &pagedExporter{orders: testOrders()}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	rows, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	// заголовок + 2 товара order1 + order2 без товаров + 1 товар order3
+	require.Len(t, rows, 5)
+	require.Equal(t, csvHeader, rows[0])
+	col := func(name string) int {
+		for i, h := range csvHeader {
+			if h == name {
+				return i
+			}
+		}
+		t.Fatalf("no column %q", name)
+		return -1
+	}
+	require.Equal(t, []string{"order1", "order1", "order2", "order3"},
+		[]string{rows[1][0], rows[2][0], rows[3][0], rows[4][0]})
+	require.Equal(t, "Lipstick", rows[2][col("item_name")])
+	require.Equal(t, "1817", rows[2][col("payment_amount")])
+	require.Equal(t, "", rows[3][col("item_chrt_id")])
+}
+
+func TestRun_Parquet(t *testing.T) {
+	out, n, err := runExport(t, FormatParquet, &pagedExporter{orders: testOrders()}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	f, err := parquet.OpenFile(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	// страница курсора — отдельная группа строк
+	require.Len(t, f.RowGroups(), 2)
+
+	rows, err := parquet.Read[parquetOrder](bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.Equal(t, "order1", rows[0].OrderUID)
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(), rows[0].DateCreated)
+	require.Equal(t, "Moscow", rows[0].Delivery.City)
+	require.Equal(t, int64(1817), rows[0].Payment.Amount)
+	require.Len(t, rows[0].Items, 2)
+	require.Equal(t, "Lipstick", rows[0].Items[1].Name)
+	require.Empty(t, rows[1].Items)
+	require.Zero(t, rows[2].DateCreated)
+}
+
+func TestRun_SourceError(t *testing.T) {
+	src := &pagedExporter{orders: testOrders(), err: errors.New("connection reset")}
+	_, n, err := runExport(t, FormatNDJSON, src, nil)
+	require.ErrorContains(t, err, "connection reset")
+	require.Equal(t, 2, n)
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatNDJSON, "csv": FormatCSV, "Parquet": FormatParquet} {
+		got, err := ParseFormat(in)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+	_, err := ParseFormat("xml")
+	require.Error(t, err)
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter("2024-01-01", "2024-02-01T12:00:00+03:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), f.From)
+	require.True(t, f.To.Equal(time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)))
+
+	f, err = ParseFilter("", "")
+	require.NoError(t, err)
+	require.True(t, f.From.IsZero() && f.To.IsZero())
+
+	for _, tt := range [][2]string{{"yesterday", ""}, {"", "2024-13-01"}, {"2024-02-01", "2024-01-01"}} {
+		_, err := ParseFilter(tt[0], tt[1])
+		require.Error(t, err, "%v", tt)
+	}
+}
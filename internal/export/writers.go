@@ -0,0 +1,230 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"WBtech_l0/internal/domain"
+)
+
+// ndjsonWriter пишет по одному заказу в строке
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(out io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(out)}
+}
+
+func (w *ndjsonWriter) Write(orders []domain.Order) error {
+	for _, o := range orders {
+		if err := w.enc.Encode(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error { return nil }
+
+// csvHeader — колонки плоского CSV: поля заказа, доставки и оплаты повторяются
+// в каждой строке товара. Заказ без товаров даёт одну строку с пустыми колонками item_*
+var csvHeader = []string{
+	"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id",
+	"delivery_service", "shardkey", "sm_id", "date_created", "oof_shard",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city", "delivery_address",
+	"delivery_region", "delivery_email",
+	"payment_transaction", "payment_request_id", "payment_currency", "payment_provider",
+	"payment_amount", "payment_dt", "payment_bank", "payment_delivery_cost",
+	"payment_goods_total", "payment_custom_fee",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name", "item_sale",
+	"item_size", "item_total_price", "item_nm_id", "item_brand", "item_status",
+}
+
+// csvWriter пишет плоский CSV: строка на товар
+type csvWriter struct {
+	w   *csv.Writer
+	row []string
+}
+
+func newCSVWriter(out io.Writer) (*csvWriter, error) {
+	w := csv.NewWriter(out)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: w, row: make([]string, 0, len(csvHeader))}, nil
+}
+
+func (w *csvWriter) Write(orders []domain.Order) error {
+	for _, o := range orders {
+		if len(o.Items) == 0 {
+			if err := w.w.Write(w.orderRow(o, nil)); err != nil {
+				return err
+			}
+			continue
+		}
+		for i := range o.Items {
+			if err := w.w.Write(w.orderRow(o, &o.Items[i])); err != nil {
+				return err
+			}
+		}
+	}
+	// Сбрасываем буфер на каждой странице, чтобы данные уходили клиенту сразу
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) orderRow(o domain.Order, it *domain.Item) []string {
+	d, p := o.Delivery, o.Payment
+	row := append(w.row[:0],
+		o.OrderUID, o.TrackNumber, o.Entry, o.Locale, o.InternalSignature, o.CustomerID,
+		o.DeliveryService, o.Shardkey, strconv.Itoa(o.SmID), o.DateCreated, o.OofShard,
+		d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email,
+		p.Transaction, p.RequestID, p.Currency, p.Provider, strconv.Itoa(p.Amount),
+		strconv.FormatInt(p.PaymentDT, 10), p.Bank, strconv.Itoa(p.DeliveryCost),
+		strconv.Itoa(p.GoodsTotal), strconv.Itoa(p.CustomFee),
+	)
+	if it == nil {
+		for len(row) < len(csvHeader) {
+			row = append(row, "")
+		}
+		return row
+	}
+	return append(row,
+		strconv.Itoa(it.ChrtID), it.TrackNumber, strconv.Itoa(it.Price), it.Rid, it.Name,
+		strconv.Itoa(it.Sale), it.Size, strconv.Itoa(it.TotalPrice), strconv.Itoa(it.NmID),
+		it.Brand, strconv.Itoa(it.Status),
+	)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// parquetOrder — строка Parquet: заказ с вложенными доставкой, оплатой и списком товаров
+type parquetOrder struct {
+	OrderUID          string          `parquet:"order_uid"`
+	TrackNumber       string          `parquet:"track_number"`
+	Entry             string          `parquet:"entry,dict"`
+	Locale            string          `parquet:"locale,dict"`
+	InternalSignature string          `parquet:"internal_signature"`
+	CustomerID        string          `parquet:"customer_id"`
+	DeliveryService   string          `parquet:"delivery_service,dict"`
+	Shardkey          string          `parquet:"shardkey"`
+	SmID              int64           `parquet:"sm_id"`
+	DateCreated       int64           `parquet:"date_created,timestamp(millisecond),optional"` // Unix-время в мс; 0 — NULL
+	OofShard          string          `parquet:"oof_shard"`
+	Delivery          parquetDelivery `parquet:"delivery"`
+	Payment           parquetPayment  `parquet:"payment"`
+	Items             []parquetItem   `parquet:"items,list"`
+}
+
+type parquetDelivery struct {
+	Name    string `parquet:"name"`
+	Phone   string `parquet:"phone"`
+	Zip     string `parquet:"zip"`
+	City    string `parquet:"city"`
+	Address string `parquet:"address"`
+	Region  string `parquet:"region"`
+	Email   string `parquet:"email"`
+}
+
+type parquetPayment struct {
+	Transaction  string `parquet:"transaction"`
+	RequestID    string `parquet:"request_id"`
+	Currency     string `parquet:"currency,dict"`
+	Provider     string `parquet:"provider,dict"`
+	Amount       int64  `parquet:"amount"`
+	PaymentDT    int64  `parquet:"payment_dt"`
+	Bank         string `parquet:"bank,dict"`
+	DeliveryCost int64  `parquet:"delivery_cost"`
+	GoodsTotal   int64  `parquet:"goods_total"`
+	CustomFee    int64  `parquet:"custom_fee"`
+}
+
+type parquetItem struct {
+	ChrtID      int64  `parquet:"chrt_id"`
+	TrackNumber string `parquet:"track_number"`
+	Price       int64  `parquet:"price"`
+	Rid         string `parquet:"rid"`
+	Name        string `parquet:"name"`
+	Sale        int64  `parquet:"sale"`
+	Size        string `parquet:"size"`
+	TotalPrice  int64  `parquet:"total_price"`
+	NmID        int64  `parquet:"nm_id"`
+	Brand       string `parquet:"brand"`
+	Status      int64  `parquet:"status"`
+}
+
+// parquetWriter пишет каждую страницу отдельной группой строк (row group):
+// в памяти держится только текущая страница
+type parquetWriter struct {
+	w    *parquet.GenericWriter[parquetOrder]
+	rows []parquetOrder
+}
+
+func newParquetWriter(out io.Writer) *parquetWriter {
+	return &parquetWriter{w: parquet.NewGenericWriter[parquetOrder](out, parquet.Compression(&parquet.Zstd))}
+}
+
+func (w *parquetWriter) Write(orders []domain.Order) error {
+	w.rows = w.rows[:0]
+	for _, o := range orders {
+		w.rows = append(w.rows, toParquet(o))
+	}
+	if _, err := w.w.Write(w.rows); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+func (w *parquetWriter) Close() error {
+	return w.w.Close()
+}
+
+func toParquet(o domain.Order) parquetOrder {
+	// Некорректная дата выгружается как NULL, а не обрывает выгрузку
+	var created int64
+	if t, err := time.Parse(time.RFC3339Nano, o.DateCreated); err == nil {
+		created = t.UnixMilli()
+	}
+	items := make([]parquetItem, 0, len(o.Items))
+	for _, it := range o.Items {
+		items = append(items, parquetItem{
+			ChrtID: int64(it.ChrtID), TrackNumber: it.TrackNumber, Price: int64(it.Price),
+			Rid: it.Rid, Name: it.Name, Sale: int64(it.Sale), Size: it.Size,
+			TotalPrice: int64(it.TotalPrice), NmID: int64(it.NmID), Brand: it.Brand,
+			Status: int64(it.Status),
+		})
+	}
+	d, p := o.Delivery, o.Payment
+	return parquetOrder{
+		OrderUID: o.OrderUID, TrackNumber: o.TrackNumber, Entry: o.Entry, Locale: o.Locale,
+		InternalSignature: o.InternalSignature, CustomerID: o.CustomerID,
+		DeliveryService: o.DeliveryService, Shardkey: o.Shardkey, SmID: int64(o.SmID),
+		DateCreated: created, OofShard: o.OofShard,
+		Delivery: parquetDelivery{
+			Name: d.Name, Phone: d.Phone, Zip: d.Zip, City: d.City,
+			Address: d.Address, Region: d.Region, Email: d.Email,
+		},
+		Payment: parquetPayment{
+			Transaction: p.Transaction, RequestID: p.RequestID, Currency: p.Currency,
+			Provider: p.Provider, Amount: int64(p.Amount), PaymentDT: p.PaymentDT, Bank: p.Bank,
+			DeliveryCost: int64(p.DeliveryCost), GoodsTotal: int64(p.GoodsTotal),
+			CustomFee: int64(p.CustomFee),
+		},
+		Items: items,
+	}
+}
+
+var (
+	_ Writer = (*ndjsonWriter)(nil)
+	_ Writer = (*csvWriter)(nil)
+	_ Writer = (*parquetWriter)(nil)
+)
@@ -0,0 +1,186 @@
+// Package health реализует проверки готовности сервиса и его зависимостей
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Статусы проверок
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Checker — проверка одной зависимости
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkerFunc) Name() string                    { return c.name }
+func (c checkerFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckerFunc оборачивает функцию в Checker
+func CheckerFunc(name string, fn func(ctx context.Context) error) Checker {
+	return checkerFunc{name: name, fn: fn}
+}
+
+// Result — результат одной проверки
+type Result struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report — сводный результат всех проверок
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK сообщает, что все проверки прошли
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type entry struct {
+	checker Checker
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu   sync.Mutex
+	last Result
+	at   time.Time
+}
+
+// run выполняет проверку или возвращает закешированный результат.
+// Параллельные запросы к одной проверке ждут друг друга и не нагружают зависимость
+func (e *entry) run(ctx context.Context) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ttl > 0 && !e.at.IsZero() && time.Since(e.at) < e.ttl {
+		return e.last
+	}
+
+	start := time.Now()
+	err := e.check(ctx)
+	res := Result{
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start.UTC(),
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	e.last, e.at = res, start
+	return res
+}
+
+// check запускает проверку с таймаутом. Если проверка игнорирует контекст,
+// результат всё равно будет получен не позже таймаута
+func (e *entry) check(ctx context.Context) error {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- e.checker.Check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+}
+
+// Registry хранит набор проверок
+type Registry struct {
+	mu      sync.RWMutex
+	entries []*entry
+}
+
+// NewRegistry создаёт пустой набор проверок
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register добавляет проверку с таймаутом и временем кеширования результата (0 — без кеша)
+func (r *Registry) Register(c Checker, timeout, cacheTTL time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, &entry{checker: c, timeout: timeout, ttl: cacheTTL})
+}
+
+// Check параллельно выполняет все проверки
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	entries := make([]*entry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = e.run(ctx)
+		}(i, e)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(entries))}
+	for i, e := range entries {
+		report.Checks[e.checker.Name()] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Flag — проверка-флаг для этапов запуска (миграции, прогрев кеша),
+// которую выставляет сам сервис
+type Flag struct {
+	name     string
+	notReady string
+	ready    atomic.Bool
+}
+
+// NewFlag создаёт флаг в состоянии "не готов" с указанным сообщением
+func NewFlag(name, notReadyMsg string) *Flag {
+	return &Flag{name: name, notReady: notReadyMsg}
+}
+
+// Set меняет состояние флага
+func (f *Flag) Set(ready bool) {
+	f.ready.Store(ready)
+}
+
+// Name реализует Checker
+func (f *Flag) Name() string {
+	return f.name
+}
+
+// Check реализует Checker
+func (f *Flag) Check(context.Context) error {
+	if f.ready.Load() {
+		return nil
+	}
+	return errors.New(f.notReady)
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	r := NewRegistry()
+	flag := NewFlag("cache", "cache warm-up in progress")
+	r.Register(flag, 0, 0)
+	r.Register(CheckerFunc("postgres", func(context.Context) error { return nil }), time.Second, 0)
+
+	report := r.Check(context.Background())
+	require.False(t, report.OK())
+	require.Equal(t, StatusFail, report.Checks["cache"].Status)
+	require.Equal(t, "cache warm-up in progress", report.Checks["cache"].Error)
+	require.Equal(t, StatusOK, report.Checks["postgres"].Status)
+
+	flag.Set(true)
+	require.True(t, r.Check(context.Background()).OK())
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry()
+	block := make(chan struct{})
+	defer close(block)
+	r.Register(CheckerFunc("kafka", func(context.Context) error {
+		<-block // проверка игнорирует контекст
+		return nil
+	}), 20*time.Millisecond, 0)
+
+	start := time.Now()
+	report := r.Check(context.Background())
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, StatusFail, report.Checks["kafka"].Status)
+	require.Contains(t, report.Checks["kafka"].Error, "timed out")
+}
+
+func TestRegistry_CachesResults(t *testing.T) {
+	r := NewRegistry()
+	var calls atomic.Int32
+	r.Register(CheckerFunc("postgres", func(context.Context) error {
+		calls.Add(1)
+		return errors.New("connection refused")
+	}), time.Second, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		report := r.Check(context.Background())
+		require.Equal(t, "connection refused", report.Checks["postgres"].Error)
+	}
+	require.Equal(t, int32(1), calls.Load())
+}
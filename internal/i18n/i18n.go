@@ -0,0 +1,188 @@
+// Package i18n содержит каталоги сообщений и форматирование сумм и дат
+// для веб-интерфейса с учётом языка и часового пояса
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // часовые пояса не зависят от tzdata в образе
+
+	"golang.org/x/text/language"
+
+	"WBtech_l0/internal/config"
+)
+
+//go:embed catalog/*.json
+var catalogFS embed.FS
+
+// Bundle хранит каталоги сообщений и выбирает язык для запроса
+type Bundle struct {
+	catalogs    map[string]map[string]string
+	langs       []string // языки в порядке тегов matcher'а
+	matcher     language.Matcher
+	defaultLang string
+	location    *time.Location
+}
+
+// New загружает встроенные каталоги сообщений и часовой пояс из настроек
+func New(cfg config.I18nConfig) (*Bundle, error) {
+	b := &Bundle{catalogs: make(map[string]map[string]string)}
+
+	entries, err := catalogFS.ReadDir("catalog")
+	if err != nil {
+		return nil, fmt.Errorf("read catalogs: %w", err)
+	}
+	for _, e := range entries {
+		data, err := catalogFS.ReadFile(path.Join("catalog", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read catalog %s: %w", e.Name(), err)
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("parse catalog %s: %w", e.Name(), err)
+		}
+		b.catalogs[strings.TrimSuffix(e.Name(), path.Ext(e.Name()))] = messages
+	}
+
+	b.defaultLang = strings.ToLower(cfg.DefaultLocale)
+	if _, ok := b.catalogs[b.defaultLang]; !ok {
+		return nil, fmt.Errorf("no message catalog for default locale %q", cfg.DefaultLocale)
+	}
+
+	// Язык по умолчанию идёт первым: matcher возвращает его, если ничего не подошло
+	b.langs = append(b.langs, b.defaultLang)
+	for lang := range b.catalogs {
+		if lang != b.defaultLang {
+			b.langs = append(b.langs, lang)
+		}
+	}
+	sort.Strings(b.langs[1:])
+	tags := make([]language.Tag, len(b.langs))
+	for i, lang := range b.langs {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", lang, err)
+		}
+		tags[i] = tag
+	}
+	b.matcher = language.NewMatcher(tags)
+
+	b.location, err = time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("load time zone %q: %w", cfg.TimeZone, err)
+	}
+	return b, nil
+}
+
+// Languages возвращает поддерживаемые языки, первым — язык по умолчанию
+func (b *Bundle) Languages() []string {
+	return append([]string(nil), b.langs...)
+}
+
+// Localizer возвращает локализатор для запроса. Поддерживаемый язык из
+// Accept-Language имеет приоритет над локалью заказа, иначе используется язык по умолчанию
+func (b *Bundle) Localizer(orderLocale, acceptLanguage string) *Localizer {
+	lang := b.negotiate(orderLocale, acceptLanguage)
+	return &Localizer{
+		lang:     lang,
+		messages: b.catalogs[lang],
+		fallback: b.catalogs[b.defaultLang],
+		location: b.location,
+	}
+}
+
+// negotiate выбирает язык из поддерживаемых
+func (b *Bundle) negotiate(orderLocale, acceptLanguage string) string {
+	if tags, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(tags) > 0 {
+		if _, i, conf := b.matcher.Match(tags...); conf != language.No {
+			return b.langs[i]
+		}
+	}
+	if tag, err := language.Parse(orderLocale); err == nil {
+		if _, i, conf := b.matcher.Match(tag); conf != language.No {
+			return b.langs[i]
+		}
+	}
+	return b.defaultLang
+}
+
+// Localizer переводит сообщения и форматирует значения для одного языка
+type Localizer struct {
+	lang     string
+	messages map[string]string
+	fallback map[string]string
+	location *time.Location
+}
+
+// Lang возвращает код выбранного языка (для атрибута lang страницы)
+func (l *Localizer) Lang() string {
+	return l.lang
+}
+
+// T возвращает сообщение по ключу. Если переданы аргументы, сообщение
+// используется как формат fmt. Неизвестный ключ возвращается как есть
+func (l *Localizer) T(key string, args ...interface{}) string {
+	msg, ok := l.messages[key]
+	if !ok {
+		if msg, ok = l.fallback[key]; !ok {
+			return key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Status возвращает текст статуса товара (пороги совпадают с getStatusClass в шаблоне)
+func (l *Localizer) Status(status int) string {
+	switch {
+	case status >= 200:
+		return l.T("status.delivered")
+	case status >= 100:
+		return l.T("status.pending")
+	default:
+		return l.T("status.cancelled")
+	}
+}
+
+// dateLayouts — форматы даты и времени для языков
+var dateLayouts = map[string]string{
+	"ru": "02.01.2006 15:04 MST",
+	"en": "Jan 2, 2006, 3:04 PM MST",
+}
+
+// Time форматирует момент времени в часовом поясе из настроек
+func (l *Localizer) Time(t time.Time) string {
+	layout, ok := dateLayouts[l.lang]
+	if !ok {
+		layout = time.DateTime + " MST"
+	}
+	return t.In(l.location).Format(layout)
+}
+
+// Date форматирует дату в формате RFC 3339 (date_created). Строка, которую
+// не удалось разобрать, возвращается без изменений
+func (l *Localizer) Date(value string) string {
+	if value == "" {
+		return l.T("common.not_specified")
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return l.Time(t)
+}
+
+// Unix форматирует Unix-время в секундах (payment_dt)
+func (l *Localizer) Unix(sec int64) string {
+	if sec == 0 {
+		return l.T("common.not_specified")
+	}
+	return l.Time(time.Unix(sec, 0))
+}
@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/config"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	b, err := New(config.I18nConfig{DefaultLocale: "ru", TimeZone: "Europe/Moscow"})
+	require.NoError(t, err)
+	return b
+}
+
+func TestCatalogs_SameKeys(t *testing.T) {
+	b := newTestBundle(t)
+	ru := b.catalogs["ru"]
+	for lang, messages := range b.catalogs {
+		for key := range ru {
+			require.Contains(t, messages, key, "catalog %s", lang)
+		}
+		require.Len(t, messages, len(ru), "catalog %s has extra keys", lang)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(config.I18nConfig{DefaultLocale: "de", TimeZone: "UTC"})
+	require.Error(t, err)
+	_, err = New(config.I18nConfig{DefaultLocale: "ru", TimeZone: "Mars/Olympus"})
+	require.Error(t, err)
+}
+
+func TestBundle_Localizer_Negotiation(t *testing.T) {
+	b := newTestBundle(t)
+	tests := []struct {
+		orderLocale, acceptLanguage, want string
+	}{
+		{"en", "", "en"},
+		{"ru", "", "ru"},
+		{"", "", "ru"},
+		{"de", "", "ru"},
+		{"ru", "en-US,en;q=0.9", "en"},
+		{"en", "ru-RU,ru;q=0.9,en;q=0.8", "ru"},
+		{"en", "de-DE", "en"},              // неподдерживаемый язык из заголовка не перекрывает заказ
+		{"ru", "de;q=1.0, en;q=0.5", "en"}, // из заголовка берётся первый поддерживаемый
+		{"en", "not a header;;", "en"},
+	}
+	for _, tt := range tests {
+		got := b.Localizer(tt.orderLocale, tt.acceptLanguage).Lang()
+		require.Equal(t, tt.want, got, "locale=%q accept=%q", tt.orderLocale, tt.acceptLanguage)
+	}
+}
+
+func TestLocalizer_T(t *testing.T) {
+	b := newTestBundle(t)
+	en := b.Localizer("en", "")
+	require.Equal(t, "Items (3)", en.T("items.title", 3))
+	require.Equal(t, "Processing", en.Status(150))
+	require.Equal(t, "unknown.key", en.T("unknown.key"))
+
+	ru := b.Localizer("ru", "")
+	require.Equal(t, "Доставлен", ru.Status(202))
+	require.Equal(t, "Отменен", ru.Status(0))
+}
+
+func TestLocalizer_Money(t *testing.T) {
+	b := newTestBundle(t)
+	ru, en := b.Localizer("ru", ""), b.Localizer("en", "")
+	tests := []struct {
+		l        *Localizer
+		amount   int
+		currency string
+		want     string
+	}{
+		{en, 1817, "USD", "$1,817.00"},
+		{ru, 1817, "USD", "1\u00a0817,00\u00a0$"},
+		{ru, 1234567, "RUB", "1\u00a0234\u00a0567,00\u00a0₽"},
+		{en, 1817, "JPY", "¥1,817"},
+		{en, 5, "KWD", "KWD\u00a05.000"},
+		{ru, 5, "kwd", "5,000\u00a0KWD"},
+		{en, 0, "rub", "₽0.00"},
+		{en, -42, "EUR", "-€42.00"},
+		{ru, 100, "", "100,00"},
+		{en, 100, "XYZ", "XYZ\u00a0100.00"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, tt.l.Money(tt.amount, tt.currency), "%s %d %s", tt.l.Lang(), tt.amount, tt.currency)
+	}
+}
+
+func TestLocalizer_Dates(t *testing.T) {
+	b := newTestBundle(t)
+	ru, en := b.Localizer("ru", ""), b.Localizer("en", "")
+
+	require.Equal(t, "21.11.2021 09:22 MSK", ru.Date("2021-11-21T06:22:19Z"))
+	require.Equal(t, "Nov 21, 2021, 9:22 AM MSK", en.Date("2021-11-21T06:22:19Z"))
+	require.Equal(t, "Не указана", ru.Date(""))
+	require.Equal(t, "yesterday", en.Date("yesterday"))
+
+	require.Equal(t, "01.01.1970 03:00 MSK", ru.Unix(1))
+	require.Equal(t, "Not specified", en.Unix(0))
+}
@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+// currencySymbols — символы распространённых валют. Для остальных выводится код ISO 4217
+var currencySymbols = map[string]string{
+	"RUB": "₽",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"KZT": "₸",
+	"BYN": "Br",
+	"UAH": "₴",
+	"KGS": "сом",
+	"AMD": "֏",
+	"GEL": "₾",
+	"TRY": "₺",
+	"INR": "₹",
+	"KRW": "₩",
+}
+
+// numberFormat — разделители групп разрядов и дробной части для языка.
+// Пробелы неразрывные, чтобы сумма не переносилась на странице
+type numberFormat struct {
+	group, decimal string
+	symbolFirst    bool // символ валюты перед суммой ($1,817.00), иначе после (1 817,00 ₽)
+}
+
+var numberFormats = map[string]numberFormat{
+	"ru": {group: "\u00a0", decimal: ","},
+	"en": {group: ",", decimal: ".", symbolFirst: true},
+}
+
+// Money форматирует сумму в целых единицах валюты (так суммы приходят в заказе)
+// с числом знаков после запятой по ISO 4217: 1 817,00 $ для ru, $1,817.00 для en,
+// ¥1,817 для иены. Для неизвестного кода валюты используются два знака и сам код
+func (l *Localizer) Money(amount int, code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	scale := 2
+	if unit, err := currency.ParseISO(code); err == nil {
+		scale, _ = currency.Standard.Rounding(unit)
+	}
+
+	nf, ok := numberFormats[l.lang]
+	if !ok {
+		nf = numberFormats["en"]
+	}
+
+	num := groupDigits(strconv.Itoa(abs(amount)), nf.group)
+	if scale > 0 {
+		num += nf.decimal + strings.Repeat("0", scale)
+	}
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+
+	symbol, ok := currencySymbols[code]
+	switch {
+	case code == "":
+		return sign + num
+	case nf.symbolFirst && ok:
+		return sign + symbol + num
+	case nf.symbolFirst:
+		return sign + code + "\u00a0" + num
+	case !ok:
+		symbol = code
+	}
+	return sign + num + "\u00a0" + symbol
+}
+
+// groupDigits разбивает строку цифр на группы по три
+func groupDigits(digits, sep string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
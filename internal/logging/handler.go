@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/pii"
+)
+
+// Ключи атрибутов, общие для всех логов сервиса
+const (
+	KeyOrderUID  = "order_uid"
+	KeyTopic     = "topic"
+	KeyPartition = "partition"
+	KeyOffset    = "offset"
+	KeyError     = "error"
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+)
+
+// Err возвращает атрибут ошибки. Текст ошибки проходит через pii.Scrub
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// OrderUID возвращает атрибут идентификатора заказа
+func OrderUID(uid string) slog.Attr {
+	return slog.String(KeyOrderUID, uid)
+}
+
+// Fatal пишет запись уровня error и завершает процесс с кодом 1
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Setup делает логгер с настройками cfg логгером slog по умолчанию. После вызова
+// сообщения пакета log тоже проходят через него (уровнем info)
+func Setup(cfg config.LogConfig) error {
+	if err := SetLevel(cfg.Level); err != nil {
+		return err
+	}
+	slog.SetDefault(New(os.Stderr, cfg.Format))
+	return nil
+}
+
+// New создаёт логгер в формате text или json с общим уровнем (SetLevel),
+// идентификаторами запроса и трейса из контекста и маскированием персональных данных
+func New(w io.Writer, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var h slog.Handler
+	if format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// contextHandler добавляет к записи request_id, trace_id и span_id из контекста,
+// если вызывающий не указал их сам
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	var hasRequestID, hasTraceID bool
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case KeyRequestID:
+			hasRequestID = true
+		case KeyTraceID:
+			hasTraceID = true
+		}
+		return true
+	})
+	if id := RequestIDFromContext(ctx); id != "" && !hasRequestID {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !hasTraceID {
+		r.AddAttrs(slog.String(KeyTraceID, sc.TraceID().String()), slog.String(KeySpanID, sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// piiKeys — атрибуты с персональными данными и их маски. Ключ name маскируется
+// только внутри группы delivery, чтобы не задеть имена проверок, ключей и т. п.
+var piiKeys = map[string]func(string) string{
+	"phone":         pii.MaskPhone,
+	"email":         pii.MaskEmail,
+	"address":       pii.MaskAddress,
+	"customer_name": pii.MaskName,
+}
+
+// redactAttr маскирует персональные данные: известные ключи, заказы и тексты ошибок
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindString {
+		mask, ok := piiKeys[a.Key]
+		if !ok && a.Key == "name" && len(groups) > 0 && groups[len(groups)-1] == "delivery" {
+			mask, ok = pii.MaskName, true
+		}
+		if ok {
+			return slog.String(a.Key, mask(a.Value.String()))
+		}
+		return a
+	}
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	switch v := a.Value.Any().(type) {
+	case error:
+		return slog.String(a.Key, pii.Scrub(v.Error()))
+	case domain.Order:
+		return slog.Any(a.Key, pii.RedactOrder(v))
+	case domain.Delivery:
+		return slog.Any(a.Key, pii.RedactDelivery(v))
+	}
+	return a
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"WBtech_l0/internal/domain"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestNew_ContextIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "json")
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(WithRequestID(context.Background(), "req-1"), sc)
+
+	logger.InfoContext(ctx, "with context", OrderUID("b563feb7b2b84b6test"))
+	logger.InfoContext(ctx, "explicit", slog.String(KeyRequestID, "req-2"), slog.String(KeyTraceID, "custom"))
+	logger.Info("without context")
+
+	entries := decodeLines(t, &buf)
+	require.Len(t, entries, 3)
+	require.Equal(t, "req-1", entries[0][KeyRequestID])
+	require.Equal(t, sc.TraceID().String(), entries[0][KeyTraceID])
+	require.Equal(t, sc.SpanID().String(), entries[0][KeySpanID])
+	require.Equal(t, "b563feb7b2b84b6test", entries[0][KeyOrderUID])
+
+	// заданные явно значения не дублируются и не перезаписываются
+	require.Equal(t, "req-2", entries[1][KeyRequestID])
+	require.Equal(t, "custom", entries[1][KeyTraceID])
+	require.NotContains(t, entries[1], KeySpanID)
+
+	require.NotContains(t, entries[2], KeyRequestID)
+	require.NotContains(t, entries[2], KeyTraceID)
+}
+
+func TestNew_RedactsPII(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "json")
+
+	order := domain.Order{OrderUID: "o1", Delivery: domain.Delivery{Name: "Test Testov", Phone: "+79001234567", Email: "test@gmail.com", City: "Moscow"}}
+	logger.Info("pii",
+		slog.String("phone", "+79001234567"),
+		slog.String("email", "test@gmail.com"),
+		slog.Group("delivery", slog.String("name", "Test Testov")),
+		slog.String("name", "orders-reader"),
+		Err(errors.New("duplicate key for test@gmail.com")),
+		slog.Any("order", order),
+	)
+
+	out := buf.String()
+	require.NotContains(t, out, "+79001234567")
+	require.NotContains(t, out, "test@gmail.com")
+	require.NotContains(t, out, "Test Testov")
+
+	e := decodeLines(t, &buf)[0]
+	require.Equal(t, "+7******4567", e["phone"])
+	require.Equal(t, "t***@gmail.com", e["email"])
+	require.Equal(t, "T*** T*****", e["delivery"].(map[string]any)["name"])
+	require.Equal(t, "orders-reader", e["name"])
+	require.Equal(t, "duplicate key for t***@gmail.com", e[KeyError])
+	delivery := e["order"].(map[string]any)["delivery"].(map[string]any)
+	require.Equal(t, "Moscow", delivery["city"])
+	require.Equal(t, "+7******4567", delivery["phone"])
+}
+
+func TestSetLevel(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetLevel("info")) })
+
+	var buf bytes.Buffer
+	logger := New(&buf, "text")
+	require.NoError(t, SetLevel("warn"))
+	logger.Info("hidden")
+	logger.Warn("shown")
+	require.NotContains(t, buf.String(), "hidden")
+	require.Contains(t, buf.String(), "level=WARN msg=shown")
+
+	// уровень общий для всех логгеров и меняется на лету
+	require.NoError(t, SetLevel("debug"))
+	logger.Debug("now visible")
+	require.Contains(t, buf.String(), "now visible")
+	require.Equal(t, slog.LevelDebug, Level())
+
+	require.Error(t, SetLevel("verbose"))
+}
@@ -0,0 +1,77 @@
+// Package logging содержит общие средства структурного логирования сервиса
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+
+	"WBtech_l0/internal/config"
+)
+
+// level — уровень логгеров New. Меняется на лету при перечитывании конфига
+var level = new(slog.LevelVar)
+
+// SetLevel задаёт минимальный уровень записей: debug, info, warn или error
+func SetLevel(name string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", name, err)
+	}
+	level.Set(l)
+	return nil
+}
+
+// ReloadLevel применяет уровень из перечитанного конфига, только если log.level изменился.
+// Иначе перечитывание других ключей сбрасывало бы уровень, заданный через PUT /admin/log-level
+func ReloadLevel(prev, next config.LogConfig) error {
+	if prev.Level == next.Level {
+		return nil
+	}
+	return SetLevel(next.Level)
+}
+
+// Level возвращает текущий уровень логирования
+func Level() slog.Level {
+	return level.Level()
+}
+
+type requestIDKey struct{}
+
+// WithRequestID возвращает контекст с идентификатором запроса
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса или пустую строку
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID генерирует случайный идентификатор запроса (32 hex-символа)
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read не возвращает ошибок
+	return hex.EncodeToString(b[:])
+}
+
+// MaxRequestIDLen — максимальная длина принимаемого от клиента идентификатора запроса
+const MaxRequestIDLen = 128
+
+// ValidRequestID принимает только короткие идентификаторы из безопасных символов,
+// чтобы клиент не мог внедрить в логи переводы строк или мегабайтные значения
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') &&
+			c != '-' && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
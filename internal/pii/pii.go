@@ -0,0 +1,160 @@
+// Package pii содержит правила маскирования персональных данных покупателей
+// для ответов API, логов, трейсов и DLQ
+package pii
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"WBtech_l0/internal/domain"
+)
+
+const mask = '*'
+
+// MaskPhone оставляет код страны и последние 4 цифры: +79001234567 -> +7******4567
+func MaskPhone(phone string) string {
+	runes := []rune(phone)
+	if len(runes) == 0 {
+		return phone
+	}
+	prefix := 0
+	if runes[0] == '+' {
+		prefix = 2
+	}
+	const suffix = 4
+	if len(runes) <= prefix+suffix {
+		return strings.Repeat(string(mask), len(runes))
+	}
+	for i := prefix; i < len(runes)-suffix; i++ {
+		runes[i] = mask
+	}
+	return string(runes)
+}
+
+// MaskEmail оставляет первую букву имени и домен: test@example.com -> t***@example.com
+func MaskEmail(email string) string {
+	local, domainPart, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return maskAll(email)
+	}
+	first, _ := utf8.DecodeRuneInString(local)
+	return string(first) + "***@" + domainPart
+}
+
+// MaskName оставляет первые буквы слов: Test Testov -> T*** T*****
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, w := range words {
+		runes := []rune(w)
+		for j := 1; j < len(runes); j++ {
+			runes[j] = mask
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskAddress полностью скрывает адрес фиксированной маской ***, не раскрывая даже его длину
+func MaskAddress(address string) string {
+	return maskAll(address)
+}
+
+func maskAll(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.Repeat(string(mask), 3)
+}
+
+// RedactDelivery маскирует персональные поля доставки. Город, регион и индекс
+// не считаются персональными данными и остаются как есть
+func RedactDelivery(d domain.Delivery) domain.Delivery {
+	d.Name = MaskName(d.Name)
+	d.Phone = MaskPhone(d.Phone)
+	d.Address = MaskAddress(d.Address)
+	d.Email = MaskEmail(d.Email)
+	return d
+}
+
+// RedactOrder возвращает копию заказа с замаскированными персональными данными
+func RedactOrder(o domain.Order) domain.Order {
+	o.Delivery = RedactDelivery(o.Delivery)
+	return o
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[\p{L}0-9._%+\-]+@[\p{L}0-9.\-]+\.[\p{L}]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\-\s()]{8,}\d`)
+	datePattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+)
+
+// minPhoneDigits — столько цифр нужно номеру без «+», чтобы его не путать с датами и числами
+const minPhoneDigits = 10
+
+// Scrub маскирует email и телефоны внутри произвольного текста
+// (сообщения об ошибках, детали DLQ, атрибуты спанов)
+func Scrub(text string) string {
+	return scrubPhones(emailPattern.ReplaceAllStringFunc(text, MaskEmail))
+}
+
+// scrubPhones маскирует похожие на телефон последовательности цифр: с «+» или не короче
+// minPhoneDigits цифр. Даты вида 2021-11-26 и время после них (2021-11-26 06:22) не трогает
+func scrubPhones(text string) string {
+	return phonePattern.ReplaceAllStringFunc(text, func(s string) string {
+		if date := datePattern.FindString(s); date != "" {
+			return date + scrubPhones(s[len(date):])
+		}
+		digits := strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) || r == '+' {
+				return r
+			}
+			return -1
+		}, s)
+		if !strings.HasPrefix(digits, "+") && len(digits) < minPhoneDigits {
+			return s
+		}
+		return MaskPhone(digits)
+	})
+}
+
+// deliveryFields — ключи объекта delivery, которые маскируются в сыром JSON
+var deliveryFields = map[string]func(string) string{
+	"name":    MaskName,
+	"phone":   MaskPhone,
+	"address": MaskAddress,
+	"email":   MaskEmail,
+}
+
+// RedactJSON маскирует персональные данные в сыром JSON заказа, не требуя, чтобы
+// сообщение было валидным заказом. Если это вообще не JSON-объект, ok = false
+func RedactJSON(raw []byte) (redacted []byte, ok bool) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, false
+	}
+
+	if rawDelivery, found := doc["delivery"]; found {
+		var delivery map[string]interface{}
+		if err := json.Unmarshal(rawDelivery, &delivery); err == nil {
+			for key, maskFn := range deliveryFields {
+				if v, isString := delivery[key].(string); isString {
+					delivery[key] = maskFn(v)
+				}
+			}
+			if data, err := json.Marshal(delivery); err == nil {
+				doc["delivery"] = data
+			}
+		} else {
+			delete(doc, "delivery")
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
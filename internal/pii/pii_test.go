@@ -0,0 +1,76 @@
+package pii
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/domain"
+)
+
+func TestMaskFunctions(t *testing.T) {
+	require.Equal(t, "+7******1234", MaskPhone("+79001231234"))
+	require.Equal(t, "******0000", MaskPhone("9720000000"))
+	require.Equal(t, "***", MaskPhone("123"))
+	require.Equal(t, "t***@example.com", MaskEmail("test@example.com"))
+	require.Equal(t, "Т*** П***", MaskName("Тест Петр"))
+	require.Equal(t, "***", MaskAddress("Ploshad Mira 15"))
+	require.Equal(t, "", MaskEmail(""))
+}
+
+func TestRedactOrder(t *testing.T) {
+	order := domain.Order{
+		OrderUID: "uid",
+		Delivery: domain.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Email: "test@gmail.com",
+		},
+	}
+	redacted := RedactOrder(order)
+
+	require.Equal(t, "T*** T*****", redacted.Delivery.Name)
+	require.Equal(t, "+9*****0000", redacted.Delivery.Phone)
+	require.Equal(t, "Kiryat Mozkin", redacted.Delivery.City)
+	require.Equal(t, "t***@gmail.com", redacted.Delivery.Email)
+	// исходный заказ не изменился
+	require.Equal(t, "Test Testov", order.Delivery.Name)
+}
+
+func TestScrub(t *testing.T) {
+	got := Scrub("customer test@example.com called from +7 900 123-12-34")
+	require.NotContains(t, got, "test@example.com")
+	require.NotContains(t, got, "123-12-34")
+	require.Contains(t, got, "t***@example.com")
+
+	// даты, время и короткие числа — не телефоны
+	for _, text := range []string{
+		"date_created 2021-11-26T06:22:19Z",
+		"order created at 2021-11-26 06:22",
+		"retry 3 of 5, offset 123456789",
+	} {
+		require.Equal(t, text, Scrub(text))
+	}
+	require.Equal(t, "at 2021-11-26 *******4567", Scrub("at 2021-11-26 89001234567"))
+	require.Equal(t, "call ******1234", Scrub("call 900 123-12-34"))
+}
+
+func TestRedactJSON(t *testing.T) {
+	raw := []byte(`{"order_uid":"x","delivery":{"name":"Test Testov","phone":"+79001234567","city":"Moscow"}}`)
+	out, ok := RedactJSON(raw)
+	require.True(t, ok)
+	require.False(t, strings.Contains(string(out), "Testov"))
+
+	var doc struct {
+		OrderUID string          `json:"order_uid"`
+		Delivery domain.Delivery `json:"delivery"`
+	}
+	require.NoError(t, json.Unmarshal(out, &doc))
+	require.Equal(t, "x", doc.OrderUID)
+	require.Equal(t, "+7******4567", doc.Delivery.Phone)
+	require.Equal(t, "Moscow", doc.Delivery.City)
+
+	_, ok = RedactJSON([]byte("not json"))
+	require.False(t, ok)
+}
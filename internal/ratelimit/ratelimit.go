@@ -0,0 +1,179 @@
+// Package ratelimit реализует ограничение частоты запросов по алгоритму token bucket
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"WBtech_l0/internal/config"
+)
+
+// Limit — параметры token bucket: скорость пополнения и ёмкость корзины
+type Limit struct {
+	Rate  float64 // токенов в секунду; <= 0 означает отсутствие ограничения
+	Burst int     // максимальное количество токенов
+}
+
+// Unlimited сообщает, что лимит не задан
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Result — решение по одному запросу
+type Result struct {
+	Allowed    bool
+	Limit      int           // ёмкость корзины
+	Remaining  int           // оставшиеся токены после запроса
+	RetryAfter time.Duration // через сколько появится следующий токен (если запрос отклонён)
+}
+
+// Backend хранит состояние корзин. Реализация в памяти подходит для одного
+// экземпляра сервиса; для нескольких реплик можно подключить общее хранилище
+type Backend interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter сопоставляет маршрутам лимиты и делегирует подсчёт в Backend
+type Limiter struct {
+	backend Backend
+
+	mu     sync.RWMutex
+	def    Limit
+	routes map[string]Limit
+}
+
+// NewLimiter создаёт Limiter с лимитами из конфига
+func NewLimiter(backend Backend, cfg config.RateLimitConfig) *Limiter {
+	l := &Limiter{backend: backend}
+	l.SetLimits(cfg)
+	return l
+}
+
+// SetLimits заменяет лимиты. Состояние корзин в Backend сохраняется
+func (l *Limiter) SetLimits(cfg config.RateLimitConfig) {
+	routes := make(map[string]Limit, len(cfg.Routes))
+	for _, r := range cfg.Routes {
+		routes[r.Route] = Limit{Rate: r.RPS, Burst: r.Burst}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.def = Limit{Rate: cfg.Default.RPS, Burst: cfg.Default.Burst}
+	l.routes = routes
+}
+
+// LimitFor возвращает лимит маршрута или лимит по умолчанию
+func (l *Limiter) LimitFor(route string) Limit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if limit, ok := l.routes[route]; ok {
+		return limit
+	}
+	return l.def
+}
+
+// Allow проверяет, можно ли клиенту выполнить запрос к маршруту
+func (l *Limiter) Allow(ctx context.Context, route, client string) (Result, error) {
+	limit := l.LimitFor(route)
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+	return l.backend.Allow(ctx, route+"|"+client, limit)
+}
+
+// Close освобождает ресурсы Backend, если он их держит (например, io.Closer)
+func (l *Limiter) Close() error {
+	if c, ok := l.backend.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// bucket — состояние одной корзины
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryBackend хранит корзины в памяти процесса
+type MemoryBackend struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryBackend создаёт backend в памяти. Корзины, которые не использовались
+// дольше idleTTL, периодически удаляются фоновой горутиной до вызова Close
+func NewMemoryBackend(idleTTL time.Duration) *MemoryBackend {
+	b := &MemoryBackend{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+	if idleTTL > 0 {
+		go b.cleanup(idleTTL)
+	}
+	return b
+}
+
+// Allow списывает токен из корзины key, предварительно пополнив её
+func (b *MemoryBackend) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	bk, ok := b.buckets[key]
+	if !ok {
+		bk = &bucket{tokens: float64(limit.Burst), last: now}
+		b.buckets[key] = bk
+	}
+
+	elapsed := now.Sub(bk.last).Seconds()
+	bk.tokens = math.Min(float64(limit.Burst), bk.tokens+elapsed*limit.Rate)
+	bk.last = now
+
+	res := Result{Limit: limit.Burst}
+	if bk.tokens >= 1 {
+		bk.tokens--
+		res.Allowed = true
+		res.Remaining = int(bk.tokens)
+		return res, nil
+	}
+
+	missing := 1 - bk.tokens
+	res.RetryAfter = time.Duration(missing / limit.Rate * float64(time.Second))
+	return res, nil
+}
+
+// Close останавливает очистку корзин. Повторный вызов ничего не делает
+func (b *MemoryBackend) Close() error {
+	b.closeOnce.Do(func() { close(b.stop) })
+	return nil
+}
+
+// cleanup удаляет давно неиспользуемые корзины, чтобы карта не росла бесконечно
+func (b *MemoryBackend) cleanup(idleTTL time.Duration) {
+	ticker := time.NewTicker(idleTTL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+		b.mu.Lock()
+		now := b.now()
+		for key, bk := range b.buckets {
+			if now.Sub(bk.last) > idleTTL {
+				delete(b.buckets, key)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/config"
+)
+
+func TestMemoryBackend_TokenBucket(t *testing.T) {
+	b := NewMemoryBackend(0)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, err := b.Allow(ctx, "client", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed, "request %d should pass", i)
+		require.Equal(t, 2-i, res.Remaining)
+	}
+
+	res, err := b.Allow(ctx, "client", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// другой клиент имеет свою корзину
+	res, err = b.Allow(ctx, "other", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	// через полсекунды появляется один токен
+	now = now.Add(500 * time.Millisecond)
+	res, err = b.Allow(ctx, "client", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+}
+
+func TestLimiter_RouteLimits(t *testing.T) {
+	l := NewLimiter(NewMemoryBackend(0), config.RateLimitConfig{
+		Default: config.RateLimitRule{RPS: 100, Burst: 100},
+		Routes: []config.RateLimitRule{
+			{Route: "/api/order/", RPS: 1, Burst: 1},
+			{Route: "/api/health", RPS: 0},
+		},
+	})
+	ctx := context.Background()
+
+	require.Equal(t, Limit{Rate: 1, Burst: 1}, l.LimitFor("/api/order/"))
+	require.Equal(t, Limit{Rate: 100, Burst: 100}, l.LimitFor("/order/"))
+
+	res, _ := l.Allow(ctx, "/api/order/", "ip:1.2.3.4")
+	require.True(t, res.Allowed)
+	res, _ = l.Allow(ctx, "/api/order/", "ip:1.2.3.4")
+	require.False(t, res.Allowed)
+
+	for i := 0; i < 10; i++ {
+		res, _ = l.Allow(ctx, "/api/health", "ip:1.2.3.4")
+		require.True(t, res.Allowed)
+	}
+}
+
+func TestMemoryBackend_Close(t *testing.T) {
+	b := NewMemoryBackend(time.Millisecond)
+	l := NewLimiter(b, config.RateLimitConfig{})
+	require.NoError(t, l.Close())
+	// повторный вызов безопасен
+	require.NoError(t, b.Close())
+	select {
+	case <-b.stop:
+	default:
+		t.Fatal("cleanup must be stopped")
+	}
+}
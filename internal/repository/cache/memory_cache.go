@@ -2,9 +2,14 @@
 package cache
 
 import (
+	"path"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"WBtech_l0/internal/domain" // импортируем domain для использования domain.CacheStats
 )
 
@@ -15,6 +20,10 @@ type OrderCache struct {
 	defaultTTL time.Duration
 	maxSize    int
 	stats      domain.CacheStats // используем domain.CacheStats
+	totals     totals            // для метрик: в отличие от stats не сбрасываются Clear
+
+	opDuration *prometheus.HistogramVec
+	warmup     warmup
 }
 
 // Item — элемент кеша с TTL
@@ -29,6 +38,7 @@ func NewOrderCache(defaultTTL time.Duration, maxSize int) *OrderCache {
 		items:      make(map[string]Item),
 		defaultTTL: defaultTTL,
 		maxSize:    maxSize,
+		opDuration: newOpDuration(),
 	}
 	go c.cleanupExpired()
 	return c
@@ -36,10 +46,22 @@ func NewOrderCache(defaultTTL time.Duration, maxSize int) *OrderCache {
 
 // SetWithTTL добавляет заказ с указанным временем жизни
 func (c *OrderCache) SetWithTTL(order domain.Order, ttl time.Duration) {
+	defer c.observe(opSet, time.Now())
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.set(order, ttl)
+}
 
-	if len(c.items) >= c.maxSize {
+// Set добавляет заказ с TTL по умолчанию
+func (c *OrderCache) Set(order domain.Order) {
+	defer c.observe(opSet, time.Now())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(order, c.defaultTTL)
+}
+
+func (c *OrderCache) set(order domain.Order, ttl time.Duration) {
+	if _, exists := c.items[order.OrderUID]; !exists && len(c.items) >= c.maxSize {
 		c.evictOldest()
 	}
 
@@ -50,13 +72,23 @@ func (c *OrderCache) SetWithTTL(order domain.Order, ttl time.Duration) {
 	c.stats.Size = len(c.items)
 }
 
-// Set добавляет заказ с TTL по умолчанию
-func (c *OrderCache) Set(order domain.Order) {
-	c.SetWithTTL(order, c.defaultTTL)
+// SetLimits меняет TTL по умолчанию и максимальный размер без сброса кеша.
+// Новый TTL действует для следующих записей; при уменьшении размера лишние
+// записи вытесняются сразу, начиная с ближайших к истечению
+func (c *OrderCache) SetLimits(defaultTTL time.Duration, maxSize int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultTTL = defaultTTL
+	c.maxSize = maxSize
+	for len(c.items) > c.maxSize {
+		c.evictOldest()
+	}
+	c.stats.Size = len(c.items)
 }
 
 // Get возвращает заказ из кеша
 func (c *OrderCache) Get(orderUID string) (domain.Order, bool) {
+	defer c.observe(opGet, time.Now())
 	c.mu.RLock()
 	item, exists := c.items[orderUID]
 	c.mu.RUnlock()
@@ -64,20 +96,28 @@ func (c *OrderCache) Get(orderUID string) (domain.Order, bool) {
 	if !exists {
 		c.mu.Lock()
 		c.stats.Misses++
+		c.totals.misses++
 		c.mu.Unlock()
 		return domain.Order{}, false
 	}
 
 	if time.Now().After(item.ExpiresAt) {
-		c.Delete(orderUID)
 		c.mu.Lock()
+		// запись могли обновить, пока блокировка была снята
+		if current, ok := c.items[orderUID]; ok && time.Now().After(current.ExpiresAt) {
+			delete(c.items, orderUID)
+			c.stats.Size = len(c.items)
+			c.totals.expirations++
+		}
 		c.stats.Misses++
+		c.totals.misses++
 		c.mu.Unlock()
 		return domain.Order{}, false
 	}
 
 	c.mu.Lock()
 	c.stats.Hits++
+	c.totals.hits++
 	c.mu.Unlock()
 
 	return item.Order, true
@@ -100,14 +140,12 @@ func (c *OrderCache) GetAll() map[string]domain.Order {
 
 // Delete удаляет заказ
 func (c *OrderCache) Delete(orderUID string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.items, orderUID)
-	c.stats.Size = len(c.items)
+	c.Remove(orderUID)
 }
 
-// Clear очищает кеш
+// Clear очищает кеш и сбрасывает статистику GetStats. Счётчики метрик не сбрасываются
 func (c *OrderCache) Clear() {
+	defer c.observe(opClear, time.Now())
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.items = make(map[string]Item)
@@ -133,6 +171,7 @@ func (c *OrderCache) cleanupExpired() {
 		for uid, item := range c.items {
 			if now.After(item.ExpiresAt) {
 				delete(c.items, uid)
+				c.totals.expirations++
 			}
 		}
 		c.stats.Size = len(c.items)
@@ -152,5 +191,85 @@ func (c *OrderCache) evictOldest() {
 	}
 	if oldestUID != "" {
 		delete(c.items, oldestUID)
+		c.totals.evictions++
+	}
+}
+
+// Entry — запись кеша без данных заказа, для административного API
+type Entry struct {
+	OrderUID  string
+	ExpiresAt time.Time
+}
+
+// Entries возвращает неистекшие записи в порядке order_uid. Если pattern не пуст,
+// отбираются только order_uid, подходящие под шаблон path.Match (например, "test-*")
+func (c *OrderCache) Entries(pattern string) ([]Entry, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	entries := make([]Entry, 0, len(c.items))
+	for uid, item := range c.items {
+		if now.After(item.ExpiresAt) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, uid); pattern != "" && !ok {
+			continue
+		}
+		entries = append(entries, Entry{OrderUID: uid, ExpiresAt: item.ExpiresAt})
 	}
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.OrderUID, b.OrderUID) })
+	return entries, nil
+}
+
+// Peek возвращает запись вместе со сроком жизни, не меняя статистику попаданий
+func (c *OrderCache) Peek(orderUID string) (Item, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	item, ok := c.items[orderUID]
+	if !ok || time.Now().After(item.ExpiresAt) {
+		return Item{}, false
+	}
+	return item, true
+}
+
+// Remove удаляет заказ и сообщает, был ли он в кеше
+func (c *OrderCache) Remove(orderUID string) bool {
+	defer c.observe(opDelete, time.Now())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[orderUID]
+	delete(c.items, orderUID)
+	c.stats.Size = len(c.items)
+	return ok
+}
+
+// RemoveMatching удаляет заказы, order_uid которых подходит под шаблон path.Match,
+// и возвращает их число
+func (c *OrderCache) RemoveMatching(pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+	defer c.observe(opDelete, time.Now())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int
+	for uid := range c.items {
+		if ok, _ := path.Match(pattern, uid); ok {
+			delete(c.items, uid)
+			n++
+		}
+	}
+	c.stats.Size = len(c.items)
+	return n, nil
+}
+
+// Limits возвращает TTL по умолчанию и максимальный размер
+func (c *OrderCache) Limits() (time.Duration, int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.defaultTTL, c.maxSize
 }
@@ -1,9 +1,13 @@
 package cache
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"WBtech_l0/internal/domain"
 )
 
@@ -110,3 +114,125 @@ func TestOrderCache_Clear(t *testing.T) {
 		t.Errorf("stats not reset: %+v", stats)
 	}
 }
+
+func TestOrderCache_SetLimits(t *testing.T) {
+	cache := NewOrderCache(10*time.Minute, 3)
+	for _, uid := range []string{"1", "2", "3"} {
+		cache.Set(domain.Order{OrderUID: uid})
+		time.Sleep(1 * time.Millisecond)
+	}
+
+	cache.SetLimits(time.Millisecond, 2)
+
+	// лишняя запись вытесняется сразу, остальные сохраняют прежний TTL
+	if _, found := cache.Get("1"); found {
+		t.Error("expected order1 to be evicted after shrinking")
+	}
+	if size := cache.GetStats().Size; size != 2 {
+		t.Errorf("expected size 2, got %d", size)
+	}
+
+	// новый TTL действует для следующих записей
+	cache.Set(domain.Order{OrderUID: "4"})
+	time.Sleep(2 * time.Millisecond)
+	if _, found := cache.Get("4"); found {
+		t.Error("expected order4 to expire with the new TTL")
+	}
+	if _, found := cache.Get("3"); !found {
+		t.Error("expected order3 to keep its TTL")
+	}
+}
+
+func TestOrderCache_UpdateDoesNotEvict(t *testing.T) {
+	cache := NewOrderCache(10*time.Minute, 2)
+	cache.Set(domain.Order{OrderUID: "1"})
+	cache.Set(domain.Order{OrderUID: "2"})
+	cache.Set(domain.Order{OrderUID: "2", TrackNumber: "T"})
+
+	if _, found := cache.Get("1"); !found {
+		t.Error("expected order1 to stay when order2 is updated")
+	}
+}
+
+func TestOrderCache_Collector(t *testing.T) {
+	cache := NewOrderCache(time.Millisecond, 1)
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(cache.Collector()); err != nil {
+		t.Fatalf("register collector: %v", err)
+	}
+
+	cache.Set(domain.Order{OrderUID: "1"})
+	cache.Set(domain.Order{OrderUID: "2"}) // вытесняет 1
+	cache.Get("2")
+	cache.Get("1")
+	time.Sleep(2 * time.Millisecond)
+	cache.Get("2") // истёк
+	cache.Clear()
+
+	// Clear обнуляет GetStats, но не счётчики Prometheus
+	expected := `
+# HELP cache_evictions_total Orders evicted because the cache was full
+# TYPE cache_evictions_total counter
+cache_evictions_total 1
+# HELP cache_expirations_total Orders removed after their TTL expired
+# TYPE cache_expirations_total counter
+cache_expirations_total 1
+# HELP cache_hit_ratio Share of cache lookups that were hits since start
+# TYPE cache_hit_ratio gauge
+cache_hit_ratio 0.3333333333333333
+# HELP cache_hits_total Order cache hits
+# TYPE cache_hits_total counter
+cache_hits_total 1
+# HELP cache_misses_total Order cache misses, including expired entries
+# TYPE cache_misses_total counter
+cache_misses_total 2
+# HELP cache_size Number of orders in the cache
+# TYPE cache_size gauge
+cache_size 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"cache_evictions_total", "cache_expirations_total", "cache_hit_ratio", "cache_hits_total",
+		"cache_misses_total", "cache_size"); err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(cache.opDuration); n != 3 {
+		t.Errorf("expected latency series for get, set and clear, got %d", n)
+	}
+}
+
+func TestOrderCache_Warmup(t *testing.T) {
+	cache := NewOrderCache(time.Minute, 10)
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(cache.Collector()); err != nil {
+		t.Fatalf("register collector: %v", err)
+	}
+	gauge := func(name string) float64 {
+		t.Helper()
+		families, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("gather: %v", err)
+		}
+		for _, f := range families {
+			if f.GetName() == name {
+				return f.GetMetric()[0].GetGauge().GetValue()
+			}
+		}
+		t.Fatalf("metric %s not found", name)
+		return 0
+	}
+
+	cache.BeginWarmup(3)
+	cache.WarmupProgress(2)
+	if gauge("cache_warmup_in_progress") != 1 || gauge("cache_warmup_orders") != 3 || gauge("cache_warmup_loaded_orders") != 2 {
+		t.Error("unexpected warm-up progress")
+	}
+
+	cache.EndWarmup()
+	if gauge("cache_warmup_in_progress") != 0 {
+		t.Error("expected warm-up to be finished")
+	}
+	if gauge("cache_load_duration_seconds") <= 0 {
+		t.Error("expected load duration to be recorded")
+	}
+}
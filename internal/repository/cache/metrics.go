@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Операции кеша — значения метки operation в cache_operation_duration_seconds
+const (
+	opGet    = "get"
+	opSet    = "set"
+	opDelete = "delete"
+	opClear  = "clear"
+)
+
+// totals — накопительные счётчики для Prometheus. Счётчики должны только расти,
+// поэтому Clear, который обнуляет domain.CacheStats, их не трогает
+type totals struct {
+	hits        int64
+	misses      int64
+	evictions   int64
+	expirations int64
+}
+
+// warmup — состояние загрузки кеша из БД при старте
+type warmup struct {
+	mu       sync.Mutex
+	running  bool
+	total    int
+	loaded   int
+	start    time.Time
+	duration time.Duration
+}
+
+func newOpDuration() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "cache_operation_duration_seconds",
+		Help: "Duration of order cache operations",
+		// операции в памяти: от 100 нс до ~25 мс
+		Buckets: prometheus.ExponentialBuckets(1e-7, 4, 10),
+	}, []string{"operation"})
+}
+
+func (c *OrderCache) observe(op string, start time.Time) {
+	c.opDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
+
+// BeginWarmup отмечает начало загрузки кеша из БД; total — сколько заказов ожидается
+func (c *OrderCache) BeginWarmup(total int) {
+	c.warmup.mu.Lock()
+	defer c.warmup.mu.Unlock()
+	c.warmup.running = true
+	c.warmup.total = total
+	c.warmup.loaded = 0
+	c.warmup.start = time.Now()
+	c.warmup.duration = 0
+}
+
+// WarmupProgress сообщает, сколько заказов загружено с начала прогрева
+func (c *OrderCache) WarmupProgress(loaded int) {
+	c.warmup.mu.Lock()
+	c.warmup.loaded = loaded
+	c.warmup.mu.Unlock()
+}
+
+// EndWarmup отмечает окончание загрузки кеша, в том числе неудачной
+func (c *OrderCache) EndWarmup() {
+	c.warmup.mu.Lock()
+	defer c.warmup.mu.Unlock()
+	if c.warmup.running {
+		c.warmup.running = false
+		c.warmup.duration = time.Since(c.warmup.start)
+	}
+}
+
+// WarmupStatus — ход загрузки кеша из БД
+type WarmupStatus struct {
+	Running  bool
+	Total    int
+	Loaded   int
+	Duration time.Duration // для идущей загрузки — прошедшее время
+}
+
+// Warmup возвращает состояние последней загрузки кеша из БД
+func (c *OrderCache) Warmup() WarmupStatus {
+	w := &c.warmup
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := WarmupStatus{Running: w.running, Total: w.total, Loaded: w.loaded, Duration: w.duration}
+	if w.running {
+		s.Duration = time.Since(w.start)
+	}
+	return s
+}
+
+var (
+	hitsDesc        = prometheus.NewDesc("cache_hits_total", "Order cache hits", nil, nil)
+	missesDesc      = prometheus.NewDesc("cache_misses_total", "Order cache misses, including expired entries", nil, nil)
+	hitRatioDesc    = prometheus.NewDesc("cache_hit_ratio", "Share of cache lookups that were hits since start", nil, nil)
+	sizeDesc        = prometheus.NewDesc("cache_size", "Number of orders in the cache", nil, nil)
+	maxSizeDesc     = prometheus.NewDesc("cache_max_size", "Maximum number of orders in the cache", nil, nil)
+	evictionsDesc   = prometheus.NewDesc("cache_evictions_total", "Orders evicted because the cache was full", nil, nil)
+	expirationsDesc = prometheus.NewDesc("cache_expirations_total", "Orders removed after their TTL expired", nil, nil)
+	warmupDesc      = prometheus.NewDesc("cache_warmup_in_progress", "1 while the cache is being loaded from the database", nil, nil)
+	warmupTotalDesc = prometheus.NewDesc("cache_warmup_orders", "Number of orders to load during cache warm-up", nil, nil)
+	warmupDoneDesc  = prometheus.NewDesc("cache_warmup_loaded_orders", "Number of orders loaded so far during cache warm-up", nil, nil)
+	loadDesc        = prometheus.NewDesc("cache_load_duration_seconds", "Duration of the cache warm-up, elapsed time while it is running", nil, nil)
+)
+
+// collector отдаёт метрики кеша при каждом сборе Prometheus
+type collector struct {
+	c *OrderCache
+}
+
+// Collector возвращает коллектор Prometheus с метриками кеша: попадания и промахи,
+// размер, вытеснения, истечения TTL, прогрев из БД и длительность операций
+func (c *OrderCache) Collector() prometheus.Collector {
+	return collector{c: c}
+}
+
+func (col collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{hitsDesc, missesDesc, hitRatioDesc, sizeDesc, maxSizeDesc,
+		evictionsDesc, expirationsDesc, warmupDesc, warmupTotalDesc, warmupDoneDesc, loadDesc} {
+		ch <- d
+	}
+	col.c.opDuration.Describe(ch)
+}
+
+func (col collector) Collect(ch chan<- prometheus.Metric) {
+	c := col.c
+	c.mu.RLock()
+	t, size, maxSize := c.totals, len(c.items), c.maxSize
+	c.mu.RUnlock()
+
+	var ratio float64
+	if lookups := t.hits + t.misses; lookups > 0 {
+		ratio = float64(t.hits) / float64(lookups)
+	}
+	ch <- prometheus.MustNewConstMetric(hitsDesc, prometheus.CounterValue, float64(t.hits))
+	ch <- prometheus.MustNewConstMetric(missesDesc, prometheus.CounterValue, float64(t.misses))
+	ch <- prometheus.MustNewConstMetric(hitRatioDesc, prometheus.GaugeValue, ratio)
+	ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(size))
+	ch <- prometheus.MustNewConstMetric(maxSizeDesc, prometheus.GaugeValue, float64(maxSize))
+	ch <- prometheus.MustNewConstMetric(evictionsDesc, prometheus.CounterValue, float64(t.evictions))
+	ch <- prometheus.MustNewConstMetric(expirationsDesc, prometheus.CounterValue, float64(t.expirations))
+
+	w := c.Warmup()
+	running := 0.0
+	if w.Running {
+		running = 1
+	}
+	ch <- prometheus.MustNewConstMetric(warmupDesc, prometheus.GaugeValue, running)
+	ch <- prometheus.MustNewConstMetric(warmupTotalDesc, prometheus.GaugeValue, float64(w.Total))
+	ch <- prometheus.MustNewConstMetric(warmupDoneDesc, prometheus.GaugeValue, float64(w.Loaded))
+	ch <- prometheus.MustNewConstMetric(loadDesc, prometheus.GaugeValue, w.Duration.Seconds())
+
+	c.opDuration.Collect(ch)
+}
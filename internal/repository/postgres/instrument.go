@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"WBtech_l0/internal/pii"
+	"WBtech_l0/internal/telemetry"
+)
+
+var tracer = otel.Tracer("postgres")
+
+var dbSystem = attribute.String("db.system", "postgresql")
+
+// queryer — общие методы *sql.DB и *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// startQuery открывает спан запроса и возвращает функцию, которая завершает его
+// с числом строк и ошибкой и записывает длительность в db_query_duration_seconds.
+// name — короткое имя запроса: оно же метка метрики, поэтому не должно зависеть от данных
+func startQuery(ctx context.Context, name string) (context.Context, func(rows int64, err error)) {
+	ctx, span := tracer.Start(ctx, "db."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(dbSystem, attribute.String("db.statement.name", name)))
+	start := time.Now()
+	return ctx, func(rows int64, err error) {
+		status := "success"
+		span.SetAttributes(attribute.Int64("db.rows", rows))
+		if err != nil {
+			status = "error"
+			recordError(span, err)
+		}
+		telemetry.DBQueryDuration.WithLabelValues(name, status).Observe(time.Since(start).Seconds())
+		span.End()
+	}
+}
+
+// recordError записывает ошибку в спан. Ошибки Postgres могут содержать значения
+// из запроса (например, в деталях нарушения ограничения), поэтому текст маскируется
+func recordError(span trace.Span, err error) {
+	err = errors.New(pii.Scrub(err.Error()))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// execContext выполняет запрос без результата; в спан пишется число затронутых строк
+func execContext(ctx context.Context, q queryer, name, query string, args ...any) (sql.Result, error) {
+	ctx, end := startQuery(ctx, name)
+	res, err := q.ExecContext(ctx, query, args...)
+	var n int64
+	if err == nil {
+		n, _ = res.RowsAffected() // lib/pq поддерживает RowsAffected для всех команд
+	}
+	end(n, err)
+	return res, err
+}
+
+// queryContext выполняет запрос с набором строк. Спан длится до закрытия rows
+// и получает число прочитанных строк
+func queryContext(ctx context.Context, q queryer, name, query string, args ...any) (*tracedRows, error) {
+	ctx, end := startQuery(ctx, name)
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		end(0, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, end: end}, nil
+}
+
+// queryRowContext выполняет запрос одной строки; спан завершается при Scan
+func queryRowContext(ctx context.Context, q queryer, name, query string, args ...any) *tracedRow {
+	ctx, end := startQuery(ctx, name)
+	return &tracedRow{row: q.QueryRowContext(ctx, query, args...), end: end}
+}
+
+// tracedRows считает прочитанные строки и завершает спан запроса при Close
+type tracedRows struct {
+	*sql.Rows
+	end   func(rows int64, err error)
+	count int64
+	done  bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	return false
+}
+
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	if !r.done {
+		r.done = true
+		iterErr := r.Rows.Err()
+		if iterErr == nil {
+			iterErr = err
+		}
+		r.end(r.count, iterErr)
+	}
+	return err
+}
+
+// tracedRow завершает спан запроса при Scan. sql.ErrNoRows ошибкой запроса не считается
+type tracedRow struct {
+	row *sql.Row
+	end func(rows int64, err error)
+}
+
+func (r *tracedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	switch {
+	case err == nil:
+		r.end(1, nil)
+	case errors.Is(err, sql.ErrNoRows):
+		r.end(0, nil)
+	default:
+		r.end(0, err)
+	}
+	return err
+}
+
+// tracedTx — транзакция со спаном от BeginTx до Commit или Rollback. Спаны запросов
+// внутри транзакции — дочерние: для этого запросы выполняются с контекстом из beginTx
+type tracedTx struct {
+	*sql.Tx
+	span  trace.Span
+	name  string
+	start time.Time
+	done  bool
+}
+
+// beginTx начинает транзакцию name и возвращает контекст с её спаном
+func (r *Repository) beginTx(ctx context.Context, name string, opts *sql.TxOptions) (context.Context, *tracedTx, error) {
+	ctx, span := tracer.Start(ctx, "db.tx."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(dbSystem, attribute.String("db.transaction.name", name)))
+	tx, err := r.db.BeginTx(ctx, opts)
+	if err != nil {
+		recordError(span, err)
+		span.End()
+		return ctx, nil, err
+	}
+	return ctx, &tracedTx{Tx: tx, span: span, name: name, start: time.Now()}, nil
+}
+
+// Commit фиксирует транзакцию и завершает её спан
+func (t *tracedTx) Commit() error {
+	err := t.Tx.Commit()
+	status := "commit"
+	if err != nil {
+		status = "error"
+		recordError(t.span, err)
+	}
+	t.finish(status)
+	return err
+}
+
+// Rollback откатывает транзакцию. После Commit возвращает sql.ErrTxDone и спан не трогает
+func (t *tracedTx) Rollback() error {
+	err := t.Tx.Rollback()
+	if !t.done {
+		t.finish("rollback")
+	}
+	return err
+}
+
+func (t *tracedTx) finish(status string) {
+	t.done = true
+	t.span.SetAttributes(attribute.String("db.transaction.status", status))
+	telemetry.DBTransactionDuration.WithLabelValues(t.name, status).Observe(time.Since(t.start).Seconds())
+	t.span.End()
+}
+
+// StatsCollector возвращает коллектор Prometheus со статистикой пула соединений
+// (sql.DBStats): открытые и занятые соединения, ожидания и закрытия по лимитам
+func (r *Repository) StatsCollector() prometheus.Collector {
+	return collectors.NewDBStatsCollector(r.db, "orders")
+}
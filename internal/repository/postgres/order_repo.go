@@ -4,11 +4,13 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/logging"
 	"WBtech_l0/internal/repository/cache"
 
 	"github.com/lib/pq"
@@ -31,7 +33,7 @@ func InitDB(cfg config.Config) *Repository {
 		cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Database)
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatalf("Failed to connect to DB: %v", err)
+		logging.Fatal("failed to open DB", logging.Err(err))
 	}
 	return &Repository{db: db}
 }
@@ -52,18 +54,27 @@ func (r *Repository) PingContext(ctx context.Context) error {
 	return nil
 }
 
-// LoadCacheFromDB — восстанавливает кеш из БД при старте
+// LoadCacheFromDB — восстанавливает кеш из БД при старте. Ход загрузки
+// виден в метриках кеша (cache_warmup_*)
 func LoadCacheFromDB(ctx context.Context, r *Repository, cache *cache.OrderCache) error {
-	rows, err := r.db.QueryContext(ctx, "SELECT order_uid FROM orders")
+	var total int
+	if err := queryRowContext(ctx, r.db, "count_orders", "SELECT count(*) FROM orders").Scan(&total); err != nil {
+		return fmt.Errorf("count orders: %w", err)
+	}
+	cache.BeginWarmup(total)
+	defer cache.EndWarmup()
+
+	rows, err := queryContext(ctx, r.db, "select_order_uids", "SELECT order_uid FROM orders")
 	if err != nil {
 		return fmt.Errorf("query order uids: %w", err)
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("failed to close rows: %v", err)
+			slog.WarnContext(ctx, "failed to close rows", logging.Err(err))
 		}
 	}()
 
+	var processed int
 	for rows.Next() {
 		var orderUID string
 		if err := rows.Scan(&orderUID); err != nil {
@@ -73,8 +84,10 @@ func LoadCacheFromDB(ctx context.Context, r *Repository, cache *cache.OrderCache
 		if err == nil {
 			cache.Set(order)
 		} else {
-			log.Printf("Failed to load order %s from DB: %v", orderUID, err)
+			slog.WarnContext(ctx, "failed to load order from DB into cache", logging.OrderUID(orderUID), logging.Err(err))
 		}
+		processed++
+		cache.WarmupProgress(processed)
 	}
 	if err := rows.Err(); err != nil {
 		return fmt.Errorf("rows error: %w", err)
@@ -88,7 +101,7 @@ func (r *Repository) SaveOrder(ctx context.Context, order domain.Order) error {
 	if err := order.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
-	tx, err := r.db.BeginTx(ctx, nil)
+	ctx, tx, err := r.beginTx(ctx, "save_order", nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -96,12 +109,12 @@ func (r *Repository) SaveOrder(ctx context.Context, order domain.Order) error {
 	// Если что-то пойдет не так — откат
 	defer func() {
 		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-			log.Printf("failed to rollback transaction: %v", err)
+			slog.WarnContext(ctx, "failed to rollback transaction", logging.Err(err))
 		}
 	}()
 
 	// Вставляем основной заказ
-	_, err = tx.ExecContext(ctx, `
+	_, err = execContext(ctx, tx, "insert_order", `
         INSERT INTO orders (order_uid, track_number, entry, locale, internal_signature,
                             customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard)
         VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
@@ -114,7 +127,7 @@ func (r *Repository) SaveOrder(ctx context.Context, order domain.Order) error {
 	}
 
 	// Вставляем доставку
-	_, err = tx.ExecContext(ctx, `
+	_, err = execContext(ctx, tx, "insert_delivery", `
         INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
         VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
 		order.OrderUID, order.Delivery.Name, order.Delivery.Phone,
@@ -125,7 +138,7 @@ func (r *Repository) SaveOrder(ctx context.Context, order domain.Order) error {
 	}
 
 	// Вставляем оплату
-	_, err = tx.ExecContext(ctx, `
+	_, err = execContext(ctx, tx, "insert_payment", `
         INSERT INTO payments (order_uid, transaction, request_id, currency, provider,
                               amount, payment_dt, bank, delivery_cost, goods_total, custom_fee)
         VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
@@ -139,7 +152,7 @@ func (r *Repository) SaveOrder(ctx context.Context, order domain.Order) error {
 
 	// Вставляем товары
 	for _, item := range order.Items {
-		_, err = tx.ExecContext(ctx, `
+		_, err = execContext(ctx, tx, "insert_item", `
             INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name,
                                sale, size, total_price, nm_id, brand, status)
             VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`,
@@ -168,7 +181,7 @@ func (r *Repository) GetOrder(ctx context.Context, orderUID string) (domain.Orde
 	}
 
 	// Начинаем транзакцию с уровнем изоляции Repeatable Read
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+	ctx, tx, err := r.beginTx(ctx, "get_order", &sql.TxOptions{
 		Isolation: sql.LevelRepeatableRead,
 	})
 	if err != nil {
@@ -176,24 +189,27 @@ func (r *Repository) GetOrder(ctx context.Context, orderUID string) (domain.Orde
 	}
 	defer func() {
 		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-			log.Printf("failed to rollback transaction: %v", err)
+			slog.WarnContext(ctx, "failed to rollback transaction", logging.Err(err))
 		}
 	}()
 
 	// Получаем данные заказа в транзакции
-	err = tx.QueryRowContext(ctx, `
+	err = queryRowContext(ctx, tx, "select_order", `
         SELECT order_uid, track_number, entry, locale, internal_signature,
                customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
         FROM orders WHERE order_uid=$1`, orderUID).Scan(
 		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
 		&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
 		&order.Shardkey, &order.SmID, &order.DateCreated, &order.OofShard)
+	if errors.Is(err, sql.ErrNoRows) {
+		return order, domain.ErrOrderNotFound
+	}
 	if err != nil {
-		return order, fmt.Errorf("query delivery: %w", err)
+		return order, fmt.Errorf("query order: %w", err)
 	}
 
 	// Доставка
-	err = tx.QueryRowContext(ctx, `
+	err = queryRowContext(ctx, tx, "select_order_delivery", `
         SELECT name, phone, zip, city, address, region, email
         FROM deliveries WHERE order_uid=$1`, orderUID).Scan(
 		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
@@ -204,7 +220,7 @@ func (r *Repository) GetOrder(ctx context.Context, orderUID string) (domain.Orde
 	}
 
 	// Оплата
-	err = tx.QueryRowContext(ctx, `
+	err = queryRowContext(ctx, tx, "select_order_payment", `
         SELECT transaction, request_id, currency, provider,
                amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
         FROM payments WHERE order_uid=$1`, orderUID).Scan(
@@ -217,7 +233,7 @@ func (r *Repository) GetOrder(ctx context.Context, orderUID string) (domain.Orde
 	}
 
 	// Товары
-	rows, err := tx.QueryContext(ctx, `
+	rows, err := queryContext(ctx, tx, "select_order_items", `
         SELECT chrt_id, track_number, price, rid, name, sale, size,
                total_price, nm_id, brand, status
         FROM items WHERE order_uid=$1`, orderUID)
@@ -226,7 +242,7 @@ func (r *Repository) GetOrder(ctx context.Context, orderUID string) (domain.Orde
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("failed to close rows: %v", err)
+			slog.WarnContext(ctx, "failed to close rows", logging.Err(err))
 		}
 	}()
 
@@ -253,20 +269,126 @@ func (r *Repository) GetOrder(ctx context.Context, orderUID string) (domain.Orde
 	return order, nil
 }
 
+// GetOrders загружает заказы с указанными order_uid одним запросом к каждой таблице.
+// Отсутствующие в БД order_uid просто не попадают в результат, порядок не гарантируется
+func (r *Repository) GetOrders(ctx context.Context, orderUIDs []string) ([]domain.Order, error) {
+	if len(orderUIDs) == 0 {
+		return nil, nil
+	}
+	ctx, tx, err := r.beginTx(ctx, "get_orders", &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.WarnContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	rows, err := queryContext(ctx, tx, "select_orders_by_uids", `
+        SELECT order_uid, track_number, entry, locale, internal_signature,
+               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+        FROM orders
+        WHERE order_uid = ANY($1)
+    `, pq.Array(orderUIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query orders: %w", err)
+	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+	if err := loadOrderDetails(ctx, tx, orders); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return orders, nil
+}
+
+// ExportOrders читает заказы через серверный курсор по pageSize штук и для каждой
+// страницы дозагружает связанные таблицы. Вся выгрузка идёт в одной read-only транзакции
+// REPEATABLE READ, поэтому видит согласованный снимок данных
+func (r *Repository) ExportOrders(ctx context.Context, filter domain.ExportFilter, pageSize int, fn func(page []domain.Order) error) error {
+	ctx, tx, err := r.beginTx(ctx, "export_orders", &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.WarnContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	var from, to interface{}
+	if !filter.From.IsZero() {
+		from = filter.From
+	}
+	if !filter.To.IsZero() {
+		to = filter.To
+	}
+	_, err = execContext(ctx, tx, "declare_export_cursor", `
+        DECLARE export_cursor NO SCROLL CURSOR FOR
+        SELECT order_uid, track_number, entry, locale, internal_signature,
+               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+        FROM orders
+        WHERE ($1::timestamptz IS NULL OR date_created >= $1)
+          AND ($2::timestamptz IS NULL OR date_created < $2)
+        ORDER BY date_created, order_uid
+    `, from, to)
+	if err != nil {
+		return fmt.Errorf("declare cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", pageSize)
+	for {
+		rows, err := queryContext(ctx, tx, "fetch_export_cursor", fetch)
+		if err != nil {
+			return fmt.Errorf("fetch orders: %w", err)
+		}
+		page, err := scanOrders(rows)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+		if err := loadOrderDetails(ctx, tx, page); err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
 // LoadAllOrders загружает все заказы из БД со связанными данными
 func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error) {
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	ctx, tx, err := r.beginTx(ctx, "load_all_orders", &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
 	if err != nil {
 		return nil, fmt.Errorf("begin transaction: %w", err)
 	}
 	defer func() {
 		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-			log.Printf("failed to rollback transaction: %v", err)
+			slog.WarnContext(ctx, "failed to rollback transaction", logging.Err(err))
 		}
 	}()
 
 	// 1. Загружаем основные данные всех заказов
-	rows, err := tx.QueryContext(ctx, `
+	rows, err := queryContext(ctx, tx, "select_all_orders", `
         SELECT order_uid, track_number, entry, locale, internal_signature,
                customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
         FROM orders
@@ -275,15 +397,76 @@ func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error)
 	if err != nil {
 		return nil, fmt.Errorf("query orders: %w", err)
 	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	if err := loadOrderDetails(ctx, tx, orders); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return orders, nil
+}
+
+// FindByTrackNumber ищет заказы, у которых трек-номер заказа или одного из товаров
+// равен trackNumber, и загружает их со связанными данными
+func (r *Repository) FindByTrackNumber(ctx context.Context, trackNumber string) ([]domain.Order, error) {
+	ctx, tx, err := r.beginTx(ctx, "find_by_track_number", &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.WarnContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	rows, err := queryContext(ctx, tx, "select_orders_by_track_number", `
+        SELECT order_uid, track_number, entry, locale, internal_signature,
+               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+        FROM orders
+        WHERE track_number = $1
+           OR order_uid IN (SELECT order_uid FROM items WHERE track_number = $1)
+        ORDER BY date_created, order_uid
+    `, trackNumber)
+	if err != nil {
+		return nil, fmt.Errorf("query orders by track number: %w", err)
+	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+	if err := loadOrderDetails(ctx, tx, orders); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return orders, nil
+}
+
+// scanOrders читает основные данные заказов и закрывает rows
+func scanOrders(rows *tracedRows) ([]domain.Order, error) {
 	defer func() {
 		if err := rows.Close(); err != nil {
-			log.Printf("failed to close orders rows: %v", err)
+			slog.Warn("failed to close orders rows", logging.Err(err))
 		}
 	}()
 
 	var orders []domain.Order
-	orderIdxMap := make(map[string]int) // order_uid -> индекс в слайсе orders
-
 	for rows.Next() {
 		var o domain.Order
 		err := rows.Scan(
@@ -294,35 +477,36 @@ func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error)
 		if err != nil {
 			return nil, fmt.Errorf("scan order: %w", err)
 		}
-		orderIdxMap[o.OrderUID] = len(orders)
 		orders = append(orders, o)
 	}
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows iteration: %w", err)
 	}
+	return orders, nil
+}
 
-	if len(orders) == 0 {
-		return orders, nil
-	}
-
-	// Получаем список всех order_uid для массовой загрузки связанных таблиц
-	uids := make([]string, 0, len(orders))
-	for _, o := range orders {
-		uids = append(uids, o.OrderUID)
+// loadOrderDetails дозагружает доставку, оплату и товары для уже прочитанных заказов:
+// по одному запросу WHERE order_uid = ANY($1) на каждую связанную таблицу
+func loadOrderDetails(ctx context.Context, tx *tracedTx, orders []domain.Order) error {
+	uids := make([]string, len(orders))
+	orderIdxMap := make(map[string]int, len(orders)) // order_uid -> индекс в слайсе orders
+	for i, o := range orders {
+		uids[i] = o.OrderUID
+		orderIdxMap[o.OrderUID] = i
 	}
 
-	// 2. Загружаем доставки
-	deliveryRows, err := tx.QueryContext(ctx, `
+	// Доставки
+	deliveryRows, err := queryContext(ctx, tx, "select_deliveries", `
         SELECT order_uid, name, phone, zip, city, address, region, email
         FROM deliveries
         WHERE order_uid = ANY($1)
     `, pq.Array(uids))
 	if err != nil {
-		return nil, fmt.Errorf("query deliveries: %w", err)
+		return fmt.Errorf("query deliveries: %w", err)
 	}
 	defer func() {
 		if err := deliveryRows.Close(); err != nil {
-			log.Printf("failed to close deliveries rows: %v", err)
+			slog.WarnContext(ctx, "failed to close deliveries rows", logging.Err(err))
 		}
 	}()
 
@@ -333,29 +517,29 @@ func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error)
 			&orderUID, &d.Name, &d.Phone, &d.Zip, &d.City, &d.Address, &d.Region, &d.Email,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("scan delivery: %w", err)
+			return fmt.Errorf("scan delivery: %w", err)
 		}
 		if idx, ok := orderIdxMap[orderUID]; ok {
 			orders[idx].Delivery = d
 		}
 	}
-	if err = deliveryRows.Err(); err != nil {
-		return nil, fmt.Errorf("deliveries iteration: %w", err)
+	if err := deliveryRows.Err(); err != nil {
+		return fmt.Errorf("deliveries iteration: %w", err)
 	}
 
-	// 3. Загружаем платежи
-	paymentRows, err := tx.QueryContext(ctx, `
+	// Платежи
+	paymentRows, err := queryContext(ctx, tx, "select_payments", `
         SELECT order_uid, transaction, request_id, currency, provider,
                amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
         FROM payments
         WHERE order_uid = ANY($1)
     `, pq.Array(uids))
 	if err != nil {
-		return nil, fmt.Errorf("query payments: %w", err)
+		return fmt.Errorf("query payments: %w", err)
 	}
 	defer func() {
 		if err := paymentRows.Close(); err != nil {
-			log.Printf("failed to close payments rows: %v", err)
+			slog.WarnContext(ctx, "failed to close payments rows", logging.Err(err))
 		}
 	}()
 
@@ -367,18 +551,18 @@ func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error)
 			&p.Amount, &p.PaymentDT, &p.Bank, &p.DeliveryCost, &p.GoodsTotal, &p.CustomFee,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("scan payment: %w", err)
+			return fmt.Errorf("scan payment: %w", err)
 		}
 		if idx, ok := orderIdxMap[orderUID]; ok {
 			orders[idx].Payment = p
 		}
 	}
-	if err = paymentRows.Err(); err != nil {
-		return nil, fmt.Errorf("payments iteration: %w", err)
+	if err := paymentRows.Err(); err != nil {
+		return fmt.Errorf("payments iteration: %w", err)
 	}
 
-	// 4. Загружаем товары
-	itemRows, err := tx.QueryContext(ctx, `
+	// Товары
+	itemRows, err := queryContext(ctx, tx, "select_items", `
         SELECT order_uid, chrt_id, track_number, price, rid, name,
                sale, size, total_price, nm_id, brand, status
         FROM items
@@ -386,11 +570,11 @@ func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error)
         ORDER BY order_uid, chrt_id
     `, pq.Array(uids))
 	if err != nil {
-		return nil, fmt.Errorf("query items: %w", err)
+		return fmt.Errorf("query items: %w", err)
 	}
 	defer func() {
 		if err := itemRows.Close(); err != nil {
-			log.Printf("failed to close items rows: %v", err)
+			slog.WarnContext(ctx, "failed to close items rows", logging.Err(err))
 		}
 	}()
 
@@ -402,20 +586,56 @@ func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error)
 			&it.Sale, &it.Size, &it.TotalPrice, &it.NmID, &it.Brand, &it.Status,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("scan item: %w", err)
+			return fmt.Errorf("scan item: %w", err)
 		}
 		if idx, ok := orderIdxMap[orderUID]; ok {
 			orders[idx].Items = append(orders[idx].Items, it)
 		}
 	}
-	if err = itemRows.Err(); err != nil {
-		return nil, fmt.Errorf("items iteration: %w", err)
+	if err := itemRows.Err(); err != nil {
+		return fmt.Errorf("items iteration: %w", err)
+	}
+	return nil
+}
+
+// ListOrders возвращает до limit заказов с order_uid больше afterUID в порядке order_uid.
+// Keyset-пагинация не зависит от смещения, поэтому глубокие страницы не дорожают
+func (r *Repository) ListOrders(ctx context.Context, afterUID string, limit int) ([]domain.Order, error) {
+	ctx, tx, err := r.beginTx(ctx, "list_orders", &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.WarnContext(ctx, "failed to rollback transaction", logging.Err(err))
+		}
+	}()
+
+	rows, err := queryContext(ctx, tx, "select_orders_page", `
+        SELECT order_uid, track_number, entry, locale, internal_signature,
+               customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+        FROM orders
+        WHERE order_uid > $1
+        ORDER BY order_uid
+        LIMIT $2
+    `, afterUID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query orders: %w", err)
+	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+	if err := loadOrderDetails(ctx, tx, orders); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
-
 	return orders, nil
 }
 
@@ -423,15 +643,15 @@ func (r *Repository) LoadAllOrders(ctx context.Context) ([]domain.Order, error)
 func (r *Repository) ClearAll(ctx context.Context) error {
 	tables := []string{"items", "payments", "deliveries", "orders"}
 	for _, table := range tables {
-		if _, err := r.db.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+		if _, err := execContext(ctx, r.db, "delete_"+table, "DELETE FROM "+table); err != nil {
 			return fmt.Errorf("failed to clear table %s: %w", table, err)
 		}
 	}
 	// Сброс последовательностей (опционально)
 	sequences := []string{"items_id_seq", "payments_id_seq", "deliveries_id_seq"}
 	for _, seq := range sequences {
-		if _, err := r.db.ExecContext(ctx, "ALTER SEQUENCE "+seq+" RESTART WITH 1"); err != nil {
-			log.Printf("failed to restart sequence %s: %v", seq, err)
+		if _, err := execContext(ctx, r.db, "restart_sequence", "ALTER SEQUENCE "+seq+" RESTART WITH 1"); err != nil {
+			slog.WarnContext(ctx, "failed to restart sequence", slog.String("sequence", seq), logging.Err(err))
 		}
 	}
 	return nil
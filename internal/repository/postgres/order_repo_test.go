@@ -3,15 +3,23 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"slices"
 	"testing"
 	"time"
 
 	"WBtech_l0/internal/domain"
 
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // ensureTestDBExists создаёт тестовую базу данных, если она не существует.
@@ -171,8 +179,8 @@ func TestPostgresRepository_GetOrder_NotFound(t *testing.T) {
 
 	repo := &Repository{db: db}
 	_, err := repo.GetOrder(context.Background(), "nonexistent")
-	if err == nil {
-		t.Error("expected error for non-existent order, got nil")
+	if !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound for non-existent order, got %v", err)
 	}
 }
 
@@ -292,6 +300,94 @@ func TestPostgresRepository_LoadAllOrders(t *testing.T) {
 	}
 }
 
+func TestPostgresRepository_ListOrders(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	truncateTables(t, db)
+
+	repo := &Repository{db: db}
+	ctx := context.Background()
+
+	for _, uid := range []string{"list3", "list1", "list2"} {
+		order := domain.Order{
+			OrderUID:    uid,
+			TrackNumber: "T-" + uid,
+			Entry:       "WBIL",
+			Delivery:    domain.Delivery{Name: "D", Phone: "1", Zip: "1", City: "C", Address: "A", Region: "R", Email: "e@e.com"},
+			Payment:     domain.Payment{Transaction: "trx-" + uid, Currency: "USD", Amount: 100, PaymentDT: time.Now().Unix()},
+			Items:       []domain.Item{{ChrtID: 1, TrackNumber: "T-" + uid, Price: 10, Name: "I", TotalPrice: 10, NmID: 1}},
+			DateCreated: time.Now().Format(time.RFC3339),
+		}
+		if err := repo.SaveOrder(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := repo.ListOrders(ctx, "", 2)
+	if err != nil {
+		t.Fatalf("ListOrders failed: %v", err)
+	}
+	if len(page) != 2 || page[0].OrderUID != "list1" || page[1].OrderUID != "list2" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	if page[0].Delivery.Name != "D" || len(page[0].Items) != 1 {
+		t.Error("related rows not loaded for listed order")
+	}
+
+	page, err = repo.ListOrders(ctx, "list2", 2)
+	if err != nil {
+		t.Fatalf("ListOrders failed: %v", err)
+	}
+	if len(page) != 1 || page[0].OrderUID != "list3" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+}
+
+func TestPostgresRepository_GetOrders(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	truncateTables(t, db)
+
+	repo := &Repository{db: db}
+	ctx := context.Background()
+
+	for _, uid := range []string{"batch1", "batch2"} {
+		order := domain.Order{
+			OrderUID:    uid,
+			TrackNumber: "T-" + uid,
+			Entry:       "WBIL",
+			Delivery:    domain.Delivery{Name: "D", Phone: "1", Zip: "1", City: "C", Address: "A", Region: "R", Email: "e@e.com"},
+			Payment:     domain.Payment{Transaction: "trx-" + uid, Currency: "USD", Amount: 100, PaymentDT: time.Now().Unix()},
+			Items:       []domain.Item{{ChrtID: 1, TrackNumber: "T-" + uid, Price: 10, Name: "I", TotalPrice: 10, NmID: 1}},
+			DateCreated: time.Now().Format(time.RFC3339),
+		}
+		if err := repo.SaveOrder(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orders, err := repo.GetOrders(ctx, []string{"batch2", "missing", "batch1"})
+	if err != nil {
+		t.Fatalf("GetOrders failed: %v", err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("expected 2 orders, got %d", len(orders))
+	}
+	for _, o := range orders {
+		if o.Payment.Transaction != "trx-"+o.OrderUID || len(o.Items) != 1 {
+			t.Errorf("related rows not loaded for %s: %+v", o.OrderUID, o)
+		}
+	}
+}
+
 func TestPostgresRepository_ClearAll(t *testing.T) {
 	db := connectTestDB(t)
 	defer func() {
@@ -349,3 +445,207 @@ func TestPostgresRepository_ClearAll(t *testing.T) {
 		t.Errorf("expected 0 orders after ClearAll, got %d", len(orders))
 	}
 }
+
+func TestPostgresRepository_ExportOrders(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	truncateTables(t, db)
+
+	repo := &Repository{db: db}
+	ctx := context.Background()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		uid := fmt.Sprintf("export%d", i)
+		order := domain.Order{
+			OrderUID:    uid,
+			TrackNumber: "T-" + uid,
+			Entry:       "WBIL",
+			Delivery:    domain.Delivery{Name: "D", Phone: "1", Zip: "1", City: "C", Address: "A", Region: "R", Email: "e@e.com"},
+			Payment:     domain.Payment{Transaction: "trx-" + uid, Currency: "USD", Amount: 100, PaymentDT: time.Now().Unix()},
+			Items:       []domain.Item{{ChrtID: i, TrackNumber: "T-" + uid, Price: 10, Name: "I", TotalPrice: 10, NmID: 1}},
+			DateCreated: base.AddDate(0, 0, i).Format(time.RFC3339),
+		}
+		if err := repo.SaveOrder(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// [1 янв + 1 день, 1 янв + 4 дня): export1..export3, страницы по 2
+	filter := domain.ExportFilter{From: base.AddDate(0, 0, 1), To: base.AddDate(0, 0, 4)}
+	var uids []string
+	pages := 0
+	err := repo.ExportOrders(ctx, filter, 2, func(page []domain.Order) error {
+		pages++
+		for _, o := range page {
+			if o.Payment.Transaction != "trx-"+o.OrderUID || len(o.Items) != 1 {
+				t.Errorf("related rows not loaded for %s: %+v", o.OrderUID, o)
+			}
+			uids = append(uids, o.OrderUID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportOrders failed: %v", err)
+	}
+	if fmt.Sprint(uids) != "[export1 export2 export3]" || pages != 2 {
+		t.Errorf("unexpected export: %v in %d pages", uids, pages)
+	}
+
+	// ошибка обработчика прерывает выгрузку
+	stop := errors.New("stop")
+	if err := repo.ExportOrders(ctx, domain.ExportFilter{}, 2, func([]domain.Order) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("expected callback error, got %v", err)
+	}
+}
+
+func TestPostgresRepository_FindByTrackNumber(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	truncateTables(t, db)
+
+	repo := &Repository{db: db}
+	ctx := context.Background()
+
+	order := domain.Order{
+		OrderUID:    "track1",
+		TrackNumber: "ORDERTRACK",
+		Entry:       "WBIL",
+		Delivery:    domain.Delivery{Name: "D", Phone: "1", Zip: "1", City: "C", Address: "A", Region: "R", Email: "e@e.com"},
+		Payment:     domain.Payment{Transaction: "trx-track1", Currency: "USD", Amount: 100, PaymentDT: time.Now().Unix()},
+		Items: []domain.Item{
+			{ChrtID: 1, TrackNumber: "ORDERTRACK", Price: 10, Name: "I1", TotalPrice: 10, NmID: 1},
+			{ChrtID: 2, TrackNumber: "ITEMTRACK", Price: 10, Name: "I2", TotalPrice: 10, NmID: 2},
+		},
+		DateCreated: time.Now().Format(time.RFC3339),
+	}
+	if err := repo.SaveOrder(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, track := range []string{"ORDERTRACK", "ITEMTRACK"} {
+		orders, err := repo.FindByTrackNumber(ctx, track)
+		if err != nil {
+			t.Fatalf("FindByTrackNumber(%s) failed: %v", track, err)
+		}
+		if len(orders) != 1 || orders[0].OrderUID != "track1" || len(orders[0].Items) != 2 {
+			t.Errorf("unexpected orders for %s: %+v", track, orders)
+		}
+	}
+
+	orders, err := repo.FindByTrackNumber(ctx, "UNKNOWN")
+	if err != nil || len(orders) != 0 {
+		t.Errorf("expected no orders, got %+v, %v", orders, err)
+	}
+}
+
+func TestPostgresRepository_Spans(t *testing.T) {
+	db := connectTestDB(t)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Logf("failed to close test database: %v", err)
+		}
+	}()
+	truncateTables(t, db)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	repo := &Repository{db: db}
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "handler")
+	order := domain.Order{
+		OrderUID:    "test-spans-1",
+		TrackNumber: "TRACK123",
+		Entry:       "WBIL",
+		Delivery:    domain.Delivery{Name: "John Doe", Phone: "+123456789", Zip: "12345", City: "City", Address: "Address", Region: "Region", Email: "test@example.com"},
+		Payment:     domain.Payment{Transaction: "test-trx", Currency: "USD", Provider: "test", Amount: 1000, PaymentDT: time.Now().Unix(), Bank: "Bank", DeliveryCost: 100, GoodsTotal: 900},
+		Items: []domain.Item{
+			{ChrtID: 1, TrackNumber: "TRACK123", Price: 450, Rid: "rid1", Name: "Item 1", Size: "M", TotalPrice: 450, NmID: 100, Brand: "Brand", Status: 200},
+			{ChrtID: 2, TrackNumber: "TRACK123", Price: 450, Rid: "rid2", Name: "Item 2", Size: "L", TotalPrice: 450, NmID: 101, Brand: "Brand", Status: 200},
+		},
+		Locale:          "en",
+		CustomerID:      "cust1",
+		DeliveryService: "test-delivery",
+		Shardkey:        "1",
+		SmID:            1,
+		DateCreated:     time.Now().Format(time.RFC3339),
+		OofShard:        "1",
+	}
+	if err := repo.SaveOrder(ctx, order); err != nil {
+		t.Fatalf("SaveOrder failed: %v", err)
+	}
+	if _, err := repo.GetOrder(ctx, order.OrderUID); err != nil {
+		t.Fatalf("GetOrder failed: %v", err)
+	}
+	parent.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	txSpan, ok := spans["db.tx.get_order"]
+	if !ok {
+		t.Fatalf("no transaction span, got %v", slices.Sorted(maps.Keys(spans)))
+	}
+	if txSpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("transaction span must be a child of the caller span")
+	}
+	for name, wantRows := range map[string]int64{
+		"db.select_order":          1,
+		"db.select_order_delivery": 1,
+		"db.select_order_payment":  1,
+		"db.select_order_items":    2,
+	} {
+		s, ok := spans[name]
+		if !ok {
+			t.Errorf("no span %s", name)
+			continue
+		}
+		if s.Parent().SpanID() != txSpan.SpanContext().SpanID() {
+			t.Errorf("%s must be a child of the transaction span", name)
+		}
+		for _, a := range s.Attributes() {
+			if a.Key == "db.rows" && a.Value.AsInt64() != wantRows {
+				t.Errorf("%s: expected %d rows, got %d", name, wantRows, a.Value.AsInt64())
+			}
+		}
+	}
+	for _, a := range spans["db.tx.save_order"].Attributes() {
+		if a.Key == "db.transaction.status" && a.Value.AsString() != "commit" {
+			t.Errorf("save_order: expected commit, got %s", a.Value.AsString())
+		}
+	}
+
+	// Ошибка запроса отмечается в спане
+	_, err := repo.GetOrder(ctx, "test-spans-missing")
+	if !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound, got %v", err)
+	}
+	tooLong := order
+	tooLong.OrderUID = "test-spans-2"
+	tooLong.OofShard = "12345678901" // oof_shard VARCHAR(10)
+	if err := repo.SaveOrder(ctx, tooLong); err == nil {
+		t.Fatalf("expected insert with too long oof_shard to fail")
+	}
+	var failed sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "db.insert_order" && s.Status().Code == codes.Error {
+			failed = s
+		}
+	}
+	if failed == nil {
+		t.Fatalf("no failed insert_order span")
+	}
+
+	if n := testutil.CollectAndCount(repo.StatsCollector()); n == 0 {
+		t.Errorf("DB stats collector exported no metrics")
+	}
+}
@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+)
+
+// OtherLabel — значение метки для всего, что не входит в список разрешённых
+const OtherLabel = "other"
+
+// Причины отправки сообщения в DLQ (заголовок dlq-reason и метка reason)
+const (
+	DLQReasonInvalidJSON     = "invalid_json"
+	DLQReasonMissingOrderUID = "missing_order_uid"
+	DLQReasonSaveFailed      = "save_failed"
+)
+
+var dlqReasons = []string{DLQReasonInvalidJSON, DLQReasonMissingOrderUID, DLQReasonSaveFailed}
+
+// BusinessMetrics — метрики по содержимому сохранённых заказов для продуктовых дашбордов.
+// Регистрируются в переданном реестре, а не в глобальном, чтобы их можно было проверять
+// в тестах независимо друг от друга. Реализует domain.OrderPublisher
+type BusinessMetrics struct {
+	cfg config.BusinessMetricsConfig
+
+	amount           *prometheus.HistogramVec
+	items            prometheus.Histogram
+	deliveryServices *prometheus.CounterVec
+	providers        *prometheus.CounterVec
+	banks            *prometheus.CounterVec
+	entries          *prometheus.CounterVec
+	dlq              *prometheus.CounterVec
+}
+
+// NewBusinessMetrics создаёт бизнес-метрики и регистрирует их в reg. Значения меток
+// ограничены списками из cfg, остальные учитываются как OtherLabel
+func NewBusinessMetrics(reg prometheus.Registerer, cfg config.BusinessMetricsConfig) *BusinessMetrics {
+	f := promauto.With(reg)
+	m := &BusinessMetrics{
+		cfg: cfg,
+		amount: f.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "order_payment_amount",
+			Help:    "Payment amount of saved orders",
+			Buckets: prometheus.ExponentialBuckets(100, 4, 9),
+		}, []string{"currency"}),
+		items: f.NewHistogram(prometheus.HistogramOpts{
+			Name:    "order_items",
+			Help:    "Number of items per saved order",
+			Buckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		}),
+		deliveryServices: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "orders_by_delivery_service_total",
+			Help: "Saved orders by delivery service",
+		}, []string{"delivery_service"}),
+		providers: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "orders_by_payment_provider_total",
+			Help: "Saved orders by payment provider",
+		}, []string{"provider"}),
+		banks: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "orders_by_bank_total",
+			Help: "Saved orders by bank",
+		}, []string{"bank"}),
+		entries: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "orders_by_entry_total",
+			Help: "Saved orders by entry point",
+		}, []string{"entry"}),
+		dlq: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "orders_dlq_total",
+			Help: "Messages sent to the dead letter queue by reason",
+		}, []string{"reason"}),
+	}
+	// Ряды с нулями видны на дашбордах сразу, а не после первого события
+	for _, reason := range dlqReasons {
+		m.dlq.WithLabelValues(reason)
+	}
+	return m
+}
+
+// PublishOrder учитывает сохранённый заказ; вызывается usecase после записи в БД
+func (m *BusinessMetrics) PublishOrder(order domain.Order) {
+	m.amount.WithLabelValues(allowed(m.cfg.Currencies, order.Payment.Currency)).Observe(float64(order.Payment.Amount))
+	m.items.Observe(float64(len(order.Items)))
+	m.deliveryServices.WithLabelValues(allowed(m.cfg.DeliveryServices, order.DeliveryService)).Inc()
+	m.providers.WithLabelValues(allowed(m.cfg.Providers, order.Payment.Provider)).Inc()
+	m.banks.WithLabelValues(allowed(m.cfg.Banks, order.Payment.Bank)).Inc()
+	m.entries.WithLabelValues(allowed(m.cfg.Entries, order.Entry)).Inc()
+}
+
+// DLQ учитывает сообщение, отправленное в DLQ. Неизвестная причина учитывается как OtherLabel
+func (m *BusinessMetrics) DLQ(reason string) {
+	m.dlq.WithLabelValues(allowed(dlqReasons, reason)).Inc()
+}
+
+// allowed возвращает value, если оно есть в списке, иначе OtherLabel
+func allowed(list []string, value string) string {
+	if slices.Contains(list, value) {
+		return value
+	}
+	return OtherLabel
+}
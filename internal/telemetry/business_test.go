@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/domain"
+)
+
+func newTestBusinessMetrics(t *testing.T) (*BusinessMetrics, *prometheus.Registry) {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	return NewBusinessMetrics(reg, config.BusinessMetricsConfig{
+		Currencies:       []string{"RUB", "USD"},
+		DeliveryServices: []string{"meest"},
+		Providers:        []string{"wbpay"},
+		Banks:            []string{"alpha"},
+		Entries:          []string{"WBIL"},
+	}), reg
+}
+
+func TestBusinessMetrics_PublishOrder(t *testing.T) {
+	m, reg := newTestBusinessMetrics(t)
+
+	m.PublishOrder(domain.Order{
+		Entry:           "WBIL",
+		DeliveryService: "meest",
+		Payment:         domain.Payment{Currency: "RUB", Provider: "wbpay", Bank: "alpha", Amount: 1817},
+		Items:           []domain.Item{{}, {}},
+	})
+	// значения не из списков не создают новых рядов
+	m.PublishOrder(domain.Order{
+		Entry:           "TEST-123",
+		DeliveryService: "courier-42",
+		Payment:         domain.Payment{Currency: "XYZ", Provider: "someone", Bank: "", Amount: 50},
+	})
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.deliveryServices.WithLabelValues("meest")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.deliveryServices.WithLabelValues(OtherLabel)))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.providers.WithLabelValues(OtherLabel)))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.banks.WithLabelValues("alpha")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.entries.WithLabelValues(OtherLabel)))
+	require.Equal(t, 2, testutil.CollectAndCount(m.entries))
+	require.Equal(t, 2, testutil.CollectAndCount(m.amount))
+
+	expected := `
+# HELP order_items Number of items per saved order
+# TYPE order_items histogram
+order_items_bucket{le="1"} 1
+order_items_bucket{le="2"} 2
+order_items_bucket{le="3"} 2
+order_items_bucket{le="5"} 2
+order_items_bucket{le="10"} 2
+order_items_bucket{le="20"} 2
+order_items_bucket{le="50"} 2
+order_items_bucket{le="100"} 2
+order_items_bucket{le="+Inf"} 2
+order_items_sum 2
+order_items_count 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "order_items"))
+}
+
+func TestBusinessMetrics_DLQ(t *testing.T) {
+	m, _ := newTestBusinessMetrics(t)
+
+	// ряды для известных причин есть сразу
+	require.Equal(t, len(dlqReasons), testutil.CollectAndCount(m.dlq))
+
+	m.DLQ(DLQReasonSaveFailed)
+	m.DLQ(DLQReasonSaveFailed)
+	m.DLQ("something_else")
+
+	require.Equal(t, 2.0, testutil.ToFloat64(m.dlq.WithLabelValues(DLQReasonSaveFailed)))
+	require.Equal(t, 0.0, testutil.ToFloat64(m.dlq.WithLabelValues(DLQReasonInvalidJSON)))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.dlq.WithLabelValues(OtherLabel)))
+}
+
+func TestNewBusinessMetrics_SeparateRegistries(t *testing.T) {
+	// глобальный реестр не используется: экземпляры не конфликтуют между собой
+	first, _ := newTestBusinessMetrics(t)
+	second, _ := newTestBusinessMetrics(t)
+	first.DLQ(DLQReasonInvalidJSON)
+	require.Equal(t, 0.0, testutil.ToFloat64(second.dlq.WithLabelValues(DLQReasonInvalidJSON)))
+}
@@ -2,17 +2,8 @@
 package telemetry
 
 import (
-	"context"
-	"log"
-
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
 // Метрики Prometheus
@@ -31,55 +22,57 @@ var (
 			Help:    "Duration of HTTP requests",
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"path", "method"},
+		[]string{"route", "method", "code"},
 	)
 
-	KafkaMessageProcessDuration = promauto.NewHistogramVec(
+	GRPCRequestDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "kafka_message_process_duration_seconds",
-			Help:    "Duration of Kafka message processing",
+			Name:    "grpc_request_duration_seconds",
+			Help:    "Duration of gRPC calls (for streams, until the stream is closed)",
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"topic", "status"},
+		[]string{"method", "code"},
 	)
-)
 
-// InitTracer инициализирует OTLP экспортер трейсов.
-// Параметр otlpEndpoint должен содержать адрес OTLP collector (например, "localhost:4318").
-func InitTracer(serviceName, otlpEndpoint string) func() {
-	ctx := context.Background()
+	HTTPRequestsThrottled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_throttled_total",
+			Help: "Total number of HTTP requests rejected by the rate limiter",
+		},
+		[]string{"route", "client_type"},
+	)
 
-	// Создаём OTLP HTTP экспортер
-	exporter, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpoint(otlpEndpoint),
-		otlptracehttp.WithInsecure(), // если collector без TLS (для разработки)
+	RateLimitBackendErrors = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limit_backend_errors_total",
+			Help: "Total number of rate limiter backend errors (requests are allowed on error)",
+		},
 	)
-	if err != nil {
-		log.Fatalf("failed to create OTLP exporter: %v", err)
-	}
 
-	// Ресурс с именем сервиса
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-			attribute.String("environment", "production"),
-		),
+	KafkaMessageProcessDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kafka_message_process_duration_seconds",
+			Help:    "Duration of Kafka message processing",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"topic", "status"},
 	)
-	if err != nil {
-		log.Fatalf("failed to create resource: %v", err)
-	}
 
-	// Провайдер трассировки
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // для разработки
+	DBQueryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of database queries by statement name (for row sets, until the rows are closed)",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		},
+		[]string{"statement", "status"},
 	)
-	otel.SetTracerProvider(tp)
 
-	return func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer provider: %v", err)
-		}
-	}
-}
+	DBTransactionDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_transaction_duration_seconds",
+			Help:    "Duration of database transactions from begin to commit or rollback",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"transaction", "status"},
+	)
+)
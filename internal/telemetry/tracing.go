@@ -0,0 +1,216 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"google.golang.org/grpc/credentials"
+
+	"WBtech_l0/internal/config"
+	"WBtech_l0/internal/logging"
+)
+
+// dynamicSampler — сэмплер, который можно заменить без пересоздания провайдера
+type dynamicSampler struct {
+	current atomic.Pointer[sdktrace.Sampler]
+}
+
+func (s *dynamicSampler) set(sampler sdktrace.Sampler) {
+	s.current.Store(&sampler)
+}
+
+func (s *dynamicSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return (*s.current.Load()).ShouldSample(p)
+}
+
+func (s *dynamicSampler) Description() string {
+	return (*s.current.Load()).Description()
+}
+
+// sampler решает, трассировать ли запрос. По умолчанию — parent_ratio с долей 1
+var sampler = func() *dynamicSampler {
+	s := &dynamicSampler{}
+	s.set(newSampler("parent_ratio", 1))
+	return s
+}()
+
+// newSampler собирает сэмплер по имени из telemetry.sampler:
+// always и never — все или ни одного запроса, ratio — доля ratio по trace id,
+// parent_ratio — как ratio для корневых спанов, а при наличии родителя его решение
+// сохраняется, чтобы трасса не рвалась между сервисами
+func newSampler(name string, ratio float64) sdktrace.Sampler {
+	switch name {
+	case "always":
+		return sdktrace.AlwaysSample()
+	case "never":
+		return sdktrace.NeverSample()
+	case "ratio":
+		return sdktrace.TraceIDRatioBased(ratio)
+	default:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	}
+}
+
+// SetSampler меняет сэмплер на лету (telemetry.sampler и telemetry.sample_ratio)
+func SetSampler(name string, ratio float64) {
+	sampler.set(newSampler(name, ratio))
+}
+
+// InitTracer настраивает глобальный TracerProvider по cfg и возвращает функцию его
+// остановки. Ошибки настройки не фатальны: сервис пишет предупреждение и работает
+// без трассировки. Недоступный коллектор тоже приводит только к предупреждениям
+func InitTracer(cfg config.TelemetryConfig) func() {
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("tracing error", logging.Err(err))
+	}))
+
+	tp, err := newTracerProvider(context.Background(), cfg)
+	if err != nil {
+		slog.Warn("tracing disabled", slog.String("exporter", cfg.Exporter), logging.Err(err))
+		return func() {}
+	}
+	otel.SetTracerProvider(tp)
+	slog.Info("tracing enabled", slog.String("exporter", cfg.Exporter), slog.String("sampler", cfg.Sampler))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			slog.Warn("failed to shut down tracer provider", logging.Err(err))
+		}
+	}
+}
+
+// newTracerProvider создаёт провайдер с экспортером, ресурсом и сэмплером из cfg
+func newTracerProvider(ctx context.Context, cfg config.TelemetryConfig) (*sdktrace.TracerProvider, error) {
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("build resource: %w", err)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", cfg.Exporter, err)
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// newExporter создаёт экспортер трейсов; для none возвращает nil
+func newExporter(ctx context.Context, cfg config.TelemetryConfig) (sdktrace.SpanExporter, error) {
+	var headers map[string]string
+	if cfg.OTLPHeaders != "" {
+		var err error
+		if headers, err = config.ParseKeyValues(cfg.OTLPHeaders); err != nil {
+			return nil, fmt.Errorf("otlp_headers: %w", err)
+		}
+	}
+
+	switch cfg.Exporter {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New()
+	case "otlp-grpc":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(headers)}
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPTLS.Enabled {
+			tlsCfg, err := clientTLSConfig(cfg.OTLPTLS)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		} else {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "otlp-http":
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(headers)}
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPTLS.Enabled {
+			tlsCfg, err := clientTLSConfig(cfg.OTLPTLS)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		} else {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	return nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+}
+
+// clientTLSConfig собирает TLS-настройки подключения к коллектору: свой корневой
+// сертификат вместо системных и клиентский сертификат для mTLS, если они заданы
+func clientTLSConfig(cfg config.OTLPTLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CA file contains no PEM certificates")
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// newResource описывает сервис в трейсах: service.name, deployment.environment
+// и атрибуты из telemetry.resource_attributes. Стандартные OTEL_RESOURCE_ATTRIBUTES
+// и OTEL_SERVICE_NAME тоже учитываются и имеют приоритет
+func newResource(ctx context.Context, cfg config.TelemetryConfig) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{semconv.ServiceName(cfg.ServiceName)}
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(cfg.Environment))
+	}
+	if cfg.ResourceAttributes != "" {
+		extra, err := config.ParseKeyValues(cfg.ResourceAttributes)
+		if err != nil {
+			return nil, fmt.Errorf("resource_attributes: %w", err)
+		}
+		for k, v := range extra {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+	}
+	return resource.New(ctx,
+		resource.WithAttributes(attrs...),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+	)
+}
@@ -0,0 +1,115 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"WBtech_l0/internal/config"
+)
+
+func testTelemetryConfig(exporter string) config.TelemetryConfig {
+	return config.TelemetryConfig{
+		Exporter:           exporter,
+		Sampler:            "parent_ratio",
+		SampleRatio:        1,
+		ServiceName:        "order-service-test",
+		Environment:        "test",
+		ResourceAttributes: "team=orders, region = eu",
+	}
+}
+
+func TestSetSampler(t *testing.T) {
+	t.Cleanup(func() { SetSampler("parent_ratio", 1) })
+
+	root := sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: trace.TraceID{0xff}}
+	sampledParent := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0xff},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	child := sdktrace.SamplingParameters{ParentContext: sampledParent, TraceID: trace.TraceID{0xff}}
+
+	tests := []struct {
+		name      string
+		ratio     float64
+		wantRoot  sdktrace.SamplingDecision
+		wantChild sdktrace.SamplingDecision
+	}{
+		{"always", 0, sdktrace.RecordAndSample, sdktrace.RecordAndSample},
+		{"never", 1, sdktrace.Drop, sdktrace.Drop},
+		{"ratio", 0, sdktrace.Drop, sdktrace.Drop},
+		// решение родителя важнее доли
+		{"parent_ratio", 0, sdktrace.Drop, sdktrace.RecordAndSample},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetSampler(tt.name, tt.ratio)
+			require.Equal(t, tt.wantRoot, sampler.ShouldSample(root).Decision)
+			require.Equal(t, tt.wantChild, sampler.ShouldSample(child).Decision)
+		})
+	}
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	ctx := context.Background()
+
+	for _, exporter := range []string{"none", "stdout", "otlp-http", "otlp-grpc"} {
+		t.Run(exporter, func(t *testing.T) {
+			cfg := testTelemetryConfig(exporter)
+			// коллектора нет: провайдер всё равно создаётся, ошибки будут только при экспорте
+			cfg.OTLPEndpoint = "127.0.0.1:1"
+			cfg.OTLPHeaders = "authorization=Bearer token"
+			tp, err := newTracerProvider(ctx, cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = tp.Shutdown(ctx) })
+		})
+	}
+}
+
+func TestNewTracerProvider_TLSErrors(t *testing.T) {
+	badCA := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(badCA, []byte("not a certificate"), 0o600))
+
+	for name, tlsCfg := range map[string]config.OTLPTLSConfig{
+		"missing CA":   {Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"invalid CA":   {Enabled: true, CAFile: badCA},
+		"missing cert": {Enabled: true, CertFile: "missing.pem", KeyFile: "missing-key.pem"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := testTelemetryConfig("otlp-grpc")
+			cfg.OTLPTLS = tlsCfg
+			_, err := newTracerProvider(context.Background(), cfg)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewResource(t *testing.T) {
+	res, err := newResource(context.Background(), testTelemetryConfig("none"))
+	require.NoError(t, err)
+
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range res.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	require.Equal(t, "order-service-test", attrs["service.name"])
+	require.Equal(t, "test", attrs["deployment.environment"])
+	require.Equal(t, "orders", attrs["team"])
+	require.Equal(t, "eu", attrs["region"])
+	require.NotContains(t, attrs, "environment")
+}
+
+func TestInitTracer_NoCrash(t *testing.T) {
+	cfg := testTelemetryConfig("otlp-http")
+	cfg.OTLPTLS = config.OTLPTLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}
+	// при ошибке настройки трассировка отключается, процесс продолжает работу
+	shutdown := InitTracer(cfg)
+	shutdown()
+}
@@ -3,9 +3,14 @@ package kafka
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -14,6 +19,8 @@ import (
 
 	"WBtech_l0/internal/config"
 	"WBtech_l0/internal/domain"
+	"WBtech_l0/internal/logging"
+	"WBtech_l0/internal/pii"
 	"WBtech_l0/internal/telemetry"
 
 	"github.com/segmentio/kafka-go"
@@ -21,19 +28,139 @@ import (
 
 var tracer = otel.Tracer("kafka-consumer")
 
-// ConsumeKafka подключаемся к Kafka и обрабатываем новые заказы
-func ConsumeKafka(ctx context.Context, cfg config.Config, usecase domain.OrderUsecase) {
+// Consumer читает заказы из Kafka и сохраняет их через usecase
+type Consumer struct {
+	cfg       config.Config
+	usecase   domain.OrderUsecase
+	dialer    *kafka.Dialer
+	transport *kafka.Transport
+	metrics   *telemetry.BusinessMetrics
+
+	running      atomic.Bool
+	retryBackoff atomic.Int64 // time.Duration, меняется при перечитывании конфига
+	mu           sync.Mutex
+	fetchErr     error         // последняя ошибка чтения из Kafka, nil после успешного чтения
+	resumed      chan struct{} // закрывается при Resume; nil — consumer не на паузе
+}
+
+// NewConsumer создаёт consumer. Чтение начинается после вызова Run.
+// В metrics учитываются сообщения, отправленные в DLQ
+func NewConsumer(cfg config.Config, usecase domain.OrderUsecase, metrics *telemetry.BusinessMetrics) (*Consumer, error) {
+	dialer, err := NewDialer(cfg.Kafka)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := NewTransport(cfg.Kafka)
+	if err != nil {
+		return nil, err
+	}
+	c := &Consumer{cfg: cfg, usecase: usecase, dialer: dialer, transport: transport, metrics: metrics}
+	c.SetRetryBackoff(cfg.Kafka.RetryBackoff)
+	return c, nil
+}
+
+// SetRetryBackoff задаёт паузу перед повторным чтением после ошибки брокера
+func (c *Consumer) SetRetryBackoff(d time.Duration) {
+	c.retryBackoff.Store(int64(d))
+}
+
+// Name реализует health.Checker
+func (c *Consumer) Name() string {
+	return "kafka"
+}
+
+// Check реализует health.Checker: consumer должен быть запущен, последнее чтение
+// должно быть успешным, а брокер — доступен
+func (c *Consumer) Check(ctx context.Context) error {
+	if !c.running.Load() {
+		return errors.New("consumer is not running")
+	}
+	c.mu.Lock()
+	fetchErr := c.fetchErr
+	c.mu.Unlock()
+	if fetchErr != nil {
+		return fmt.Errorf("last fetch failed: %w", fetchErr)
+	}
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.cfg.Kafka.Brokers)
+	if err != nil {
+		return fmt.Errorf("dial broker: %w", err)
+	}
+	if err := conn.Close(); err != nil {
+		slog.WarnContext(ctx, "failed to close Kafka health check connection", logging.Err(err))
+	}
+	return nil
+}
+
+// Pause приостанавливает обработку: уже прочитанное сообщение не обрабатывается
+// и не коммитится до Resume, новые не читаются. Повторный вызов ничего не меняет
+func (c *Consumer) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed == nil {
+		c.resumed = make(chan struct{})
+		slog.Info("Kafka consumer paused")
+	}
+}
+
+// Resume возобновляет обработку после Pause
+func (c *Consumer) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+		slog.Info("Kafka consumer resumed")
+	}
+}
+
+// Paused сообщает, приостановлен ли consumer
+func (c *Consumer) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resumed != nil
+}
+
+// waitResumed ждёт Resume, если consumer на паузе. false — контекст отменён раньше
+func (c *Consumer) waitResumed(ctx context.Context) bool {
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *Consumer) setFetchErr(err error) {
+	c.mu.Lock()
+	c.fetchErr = err
+	c.mu.Unlock()
+}
+
+// Run подключается к Kafka и обрабатывает новые заказы до отмены контекста
+func (c *Consumer) Run(ctx context.Context) {
+	cfg, usecase := c.cfg, c.usecase
+	c.running.Store(true)
+	defer c.running.Store(false)
+
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{cfg.Kafka.Brokers},
 		GroupID: cfg.Kafka.GroupID,
 		Topic:   cfg.Kafka.Topic,
+		Dialer:  c.dialer,
 	})
 	defer func() {
 		if err := r.Close(); err != nil {
-			log.Printf("failed to close Kafka reader: %v", err)
+			slog.Warn("failed to close Kafka reader", logging.Err(err))
 		}
 	}()
-	log.Printf("Kafka consumer started for topic: %s", cfg.Kafka.Topic)
+	slog.Info("Kafka consumer started", slog.String(logging.KeyTopic, cfg.Kafka.Topic), slog.String("dlq_topic", cfg.Kafka.DLQTopic))
 
 	// Создаём writer для DLQ
 	dlqWriter := &kafka.Writer{
@@ -42,25 +169,34 @@ func ConsumeKafka(ctx context.Context, cfg config.Config, usecase domain.OrderUs
 		Balancer:     &kafka.LeastBytes{},
 		RequiredAcks: kafka.RequireOne,
 		Async:        false,
+		Transport:    c.transport,
 	}
 	defer func() {
 		if err := dlqWriter.Close(); err != nil {
-			log.Printf("failed to close DLQ writer: %v", err)
+			slog.Warn("failed to close DLQ writer", logging.Err(err))
 		}
 	}()
-	log.Printf("DLQ topic: %s", cfg.Kafka.DLQTopic)
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Kafka consumer stopped")
+			slog.Info("Kafka consumer stopped")
 			return
 		default:
 			// FetchMessage для контроля над коммитами
 			m, err := r.FetchMessage(ctx)
 			if err != nil {
-				log.Printf("Kafka fetch error: %v", err)
-				time.Sleep(1 * time.Second)
+				if ctx.Err() != nil {
+					continue
+				}
+				slog.ErrorContext(ctx, "Kafka fetch failed", slog.String(logging.KeyTopic, cfg.Kafka.Topic), logging.Err(err))
+				c.setFetchErr(err)
+				time.Sleep(time.Duration(c.retryBackoff.Load()))
+				continue
+			}
+			c.setFetchErr(nil)
+			// На паузе сообщение остаётся незакоммиченным и после перезапуска будет прочитано снова
+			if !c.waitResumed(ctx) {
 				continue
 			}
 			// Начинаем спан для обработки сообщения
@@ -73,16 +209,19 @@ func ConsumeKafka(ctx context.Context, cfg config.Config, usecase domain.OrderUs
 				))
 			processStart := time.Now()
 			status := "success"
+			logger := slog.With(messageAttrs(m)...)
 
 			// Разбираем JSON
 			var order domain.Order
 			if err := json.Unmarshal(m.Value, &order); err != nil {
-				log.Printf("Invalid JSON, sending to DLQ: %v", err)
+				err = scrubError(err)
+				logger.WarnContext(ctx, "invalid JSON, sending to DLQ", logging.Err(err))
 				status = "error"
 				span.RecordError(err)
 				telemetry.OrdersProcessed.WithLabelValues("kafka", "error").Inc()
-				if dlqErr := sendToDLQ(ctx, dlqWriter, m, "invalid_json", err.Error()); dlqErr != nil {
-					log.Printf("Failed to send to DLQ: %v", dlqErr)
+				c.metrics.DLQ(telemetry.DLQReasonInvalidJSON)
+				if dlqErr := sendToDLQ(ctx, dlqWriter, m, telemetry.DLQReasonInvalidJSON, err.Error()); dlqErr != nil {
+					logger.ErrorContext(ctx, "failed to send to DLQ", logging.Err(dlqErr))
 				}
 				commitAndEnd(ctx, r, m, span, processStart, cfg.Kafka.Topic, status)
 				continue
@@ -90,12 +229,13 @@ func ConsumeKafka(ctx context.Context, cfg config.Config, usecase domain.OrderUs
 
 			// Игнорируем, если нет order_uid
 			if order.OrderUID == "" {
-				log.Printf("Message without order_uid, sending to DLQ")
+				logger.WarnContext(ctx, "message without order_uid, sending to DLQ")
 				status = "error"
 				span.SetAttributes(attribute.String("error", "missing_order_uid"))
 				telemetry.OrdersProcessed.WithLabelValues("kafka", "error").Inc()
-				if dlqErr := sendToDLQ(ctx, dlqWriter, m, "missing_order_uid", ""); dlqErr != nil {
-					log.Printf("Failed to send to DLQ: %v", dlqErr)
+				c.metrics.DLQ(telemetry.DLQReasonMissingOrderUID)
+				if dlqErr := sendToDLQ(ctx, dlqWriter, m, telemetry.DLQReasonMissingOrderUID, ""); dlqErr != nil {
+					logger.ErrorContext(ctx, "failed to send to DLQ", logging.Err(dlqErr))
 				}
 				commitAndEnd(ctx, r, m, span, processStart, cfg.Kafka.Topic, status)
 				continue
@@ -103,18 +243,21 @@ func ConsumeKafka(ctx context.Context, cfg config.Config, usecase domain.OrderUs
 
 			// Сохраняем заказ в транзакции
 			if err = usecase.SaveOrder(ctx, order); err != nil {
-				log.Printf("Failed to save order %s: %v", order.OrderUID, err)
+				err = scrubError(err)
+				logger.ErrorContext(ctx, "failed to save order, sending to DLQ", logging.OrderUID(order.OrderUID), logging.Err(err))
 				status = "error"
 				span.RecordError(err)
 				telemetry.OrdersProcessed.WithLabelValues("kafka", "error").Inc()
-				if dlqErr := sendToDLQ(ctx, dlqWriter, m, "save_failed", err.Error()); dlqErr != nil {
-					log.Printf("Failed to send to DLQ: %v", dlqErr)
+				c.metrics.DLQ(telemetry.DLQReasonSaveFailed)
+				if dlqErr := sendToDLQ(ctx, dlqWriter, m, telemetry.DLQReasonSaveFailed, err.Error()); dlqErr != nil {
+					logger.ErrorContext(ctx, "failed to send to DLQ", logging.Err(dlqErr))
 				}
 				commitAndEnd(ctx, r, m, span, processStart, cfg.Kafka.Topic, status)
 				continue
 			}
 
 			// Успех
+			logger.DebugContext(ctx, "order saved", logging.OrderUID(order.OrderUID))
 			telemetry.OrdersProcessed.WithLabelValues("kafka", "success").Inc()
 			span.SetAttributes(attribute.String("order_uid", order.OrderUID))
 			commitAndEnd(ctx, r, m, span, processStart, cfg.Kafka.Topic, status)
@@ -128,27 +271,58 @@ func commitAndEnd(ctx context.Context, r *kafka.Reader, m kafka.Message, span tr
 	telemetry.KafkaMessageProcessDuration.WithLabelValues(topic, status).Observe(duration)
 	span.End()
 	if err := r.CommitMessages(ctx, m); err != nil {
-		log.Printf("Failed to commit message: %v", err)
+		slog.ErrorContext(ctx, "failed to commit message", append(messageAttrs(m), logging.Err(err))...)
+	}
+}
+
+// messageAttrs возвращает атрибуты лога, идентифицирующие сообщение Kafka
+func messageAttrs(m kafka.Message) []any {
+	return []any{
+		slog.String(logging.KeyTopic, m.Topic),
+		slog.Int(logging.KeyPartition, m.Partition),
+		slog.Int64(logging.KeyOffset, m.Offset),
 	}
 }
 
+// scrubError маскирует персональные данные в тексте ошибки перед записью в лог или спан
+func scrubError(err error) error {
+	return errors.New(pii.Scrub(err.Error()))
+}
+
+// dlqMessage — конверт сообщения в DLQ
+type dlqMessage struct {
+	OriginalMessage json.RawMessage `json:"original_message"`
+	OriginalSHA256  string          `json:"original_sha256"`
+	OriginalSize    int             `json:"original_size"`
+	Reason          string          `json:"reason"`
+	Details         string          `json:"details"`
+	Timestamp       int64           `json:"timestamp"`
+}
+
+// newDLQMessage собирает конверт DLQ. Персональные данные исходного сообщения
+// маскируются; если сообщение не удаётся разобрать как JSON, тело не копируется
+// вовсе, а для сверки остаются только его хеш и размер
+func newDLQMessage(original []byte, reason, details string) dlqMessage {
+	sum := sha256.Sum256(original)
+	msg := dlqMessage{
+		OriginalSHA256: hex.EncodeToString(sum[:]),
+		OriginalSize:   len(original),
+		Reason:         reason,
+		Details:        pii.Scrub(details),
+		Timestamp:      time.Now().Unix(),
+	}
+	if redacted, ok := pii.RedactJSON(original); ok {
+		msg.OriginalMessage = redacted
+	}
+	return msg
+}
+
 // sendToDLQ отправляет сообщение в DLQ с информацией об ошибке
 func sendToDLQ(ctx context.Context, writer *kafka.Writer, originalMsg kafka.Message, reason string, details string) error {
-	dlqMsg := struct {
-		OriginalMessage json.RawMessage `json:"original_message"`
-		Reason          string          `json:"reason"`
-		Details         string          `json:"details"`
-		Timestamp       int64           `json:"timestamp"`
-	}{
-		OriginalMessage: originalMsg.Value,
-		Reason:          reason,
-		Details:         details,
-		Timestamp:       time.Now().Unix(),
-	}
+	dlqMsg := newDLQMessage(originalMsg.Value, reason, details)
 
 	data, err := json.Marshal(dlqMsg)
 	if err != nil {
-		log.Printf("Failed to marshal DLQ message: %v", err)
 		return fmt.Errorf("marshal DLQ message: %w", err)
 	}
 
@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDLQMessage_RedactsPII(t *testing.T) {
+	raw := []byte(`{"order_uid":"b563feb7b2b84b6test","delivery":{"name":"Test Testov","phone":"+9720000000","email":"test@gmail.com","city":"Kiryat Mozkin"}}`)
+
+	msg := newDLQMessage(raw, "save_failed", "duplicate delivery for test@gmail.com")
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	body := string(data)
+	require.NotContains(t, body, "Test Testov")
+	require.NotContains(t, body, "+9720000000")
+	require.NotContains(t, body, "test@gmail.com")
+	require.Contains(t, body, "b563feb7b2b84b6test")
+	require.Contains(t, body, "Kiryat Mozkin")
+	require.Equal(t, len(raw), msg.OriginalSize)
+}
+
+func TestNewDLQMessage_InvalidJSON(t *testing.T) {
+	raw := []byte(`{"delivery":{"phone":"+79001234567"`)
+
+	msg := newDLQMessage(raw, "invalid_json", "unexpected end of JSON input")
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	require.Nil(t, msg.OriginalMessage)
+	require.False(t, strings.Contains(string(data), "+79001234567"))
+	require.NotEmpty(t, msg.OriginalSHA256)
+}
+
+func TestConsumer_PauseResume(t *testing.T) {
+	c := &Consumer{}
+	require.True(t, c.waitResumed(context.Background()))
+
+	c.Pause()
+	c.Pause()
+	require.True(t, c.Paused())
+
+	// на паузе обработка ждёт Resume или отмены контекста
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.False(t, c.waitResumed(ctx))
+
+	done := make(chan bool)
+	go func() { done <- c.waitResumed(context.Background()) }()
+	c.Resume()
+	require.True(t, <-done)
+	require.False(t, c.Paused())
+	c.Resume()
+}
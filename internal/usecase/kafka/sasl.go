@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"WBtech_l0/internal/config"
+)
+
+// SASLMechanism возвращает механизм аутентификации по kafka.sasl; nil — без аутентификации
+func SASLMechanism(cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	switch cfg.Mechanism {
+	case "", "none":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	}
+	return nil, fmt.Errorf("unsupported SASL mechanism %q", cfg.Mechanism)
+}
+
+// NewDialer создаёт Dialer для Reader и прямых подключений с учётом SASL
+func NewDialer(cfg config.KafkaConfig) (*kafka.Dialer, error) {
+	m, err := SASLMechanism(cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, SASLMechanism: m}, nil
+}
+
+// NewTransport создаёт Transport для Writer с учётом SASL
+func NewTransport(cfg config.KafkaConfig) (*kafka.Transport, error) {
+	m, err := SASLMechanism(cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Transport{SASL: m}, nil
+}
@@ -9,15 +9,28 @@ import (
 )
 
 type orderUsecase struct {
-	repo  domain.OrderRepository
-	cache domain.OrderCache
+	repo      domain.OrderRepository
+	cache     domain.OrderCache
+	publisher domain.OrderPublisher
 }
 
-// NewOrderUsecase создаёт новый экземпляр usecase
-func NewOrderUsecase(repo domain.OrderRepository, cache domain.OrderCache) domain.OrderUsecase {
+// NewOrderUsecase создаёт новый экземпляр usecase.
+// publisher может быть nil, если события о заказах никому не нужны
+func NewOrderUsecase(repo domain.OrderRepository, cache domain.OrderCache, publisher domain.OrderPublisher) domain.OrderUsecase {
 	return &orderUsecase{
-		repo:  repo,
-		cache: cache,
+		repo:      repo,
+		cache:     cache,
+		publisher: publisher,
+	}
+}
+
+// Publishers рассылает событие о сохранённом заказе нескольким получателям по порядку
+type Publishers []domain.OrderPublisher
+
+// PublishOrder реализует domain.OrderPublisher
+func (p Publishers) PublishOrder(order domain.Order) {
+	for _, pub := range p {
+		pub.PublishOrder(order)
 	}
 }
 
@@ -39,11 +52,79 @@ func (u *orderUsecase) GetOrder(ctx context.Context, orderUID string) (domain.Or
 	return order, nil
 }
 
-// SaveOrder сохраняет в БД и обновляет кеш
+// TrackParcel ищет посылку по трек-номеру заказа или товара. Кеш не используется:
+// он индексирован по order_uid
+func (u *orderUsecase) TrackParcel(ctx context.Context, trackNumber string) (domain.Parcel, error) {
+	orders, err := u.repo.FindByTrackNumber(ctx, trackNumber)
+	if err != nil {
+		return domain.Parcel{}, fmt.Errorf("repo.FindByTrackNumber: %w", err)
+	}
+	return domain.NewParcel(trackNumber, orders)
+}
+
+// GetOrders отдаёт заказы из кеша, а промахи загружает из БД одним пакетным запросом
+// и кладёт в кеш. Повторяющиеся order_uid обрабатываются один раз
+func (u *orderUsecase) GetOrders(ctx context.Context, orderUIDs []string) ([]domain.Order, []string, error) {
+	unique := make([]string, 0, len(orderUIDs))
+	seen := make(map[string]struct{}, len(orderUIDs))
+	for _, uid := range orderUIDs {
+		if _, ok := seen[uid]; !ok {
+			seen[uid] = struct{}{}
+			unique = append(unique, uid)
+		}
+	}
+
+	found := make(map[string]domain.Order, len(unique))
+	var misses []string
+	for _, uid := range unique {
+		if order, ok := u.cache.Get(uid); ok {
+			found[uid] = order
+		} else {
+			misses = append(misses, uid)
+		}
+	}
+
+	if len(misses) > 0 {
+		loaded, err := u.repo.GetOrders(ctx, misses)
+		if err != nil {
+			return nil, nil, fmt.Errorf("repo.GetOrders: %w", err)
+		}
+		for _, order := range loaded {
+			u.cache.Set(order)
+			found[order.OrderUID] = order
+		}
+	}
+
+	orders := make([]domain.Order, 0, len(found))
+	var missing []string
+	for _, uid := range unique {
+		if order, ok := found[uid]; ok {
+			orders = append(orders, order)
+		} else {
+			missing = append(missing, uid)
+		}
+	}
+	return orders, missing, nil
+}
+
+// SaveOrder сохраняет в БД, обновляет кеш и публикует событие для потоковых API
 func (u *orderUsecase) SaveOrder(ctx context.Context, order domain.Order) error {
 	if err := u.repo.SaveOrder(ctx, order); err != nil {
 		return err
 	}
 	u.cache.Set(order)
+	if u.publisher != nil {
+		u.publisher.PublishOrder(order)
+	}
 	return nil
 }
+
+// ListOrders возвращает страницу заказов в порядке order_uid, начиная после afterUID.
+// Страницы читаются из БД: кеш хранит не все заказы и не упорядочен
+func (u *orderUsecase) ListOrders(ctx context.Context, afterUID string, limit int) ([]domain.Order, error) {
+	orders, err := u.repo.ListOrders(ctx, afterUID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repo.ListOrders: %w", err)
+	}
+	return orders, nil
+}
@@ -13,7 +13,10 @@ import (
 type MockRepository struct {
 	SaveOrderFunc     func(ctx context.Context, order domain.Order) error
 	GetOrderFunc      func(ctx context.Context, orderUID string) (domain.Order, error)
+	GetOrdersFunc     func(ctx context.Context, orderUIDs []string) ([]domain.Order, error)
 	LoadAllOrdersFunc func(ctx context.Context) ([]domain.Order, error)
+	ListOrdersFunc    func(ctx context.Context, afterUID string, limit int) ([]domain.Order, error)
+	FindByTrackFunc   func(ctx context.Context, trackNumber string) ([]domain.Order, error)
 	ClearAllFunc      func(ctx context.Context) error
 }
 
@@ -23,9 +26,18 @@ func (m *MockRepository) SaveOrder(ctx context.Context, order domain.Order) erro
 func (m *MockRepository) GetOrder(ctx context.Context, orderUID string) (domain.Order, error) {
 	return m.GetOrderFunc(ctx, orderUID)
 }
+func (m *MockRepository) GetOrders(ctx context.Context, orderUIDs []string) ([]domain.Order, error) {
+	return m.GetOrdersFunc(ctx, orderUIDs)
+}
 func (m *MockRepository) LoadAllOrders(ctx context.Context) ([]domain.Order, error) {
 	return m.LoadAllOrdersFunc(ctx)
 }
+func (m *MockRepository) ListOrders(ctx context.Context, afterUID string, limit int) ([]domain.Order, error) {
+	return m.ListOrdersFunc(ctx, afterUID, limit)
+}
+func (m *MockRepository) FindByTrackNumber(ctx context.Context, trackNumber string) ([]domain.Order, error) {
+	return m.FindByTrackFunc(ctx, trackNumber)
+}
 func (m *MockRepository) ClearAll(ctx context.Context) error {
 	return m.ClearAllFunc(ctx)
 }
@@ -80,7 +92,7 @@ func TestOrderUsecase_GetOrder_CacheHit(t *testing.T) {
 			return domain.Order{}, nil
 		},
 	}
-	usecase := NewOrderUsecase(repo, cache)
+	usecase := NewOrderUsecase(repo, cache, nil)
 
 	// when
 	order, err := usecase.GetOrder(context.Background(), "test-uid")
@@ -117,7 +129,7 @@ func TestOrderUsecase_GetOrder_CacheMiss_RepoSuccess(t *testing.T) {
 			return domain.Order{}, errors.New("not found")
 		},
 	}
-	usecase := NewOrderUsecase(repo, cache)
+	usecase := NewOrderUsecase(repo, cache, nil)
 
 	// when
 	order, err := usecase.GetOrder(context.Background(), "test-uid")
@@ -147,7 +159,7 @@ func TestOrderUsecase_GetOrder_RepoError(t *testing.T) {
 			return domain.Order{}, repoErr
 		},
 	}
-	usecase := NewOrderUsecase(repo, cache)
+	usecase := NewOrderUsecase(repo, cache, nil)
 
 	// when
 	_, err := usecase.GetOrder(context.Background(), "test-uid")
@@ -183,7 +195,7 @@ func TestOrderUsecase_SaveOrder(t *testing.T) {
 			cacheCalled = true
 		},
 	}
-	usecase := NewOrderUsecase(repo, cache)
+	usecase := NewOrderUsecase(repo, cache, nil)
 
 	// when
 	err := usecase.SaveOrder(context.Background(), order)
@@ -213,7 +225,7 @@ func TestOrderUsecase_SaveOrder_RepoError(t *testing.T) {
 			t.Error("cache.Set should not be called on repo error")
 		},
 	}
-	usecase := NewOrderUsecase(repo, cache)
+	usecase := NewOrderUsecase(repo, cache, nil)
 
 	// when
 	err := usecase.SaveOrder(context.Background(), domain.Order{OrderUID: "test"})
@@ -226,3 +238,194 @@ func TestOrderUsecase_SaveOrder_RepoError(t *testing.T) {
 		t.Errorf("expected repoErr, got %v", err)
 	}
 }
+
+// MockPublisher — мок публикации событий о заказах.
+type MockPublisher struct {
+	Published []domain.Order
+}
+
+func (m *MockPublisher) PublishOrder(order domain.Order) {
+	m.Published = append(m.Published, order)
+}
+
+func TestOrderUsecase_SaveOrder_PublishesEvent(t *testing.T) {
+	// given
+	repoErr := errors.New("save failed")
+	fail := false
+	repo := &MockRepository{
+		SaveOrderFunc: func(_ context.Context, _ domain.Order) error {
+			if fail {
+				return repoErr
+			}
+			return nil
+		},
+	}
+	cache := &MockCache{SetFunc: func(_ domain.Order) {}}
+	publisher := &MockPublisher{}
+	usecase := NewOrderUsecase(repo, cache, publisher)
+
+	// when
+	if err := usecase.SaveOrder(context.Background(), domain.Order{OrderUID: "ok"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fail = true
+	_ = usecase.SaveOrder(context.Background(), domain.Order{OrderUID: "failed"})
+
+	// then
+	if len(publisher.Published) != 1 || publisher.Published[0].OrderUID != "ok" {
+		t.Errorf("expected exactly one event for saved order, got %+v", publisher.Published)
+	}
+}
+
+func TestPublishers_PublishOrder(t *testing.T) {
+	first, second := &MockPublisher{}, &MockPublisher{}
+	Publishers{first, second}.PublishOrder(domain.Order{OrderUID: "ok"})
+
+	for i, p := range []*MockPublisher{first, second} {
+		if len(p.Published) != 1 || p.Published[0].OrderUID != "ok" {
+			t.Errorf("publisher %d: expected one event, got %+v", i, p.Published)
+		}
+	}
+}
+
+func TestOrderUsecase_ListOrders(t *testing.T) {
+	repo := &MockRepository{
+		ListOrdersFunc: func(_ context.Context, afterUID string, limit int) ([]domain.Order, error) {
+			if afterUID != "a" || limit != 2 {
+				t.Errorf("ListOrders called with %q, %d", afterUID, limit)
+			}
+			return []domain.Order{{OrderUID: "b"}, {OrderUID: "c"}}, nil
+		},
+	}
+	usecase := NewOrderUsecase(repo, &MockCache{}, nil)
+
+	orders, err := usecase.ListOrders(context.Background(), "a", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(orders) != 2 || orders[0].OrderUID != "b" {
+		t.Errorf("unexpected orders: %+v", orders)
+	}
+}
+
+func TestOrderUsecase_GetOrders(t *testing.T) {
+	// given: "a" в кеше, "b" только в БД, "c" нигде нет
+	var repoUIDs []string
+	var cached []string
+	repo := &MockRepository{
+		GetOrdersFunc: func(_ context.Context, uids []string) ([]domain.Order, error) {
+			repoUIDs = uids
+			return []domain.Order{{OrderUID: "b"}}, nil
+		},
+	}
+	cache := &MockCache{
+		GetFunc: func(uid string) (domain.Order, bool) {
+			if uid == "a" {
+				return domain.Order{OrderUID: "a"}, true
+			}
+			return domain.Order{}, false
+		},
+		SetFunc: func(o domain.Order) { cached = append(cached, o.OrderUID) },
+	}
+	usecase := NewOrderUsecase(repo, cache, nil)
+
+	// when
+	orders, missing, err := usecase.GetOrders(context.Background(), []string{"c", "b", "a", "b"})
+
+	// then: один запрос в БД только за промахами, порядок как в запросе
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repoUIDs) != 2 || repoUIDs[0] != "c" || repoUIDs[1] != "b" {
+		t.Errorf("expected repo to be queried for [c b], got %v", repoUIDs)
+	}
+	if len(orders) != 2 || orders[0].OrderUID != "b" || orders[1].OrderUID != "a" {
+		t.Errorf("unexpected orders: %+v", orders)
+	}
+	if len(missing) != 1 || missing[0] != "c" {
+		t.Errorf("unexpected missing: %v", missing)
+	}
+	if len(cached) != 1 || cached[0] != "b" {
+		t.Errorf("expected loaded order to be cached, got %v", cached)
+	}
+}
+
+func TestOrderUsecase_GetOrders_AllCached(t *testing.T) {
+	repo := &MockRepository{
+		GetOrdersFunc: func(_ context.Context, _ []string) ([]domain.Order, error) {
+			t.Error("repo must not be queried when all orders are cached")
+			return nil, nil
+		},
+	}
+	cache := &MockCache{
+		GetFunc: func(uid string) (domain.Order, bool) { return domain.Order{OrderUID: uid}, true },
+	}
+	orders, missing, err := NewOrderUsecase(repo, cache, nil).GetOrders(context.Background(), []string{"a", "b"})
+	if err != nil || len(orders) != 2 || len(missing) != 0 {
+		t.Errorf("unexpected result: %+v %v %v", orders, missing, err)
+	}
+}
+
+func TestOrderUsecase_TrackParcel(t *testing.T) {
+	// given: у заказа свой трек, один товар уехал отдельной посылкой
+	order := domain.Order{
+		OrderUID:    "o1",
+		TrackNumber: "ORDERTRACK",
+		Locale:      "en",
+		Delivery:    domain.Delivery{Name: "Ivan", Phone: "+79990000000", City: "Kazan"},
+		Payment:     domain.Payment{Transaction: "secret", Amount: 100},
+		Items: []domain.Item{
+			{Name: "Shoes", TrackNumber: "ORDERTRACK", Status: 202},
+			{Name: "Hat", TrackNumber: "", Status: 100},
+			{Name: "Bag", TrackNumber: "ITEMTRACK", Status: 202},
+		},
+	}
+	repo := &MockRepository{
+		FindByTrackFunc: func(_ context.Context, track string) ([]domain.Order, error) {
+			if track == "ORDERTRACK" || track == "ITEMTRACK" {
+				return []domain.Order{order}, nil
+			}
+			return nil, nil
+		},
+	}
+	usecase := NewOrderUsecase(repo, &MockCache{}, nil)
+	ctx := context.Background()
+
+	// when/then: по треку заказа — товары без своего трека и с тем же треком
+	parcel, err := usecase.TrackParcel(ctx, "ORDERTRACK")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parcel.City != "Kazan" || parcel.Locale != "en" || len(parcel.Items) != 2 ||
+		parcel.Items[0].Name != "Shoes" || parcel.Items[1].Name != "Hat" {
+		t.Errorf("unexpected parcel: %+v", parcel)
+	}
+
+	// по треку товара — только этот товар
+	parcel, err = usecase.TrackParcel(ctx, "ITEMTRACK")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(parcel.Items) != 1 || parcel.Items[0].Name != "Bag" || parcel.Items[0].Status != 202 {
+		t.Errorf("unexpected parcel: %+v", parcel)
+	}
+
+	if _, err := usecase.TrackParcel(ctx, "UNKNOWN"); !errors.Is(err, domain.ErrParcelNotFound) {
+		t.Errorf("expected ErrParcelNotFound, got %v", err)
+	}
+}
+
+func TestOrderUsecase_TrackParcel_OrderTrackWithoutOwnItems(t *testing.T) {
+	// все товары с собственными треками: по треку заказа показываются все
+	repo := &MockRepository{
+		FindByTrackFunc: func(_ context.Context, _ string) ([]domain.Order, error) {
+			return []domain.Order{{TrackNumber: "ORDERTRACK", Items: []domain.Item{
+				{Name: "A", TrackNumber: "T1"}, {Name: "B", TrackNumber: "T2"},
+			}}}, nil
+		},
+	}
+	parcel, err := NewOrderUsecase(repo, &MockCache{}, nil).TrackParcel(context.Background(), "ORDERTRACK")
+	if err != nil || len(parcel.Items) != 2 {
+		t.Errorf("unexpected result: %+v %v", parcel, err)
+	}
+}
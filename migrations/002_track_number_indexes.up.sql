@@ -0,0 +1,5 @@
+-- Поиск посылки по трек-номеру: трек заказа. Индекс для треков товаров — в 003.
+-- CONCURRENTLY не блокирует запись в orders на время построения, но не работает внутри
+-- транзакции, поэтому в файле миграции ровно один оператор (migrate выполняет его без BEGIN).
+-- Если построение прервалось, индекс остаётся INVALID: удалите его и повторите миграцию
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_orders_track_number ON orders (track_number);
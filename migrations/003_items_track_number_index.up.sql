@@ -0,0 +1,4 @@
+-- Поиск посылки по трек-номеру отдельного товара. Отдельная миграция: CREATE INDEX
+-- CONCURRENTLY выполняется вне транзакции, по одному оператору на файл (см. 002).
+-- Если построение прервалось, индекс остаётся INVALID: удалите его и повторите миграцию
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_items_track_number ON items (track_number);
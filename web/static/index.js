@@ -0,0 +1,109 @@
+// ИЗМЕНЕНО: Функция переключения вкладок
+function switchTab(tab) {
+    document.querySelectorAll('.tab-btn').forEach(btn => btn.classList.remove('active'));
+    document.getElementById('html-tab').style.display = 'none';
+    document.getElementById('json-tab').style.display = 'none';
+
+    if (tab === 'html') {
+        document.querySelector('.tab-btn:first-child').classList.add('active');
+        document.getElementById('html-tab').style.display = 'block';
+    } else {
+        document.querySelector('.tab-btn:last-child').classList.add('active');
+        document.getElementById('json-tab').style.display = 'block';
+    }
+}
+
+// ИЗМЕНЕНО: Существующая функция для HTML (переименована для ясности)
+function searchHTML() {
+    const orderId = document.getElementById('htmlOrderId').value.trim();
+    if (orderId) {
+        window.location.href = '/order/' + encodeURIComponent(orderId);
+    } else {
+        alert('Пожалуйста, введите Order UID');
+    }
+}
+
+// ИЗМЕНЕНО: Новая функция для JSON API
+async function searchJSON() {
+    const orderId = document.getElementById('jsonOrderId').value.trim();
+    const resultDiv = document.getElementById('json-result');
+    const errorDiv = document.getElementById('json-error');
+    const jsonDisplay = document.getElementById('json-display');
+
+    resultDiv.style.display = 'none';
+    errorDiv.style.display = 'none';
+
+    if (!orderId) {
+        errorDiv.textContent = 'Пожалуйста, введите Order UID';
+        errorDiv.style.display = 'block';
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/v1/orders/' + encodeURIComponent(orderId));
+        const data = await response.json();
+
+        if (response.ok && data.success) {
+            jsonDisplay.textContent = JSON.stringify(data.data, null, 2);
+            resultDiv.style.display = 'block';
+        } else {
+            errorDiv.textContent = data.error || 'Заказ не найден';
+            errorDiv.style.display = 'block';
+        }
+    } catch (err) {
+        errorDiv.textContent = 'Ошибка при запросе: ' + err.message;
+        errorDiv.style.display = 'block';
+    }
+}
+
+// ИЗМЕНЕНО: Поиск по Enter для обоих полей
+document.getElementById('htmlOrderId').addEventListener('keypress', function (e) {
+    if (e.key === 'Enter') {
+        searchHTML();
+    }
+});
+
+document.getElementById('jsonOrderId').addEventListener('keypress', function (e) {
+    if (e.key === 'Enter') {
+        searchJSON();
+    }
+});
+
+// Лента новых заказов. EventSource сам переподключается и передаёт Last-Event-ID
+function startLiveFeed() {
+    const status = document.getElementById('live-status');
+    const list = document.getElementById('live-list');
+    const maxItems = 20;
+    const source = new EventSource('/api/v1/orders/stream');
+
+    source.onopen = function () {
+        status.textContent = '● онлайн';
+        status.classList.add('connected');
+    };
+    source.onerror = function () {
+        status.textContent = '● переподключение...';
+        status.classList.remove('connected');
+    };
+    source.addEventListener('order.saved', function (e) {
+        const event = JSON.parse(e.data);
+        const order = event.order;
+
+        const item = document.createElement('li');
+        const link = document.createElement('a');
+        link.href = '/order/' + encodeURIComponent(order.order_uid);
+        link.textContent = order.order_uid;
+        const meta = document.createElement('span');
+        meta.className = 'live-meta';
+        meta.textContent = [order.entry, order.delivery_service, new Date(event.time).toLocaleTimeString()]
+            .filter(Boolean).join(' · ');
+        item.appendChild(link);
+        item.appendChild(meta);
+
+        list.insertBefore(item, list.firstChild);
+        while (list.children.length > maxItems) {
+            list.removeChild(list.lastChild);
+        }
+    });
+}
+
+startLiveFeed();
@@ -0,0 +1,10 @@
+// Package web содержит HTML-шаблоны и статические файлы веб-интерфейса,
+// встроенные в бинарник
+package web
+
+import "embed"
+
+// FS — встроенные шаблоны (*.html) и статические файлы (static/)
+//
+//go:embed *.html static
+var FS embed.FS